@@ -0,0 +1,105 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errAsyncQueueFull = errors.New("async write queue full")
+	errAsyncClosed    = errors.New("cache closed")
+)
+
+// asyncOp is a queued SetAsync store write, or a delete when del is set.
+type asyncOp[K comparable, V any] struct {
+	ctx    context.Context //nolint:containedctx // caller's context, detached from its cancellation
+	expiry time.Time
+	key    K
+	value  V
+	del    bool
+}
+
+// asyncWriters runs SetAsync store writes on a fixed pool of goroutines fed by a
+// bounded queue (see AsyncWorkers). Workers start on the first write.
+//
+//nolint:govet // fieldalignment - mutex kept next to the state it guards
+type asyncWriters[K comparable, V any] struct {
+	write        func(asyncOp[K, V])
+	workers      int
+	timeout      time.Duration // longest wait for room in a full queue
+	dropWhenFull bool
+
+	start sync.Once
+	wg    sync.WaitGroup
+
+	// mu is held for reading while enqueueing and for writing by close, so no write
+	// is sent on a closed queue.
+	mu     sync.RWMutex
+	queue  chan asyncOp[K, V]
+	closed bool
+}
+
+func newAsyncWriters[K comparable, V any](
+	workers, queue int, timeout time.Duration, dropWhenFull bool, write func(asyncOp[K, V]),
+) *asyncWriters[K, V] {
+	return &asyncWriters[K, V]{
+		write:        write,
+		workers:      workers,
+		timeout:      timeout,
+		dropWhenFull: dropWhenFull,
+		queue:        make(chan asyncOp[K, V], queue),
+	}
+}
+
+// add queues op, waiting for room unless dropWhenFull, for at most timeout or until
+// ctx is done. It returns why op was not queued.
+func (a *asyncWriters[K, V]) add(ctx context.Context, op asyncOp[K, V]) error {
+	a.start.Do(func() {
+		for range a.workers {
+			a.wg.Go(func() {
+				for op := range a.queue {
+					a.write(op)
+				}
+			})
+		}
+	})
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return errAsyncClosed
+	}
+	select {
+	case a.queue <- op:
+		return nil
+	default:
+	}
+	if a.dropWhenFull {
+		return errAsyncQueueFull
+	}
+
+	t := time.NewTimer(a.timeout)
+	defer t.Stop()
+	select {
+	case a.queue <- op:
+		return nil
+	case <-t.C:
+		return errAsyncQueueFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting writes and waits for the workers to drain the queue.
+func (a *asyncWriters[K, V]) close() {
+	a.start.Do(func() {}) // never start workers after close; wait for a concurrent start
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	a.wg.Wait()
+}
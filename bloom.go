@@ -15,6 +15,12 @@ type bloomFilter struct {
 
 // newBloomFilter creates a filter sized for capacity at fpRate false positive rate.
 func newBloomFilter(capacity int, fpRate float64) *bloomFilter {
+	return newBloomFilterMax(capacity, fpRate, 0)
+}
+
+// newBloomFilterMax is newBloomFilter with the bit array capped at maxBytes (0 means
+// no cap). A capped filter keeps its capacity and accepts a higher false positive rate.
+func newBloomFilterMax(capacity int, fpRate float64, maxBytes int64) *bloomFilter {
 	if capacity < 1 {
 		capacity = 1
 	}
@@ -23,6 +29,12 @@ func newBloomFilter(capacity int, fpRate float64) *bloomFilter {
 	m := float64(capacity) * -math.Log(fpRate) / (ln2 * ln2)
 
 	mInt := uint64(1) << bits.Len64(uint64(m)-1) // round up to power of 2
+	if maxBytes > 0 {
+		//nolint:gosec // G115: maxBytes is positive
+		if limit := uint64(1) << (bits.Len64(uint64(maxBytes)*8) - 1); mInt > limit { // round down to power of 2
+			mInt = limit
+		}
+	}
 	mInt = max(mInt, 64)
 
 	k := min(max(int(float64(mInt)/float64(capacity)*ln2), 1), 16)
@@ -34,6 +46,16 @@ func newBloomFilter(capacity int, fpRate float64) *bloomFilter {
 	}
 }
 
+// bytes returns the size of the filter's bit array.
+func (b *bloomFilter) bytes() int { return len(b.data) * 8 }
+
+// fpRate estimates the current false positive rate from the number of entries added.
+func (b *bloomFilter) fpRate() float64 {
+	m := float64(len(b.data) * 64)
+	k := float64(b.k)
+	return math.Pow(1-math.Exp(-k*float64(b.entries)/m), k)
+}
+
 func (b *bloomFilter) Add(h uint64) {
 	h1 := h
 	h2 := h >> 32
@@ -2,32 +2,33 @@
 package fido
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"iter"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v4"
 )
 
-// calculateExpiry returns the expiry time for a given TTL, falling back to defaultTTL.
-// Returns zero Time (no expiry) if both TTL and defaultTTL are zero or negative.
-func calculateExpiry(ttl, defaultTTL time.Duration) time.Time {
-	if ttl <= 0 {
-		ttl = defaultTTL
-	}
-	if ttl <= 0 {
-		return time.Time{}
-	}
-	return time.Now().Add(ttl)
-}
-
 // Cache is an in-memory cache. All operations are synchronous and infallible.
 type Cache[K comparable, V any] struct {
 	flights    *xsync.Map[K, *flightCall[V]]
+	scoped     *xsync.Map[scopedKey[K], *flightCall[V]] // FetchScoped flights
+	ctxFlights *xsync.Map[K, *ctxFlight[V]]             // FetchContext flights
 	memory     *s3fifo[K, V]
+	sampler    *latencySampler
+	tags       *tagIndex[K, V]
+	soft       *softIndex[K, V] // FetchStale soft deadlines
 	defaultTTL time.Duration
 }
 
+// errNotLoaded marks a flight whose batch loader did not return a value for the key.
+// Waiters treat it as "no result" rather than as a failure.
+var errNotLoaded = errors.New("key not returned by batch loader")
+
 // flightCall holds an in-flight computation for singleflight deduplication.
 //
 //nolint:govet // fieldalignment: semantic grouping preferred
@@ -44,18 +45,85 @@ func New[K comparable, V any](opts ...Option) *Cache[K, V] {
 		opt(cfg)
 	}
 
+	if err := checkOptionTypes[K, V](cfg); err != nil {
+		panic(err)
+	}
+
+	memory := newS3FIFO[K, V](cfg)
 	return &Cache[K, V]{
 		flights:    xsync.NewMap[K, *flightCall[V]](),
-		memory:     newS3FIFO[K, V](cfg),
-		defaultTTL: cfg.defaultTTL,
+		scoped:     xsync.NewMap[scopedKey[K], *flightCall[V]](),
+		ctxFlights: xsync.NewMap[K, *ctxFlight[V]](),
+		memory:     memory,
+		sampler:    newLatencySampler(cfg.sampleRate, cfg.sampleSink),
+		tags:       newTagIndex[K, V](),
+		soft:       newSoftIndex[K, V](),
+		defaultTTL: memory.clampTTL(cfg.defaultTTL),
 	}
 }
 
+// checkOptionTypes reports a KeyValidator or Cost function whose key or value type
+// differs from the cache's.
+func checkOptionTypes[K comparable, V any](cfg *config) error {
+	if cfg.keyValidator != nil {
+		if _, ok := cfg.keyValidator.(func(K) error); !ok {
+			var zero K
+			return fmt.Errorf("KeyValidator is %T, want func(%T) error", cfg.keyValidator, zero)
+		}
+	}
+	if cfg.hasher != nil {
+		if _, ok := cfg.hasher.(func(K) uint64); !ok {
+			var zero K
+			return fmt.Errorf("Hasher is %T, want func(%T) uint64", cfg.hasher, zero)
+		}
+	}
+	if cfg.costFn != nil {
+		if _, ok := cfg.costFn.(func(V) int64); !ok {
+			var zero V
+			return fmt.Errorf("Cost is %T, want func(%T) int64", cfg.costFn, zero)
+		}
+	}
+	return nil
+}
+
 // Get returns the value for key, or zero and false if not found.
 func (c *Cache[K, V]) Get(key K) (V, bool) {
+	if c.sampler.sample() {
+		return c.sampledGet(key)
+	}
 	return c.memory.get(key)
 }
 
+// sampledGet is Get with latency recording, kept out of line so Get stays inlinable.
+func (c *Cache[K, V]) sampledGet(key K) (V, bool) {
+	defer c.sampler.record("get", time.Now())
+	return c.memory.get(key)
+}
+
+// GetWithExpiry is like Get but also returns when the entry expires, or the zero time
+// if it never does, so callers can refresh values before they lapse.
+func (c *Cache[K, V]) GetWithExpiry(key K) (V, time.Time, bool) {
+	v, exp, ok := c.memory.getWithExpiry(key)
+	if !ok {
+		return v, time.Time{}, false
+	}
+	return v, c.memory.fromSec(exp), true
+}
+
+// Peek returns the value for key like Get, but does not count as an access for
+// eviction purposes, so monitoring reads don't keep cold keys alive. Peeking an entry
+// held on death row returns its value without resurrecting it.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	return c.memory.liveValue(key)
+}
+
+// Contains reports whether key is present and unexpired. Evicted entries still held
+// on death row count as absent, matching Len and Range. Unlike Get, it does not count
+// as an access for eviction purposes and never copies the value.
+func (c *Cache[K, V]) Contains(key K) bool {
+	return c.memory.liveEntry(key)
+}
+
 // Set stores a value using the default TTL specified at cache creation.
 // If no default TTL was set, the entry never expires.
 func (c *Cache[K, V]) Set(key K, value V) {
@@ -65,12 +133,202 @@ func (c *Cache[K, V]) Set(key K, value V) {
 // SetTTL stores a value with an explicit TTL.
 // A zero or negative TTL means the entry never expires.
 func (c *Cache[K, V]) SetTTL(key K, value V, ttl time.Duration) {
+	if c.sampler.sample() {
+		defer c.sampler.record("set", time.Now())
+	}
 	if ttl <= 0 {
 		c.memory.set(key, value, 0)
 		return
 	}
-	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-	c.memory.set(key, value, uint32(time.Now().Add(ttl).Unix()))
+	c.memory.set(key, value, c.memory.toSec(c.memory.expiry(ttl, 0)))
+}
+
+// SetMiss caches the absence of key for ttl, so callers can remember that a backend
+// has no value for it without reserving a sentinel V. Get, Peek, Contains and Range
+// treat the entry as absent; GetOrMiss reports it as known. A later Set replaces it.
+// A cached miss occupies a slot like any other entry and counts toward Len.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) SetMiss(key K, ttl time.Duration) {
+	if ttl <= 0 {
+		c.memory.setMiss(key, 0)
+		return
+	}
+	c.memory.setMiss(key, c.memory.toSec(c.memory.expiry(ttl, 0)))
+}
+
+// GetOrMiss is like Get but also reports whether a miss for key was cached by SetMiss.
+// found is true when a value is returned; known is true when either a value or an
+// unexpired cached miss exists, so known && !found means the key is known to be absent.
+func (c *Cache[K, V]) GetOrMiss(key K) (value V, found, known bool) {
+	return c.memory.getOrMiss(key)
+}
+
+// SetMany stores items using the default TTL, taking the write lock once for the whole
+// batch rather than once per new key. Use it to hydrate the cache from bulk queries.
+func (c *Cache[K, V]) SetMany(items map[K]V) {
+	c.SetManyTTL(items, c.defaultTTL)
+}
+
+// SetManyTTL is like SetMany but stores items with an explicit TTL.
+// A zero or negative TTL means the entries never expire.
+func (c *Cache[K, V]) SetManyTTL(items map[K]V, ttl time.Duration) {
+	if ttl <= 0 {
+		c.memory.setMany(items, 0)
+		return
+	}
+	c.memory.setMany(items, c.memory.toSec(c.memory.expiry(ttl, 0)))
+}
+
+// GetMany returns the values found for keys; absent and expired keys are omitted.
+// Each found key counts as an access, as with Get.
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	out := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := c.memory.get(k); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// TrySet is like Set but reports whether the value was stored. It returns false when
+// the insert is refused by RejectWhenFull, MaxKeyBytes or KeyValidator. Updates to
+// existing keys always succeed.
+func (c *Cache[K, V]) TrySet(key K, value V) bool {
+	return c.TrySetTTL(key, value, c.defaultTTL)
+}
+
+// TrySetTTL is like TrySet but with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) TrySetTTL(key K, value V, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return c.memory.set(key, value, 0)
+	}
+	return c.memory.set(key, value, c.memory.toSec(c.memory.expiry(ttl, 0)))
+}
+
+// Swap stores value using the default TTL and returns the previous value, if one
+// was live. Concurrent Swap calls on a key are serialized, so each caller sees the
+// value the previous Swap stored.
+func (c *Cache[K, V]) Swap(key K, value V) (old V, had bool) {
+	return c.SwapTTL(key, value, c.defaultTTL)
+}
+
+// SwapTTL is like Swap but with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) SwapTTL(key K, value V, ttl time.Duration) (old V, had bool) {
+	if ttl <= 0 {
+		return c.memory.swap(key, value, 0)
+	}
+	return c.memory.swap(key, value, c.memory.toSec(c.memory.expiry(ttl, 0)))
+}
+
+// SetIfAbsent stores value using the default TTL only if key has no live entry, and
+// reports whether it did. As with Contains, evicted entries on death row are not live. The check and insert are atomic, so among concurrent callers
+// for a key exactly one wins, which suits deduplication guards. It also returns false
+// when the insert is refused, as TrySet does.
+func (c *Cache[K, V]) SetIfAbsent(key K, value V) bool {
+	return c.SetIfAbsentTTL(key, value, c.defaultTTL)
+}
+
+// SetIfAbsentTTL is like SetIfAbsent but with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) SetIfAbsentTTL(key K, value V, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return c.memory.setIfAbsent(key, value, 0)
+	}
+	return c.memory.setIfAbsent(key, value, c.memory.toSec(c.memory.expiry(ttl, 0)))
+}
+
+// GetOrSet returns the live value for key if there is one; otherwise it stores value
+// using the default TTL and returns it. loaded reports whether the value was already
+// cached. Like sync.Map.LoadOrStore, concurrent callers all see the same winning value.
+// A value refused by RejectWhenFull, MaxKeyBytes or KeyValidator is returned unstored.
+func (c *Cache[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	return c.GetOrSetTTL(key, value, c.defaultTTL)
+}
+
+// GetOrSetTTL is like GetOrSet but with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) GetOrSetTTL(key K, value V, ttl time.Duration) (actual V, loaded bool) {
+	if ttl <= 0 {
+		return c.memory.getOrSet(key, value, 0)
+	}
+	return c.memory.getOrSet(key, value, c.memory.toSec(c.memory.expiry(ttl, 0)))
+}
+
+// Number is the set of value types Add accepts.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Add atomically adds delta to key's value and returns the result. An absent or
+// expired key is created at delta with the default TTL; an existing entry keeps its
+// expiry. Concurrent Add calls on c are serialized, so counters such as rate limits
+// never lose updates. Plain Set calls can still overwrite the value in between. A new
+// key refused as by TrySet is not stored, but its sum is still returned.
+// It is a function rather than a method because it needs a numeric V.
+func Add[K comparable, V Number](c *Cache[K, V], key K, delta V) V {
+	var sum V
+	c.memory.compute(key, c.memory.toSec(c.memory.expiry(0, c.defaultTTL)), func(old V, _ bool) (V, bool) {
+		sum = old + delta
+		return sum, true
+	})
+	return sum
+}
+
+// CompareAndSwap stores newValue for key only if key has a live entry equal to old,
+// and reports whether it did. The entry keeps its expiry. It is a function rather than
+// a method because it needs a comparable V; for values that aren't comparable, store
+// versioned pointers and compare those.
+func CompareAndSwap[K, V comparable](c *Cache[K, V], key K, old, newValue V) bool {
+	return c.memory.compute(key, 0, func(cur V, live bool) (V, bool) {
+		return newValue, live && cur == old
+	})
+}
+
+// DeletePrefix removes every key starting with prefix and returns how many it removed,
+// for purging a namespace such as one tenant's keys. It scans all entries under the
+// write lock, so it costs time proportional to the cache size, not the match count.
+func DeletePrefix[V any](c *Cache[string, V], prefix string) int {
+	return c.memory.deleteKeys(func(k string) bool { return strings.HasPrefix(k, prefix) })
+}
+
+// KeyHandle is a key with its eviction hash precomputed, for hot loops that touch
+// the same keys many times. Handles are only valid for the cache that created them.
+type KeyHandle[K comparable] struct {
+	key  K
+	hash uint64
+}
+
+// Key returns the handle's key.
+func (h KeyHandle[K]) Key() K { return h.key }
+
+// Handle precomputes the hash for key. Lookups are already hash-free at this layer,
+// so the savings apply to SetHandle inserts, where the hash feeds the ghost filter.
+func (c *Cache[K, V]) Handle(key K) KeyHandle[K] {
+	return KeyHandle[K]{key: key, hash: c.memory.hasher(key)}
+}
+
+// GetHandle is Get for a precomputed handle.
+func (c *Cache[K, V]) GetHandle(h KeyHandle[K]) (V, bool) {
+	return c.memory.get(h.key)
+}
+
+// SetHandle is Set for a precomputed handle, skipping key hashing on insert.
+func (c *Cache[K, V]) SetHandle(h KeyHandle[K], value V) {
+	c.memory.setWithHash(h.key, value, c.memory.toSec(c.memory.expiry(0, c.defaultTTL)), h.hash)
+}
+
+// Touch extends a live entry's lifetime to ttl from now without rewriting its value,
+// which avoids copying large values just to keep them. A zero or negative ttl applies
+// the default TTL, as Set does. Returns false if key is absent, expired, or evicted, and
+// for cached misses, which keep their miss TTL. Touch does not count as an access for
+// eviction purposes.
+func (c *Cache[K, V]) Touch(key K, ttl time.Duration) bool {
+	return c.memory.refreshExpiry(key, c.memory.toSec(c.memory.expiry(ttl, c.defaultTTL)))
 }
 
 // Delete removes a key from the cache.
@@ -78,6 +336,17 @@ func (c *Cache[K, V]) Delete(key K) {
 	c.memory.del(key)
 }
 
+// UpdateMulti atomically reads and rewrites a group of related keys.
+// fn receives the live values for keys (absent or expired keys are omitted) and returns
+// the new contents: returned keys are stored with the default TTL, and listed keys missing
+// from the result are deleted. Keys not listed in keys are ignored.
+//
+// Concurrent UpdateMulti calls are serialized, so callers never observe each other's
+// partial results. Plain Get and Set do not take part in this ordering.
+func (c *Cache[K, V]) UpdateMulti(keys []K, fn func(map[K]V) map[K]V) {
+	c.memory.updateMulti(keys, fn, c.memory.toSec(c.memory.expiry(0, c.defaultTTL)))
+}
+
 // Fetch returns cached value or calls loader to compute it.
 // Concurrent calls for the same key share one loader invocation.
 // Computed values are stored with the default TTL.
@@ -102,7 +371,11 @@ func (c *Cache[K, V]) getSet(key K, loader func() (V, error), ttl time.Duration)
 	})
 
 	if loaded {
+		c.memory.coalescedCalls.Add(1)
 		call.wg.Wait()
+		if errors.Is(call.err, errNotLoaded) {
+			return c.getSet(key, loader, ttl)
+		}
 		return call.val, call.err
 	}
 
@@ -113,6 +386,13 @@ func (c *Cache[K, V]) getSet(key K, loader func() (V, error), ttl time.Duration)
 		return val, nil
 	}
 
+	return c.runFlight(key, call, loader, ttl)
+}
+
+// runFlight calls loader for the flight it registered for key, stores a successful
+// result, and releases the flight's waiters.
+func (c *Cache[K, V]) runFlight(key K, call *flightCall[V], loader func() (V, error), ttl time.Duration) (V, error) {
+	c.memory.loaderCalls.Add(1)
 	val, err := loader()
 	if err == nil {
 		if ttl <= 0 {
@@ -129,51 +409,540 @@ func (c *Cache[K, V]) getSet(key K, loader func() (V, error), ttl time.Duration)
 	return val, err
 }
 
+// FetchStale is FetchTTL with stale-while-revalidate: values are stored for hardTTL,
+// and once an entry is older than softTTL it is still returned immediately while
+// loader refreshes it in the background. Callers block on loader only when the key
+// is absent or past hardTTL. Background refreshes share Fetch's deduplication, so at
+// most one loader runs per key; a failed refresh leaves the stale value in place.
+//
+// Age is tracked to the second from when FetchStale stored the entry, so TTLJitter,
+// MinTTL and MaxTTL change when it expires but not when it turns stale. Entries that
+// FetchStale did not store, or that were rewritten or touched since, are never stale.
+// If softTTL is not in (0, hardTTL) FetchStale is FetchTTL.
+func (c *Cache[K, V]) FetchStale(key K, softTTL, hardTTL time.Duration, loader func() (V, error)) (V, error) {
+	swr := softTTL > 0 && softTTL < hardTTL
+	val, ok := c.memory.get(key)
+	if !ok {
+		val, err := c.getSet(key, loader, hardTTL)
+		if err == nil && swr {
+			c.soft.record(c.memory, key, softTTL)
+		}
+		return val, err
+	}
+	if swr && c.soft.stale(c.memory, key) {
+		c.refresh(key, loader, softTTL, hardTTL)
+	}
+	return val, nil
+}
+
+// refresh starts a background FetchStale load for key unless one is already in flight.
+func (c *Cache[K, V]) refresh(key K, loader func() (V, error), softTTL, hardTTL time.Duration) {
+	call, loaded := c.flights.LoadOrCompute(key, func() (*flightCall[V], bool) {
+		fc := &flightCall[V]{}
+		fc.wg.Add(1)
+		return fc, false
+	})
+	if loaded {
+		return
+	}
+	go func() {
+		// A failed refresh keeps the stale value.
+		if _, err := c.runFlight(key, call, loader, hardTTL); err == nil {
+			c.soft.record(c.memory, key, softTTL)
+		}
+	}()
+}
+
+// scopedKey identifies a FetchScoped flight.
+type scopedKey[K comparable] struct {
+	key   K
+	scope string
+}
+
+// FetchScoped is like Fetch but coalesces concurrent loaders by (key, scope) rather than
+// by key alone, for loaders that depend on per-request state such as tenant credentials.
+// The result is still cached under key and served to every scope on later hits.
+func (c *Cache[K, V]) FetchScoped(key K, scope string, loader func() (V, error)) (V, error) {
+	return c.getSetScoped(key, scope, loader, 0)
+}
+
+// FetchScopedTTL is like FetchScoped but stores computed values with an explicit TTL.
+func (c *Cache[K, V]) FetchScopedTTL(key K, scope string, ttl time.Duration, loader func() (V, error)) (V, error) {
+	return c.getSetScoped(key, scope, loader, ttl)
+}
+
+func (c *Cache[K, V]) getSetScoped(key K, scope string, loader func() (V, error), ttl time.Duration) (V, error) {
+	if val, ok := c.memory.get(key); ok {
+		return val, nil
+	}
+
+	fk := scopedKey[K]{key: key, scope: scope}
+	call, loaded := c.scoped.LoadOrCompute(fk, func() (*flightCall[V], bool) {
+		fc := &flightCall[V]{}
+		fc.wg.Add(1)
+		return fc, false
+	})
+
+	if loaded {
+		c.memory.coalescedCalls.Add(1)
+		call.wg.Wait()
+		return call.val, call.err
+	}
+
+	// Another scope may have filled key while we registered.
+	if val, ok := c.memory.get(key); ok {
+		call.val = val
+		c.scoped.Delete(fk)
+		call.wg.Done()
+		return val, nil
+	}
+
+	c.memory.loaderCalls.Add(1)
+	val, err := loader()
+	if err == nil {
+		if ttl <= 0 {
+			c.Set(key, val)
+		} else {
+			c.SetTTL(key, val, ttl)
+		}
+	}
+
+	call.val, call.err = val, err
+	c.scoped.Delete(fk)
+	call.wg.Done()
+
+	return val, err
+}
+
+// ctxFlight is an in-flight FetchContext load. waiters is guarded by the
+// ctxFlights bucket lock: it is only read or written inside Compute.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type ctxFlight[V any] struct {
+	done    chan struct{}
+	val     V
+	err     error
+	panic   any // recovered from loader, re-raised in each waiter
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// FetchContext is like Fetch for loaders that take a context. Concurrent calls share
+// one loader, which runs in its own goroutine with a context that carries ctx's values
+// but is cancelled only once every caller waiting on it has given up, so one caller's
+// cancellation never fails the load for the others. A caller whose ctx is done before
+// the load finishes returns ctx.Err(). If loader panics, every caller still waiting
+// panics with the same value.
+//
+// FetchContext deduplicates only against other FetchContext calls: a concurrent Fetch
+// of the same key runs its own loader. Fetch's flights can't be cancelled, so a
+// FetchContext caller joining one could not give up on it.
+func (c *Cache[K, V]) FetchContext(ctx context.Context, key K, loader func(context.Context) (V, error)) (V, error) {
+	return c.getSetContext(ctx, key, loader, 0)
+}
+
+// FetchContextTTL is like FetchContext but stores computed values with an explicit TTL.
+func (c *Cache[K, V]) FetchContextTTL(ctx context.Context, key K, ttl time.Duration, loader func(context.Context) (V, error)) (V, error) {
+	return c.getSetContext(ctx, key, loader, ttl)
+}
+
+func (c *Cache[K, V]) getSetContext(ctx context.Context, key K, loader func(context.Context) (V, error), ttl time.Duration) (V, error) {
+	if val, ok := c.memory.get(key); ok {
+		return val, nil
+	}
+	var zero V
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	var lctx context.Context
+	call, _ := c.ctxFlights.Compute(key, func(old *ctxFlight[V], loaded bool) (*ctxFlight[V], xsync.ComputeOp) {
+		if loaded {
+			old.waiters++
+			return old, xsync.CancelOp
+		}
+		var cancel context.CancelFunc
+		lctx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+		return &ctxFlight[V]{done: make(chan struct{}), cancel: cancel, waiters: 1}, xsync.UpdateOp
+	})
+	if lctx != nil {
+		go c.runContextFlight(lctx, key, call, loader, ttl)
+	} else {
+		c.memory.coalescedCalls.Add(1)
+	}
+
+	select {
+	case <-call.done:
+		if call.panic != nil {
+			panic(call.panic)
+		}
+		return call.val, call.err
+	case <-ctx.Done():
+	}
+
+	// Give up on the flight, cancelling its loader if we were the last one waiting.
+	c.ctxFlights.Compute(key, func(old *ctxFlight[V], loaded bool) (*ctxFlight[V], xsync.ComputeOp) {
+		if !loaded || old != call {
+			return old, xsync.CancelOp
+		}
+		if old.waiters--; old.waiters > 0 {
+			return old, xsync.CancelOp
+		}
+		old.cancel()
+		return nil, xsync.DeleteOp
+	})
+	return zero, ctx.Err()
+}
+
+// runContextFlight runs loader for a FetchContext flight, stores a successful result,
+// and releases the flight's waiters. A loader panic is handed to the waiters rather
+// than crashing the process from a goroutine none of them own.
+func (c *Cache[K, V]) runContextFlight(ctx context.Context, key K, call *ctxFlight[V], loader func(context.Context) (V, error), ttl time.Duration) {
+	defer call.cancel()
+	defer func() {
+		call.panic = recover()
+		close(call.done)
+		c.ctxFlights.Compute(key, func(old *ctxFlight[V], loaded bool) (*ctxFlight[V], xsync.ComputeOp) {
+			if loaded && old == call {
+				return nil, xsync.DeleteOp
+			}
+			return old, xsync.CancelOp
+		})
+	}()
+
+	val, ok := c.memory.get(key)
+	var err error
+	if !ok {
+		c.memory.loaderCalls.Add(1)
+		val, err = loader(ctx)
+		if err == nil {
+			if ttl <= 0 {
+				c.Set(key, val)
+			} else {
+				c.SetTTL(key, val, ttl)
+			}
+		}
+	}
+
+	call.val, call.err = val, err
+}
+
+// FetchMany returns cached values for keys, calling loader once with all keys that miss.
+// Misses already being loaded by another Fetch or FetchMany are awaited rather than
+// reloaded, so overlapping concurrent calls never load the same key twice.
+// Keys the loader does not return are omitted from the result and not cached.
+// On error the returned map still holds every value that was found.
+// Computed values are stored with the default TTL.
+func (c *Cache[K, V]) FetchMany(keys []K, loader func(missing []K) (map[K]V, error)) (map[K]V, error) {
+	return c.fetchMany(keys, loader, 0)
+}
+
+// FetchManyTTL is like FetchMany but stores computed values with an explicit TTL.
+func (c *Cache[K, V]) FetchManyTTL(keys []K, ttl time.Duration, loader func(missing []K) (map[K]V, error)) (map[K]V, error) {
+	return c.fetchMany(keys, loader, ttl)
+}
+
+func (c *Cache[K, V]) fetchMany(keys []K, loader func([]K) (map[K]V, error), ttl time.Duration) (map[K]V, error) {
+	out := make(map[K]V, len(keys))
+	var owned []K
+	calls := make(map[K]*flightCall[V])
+	waiting := make(map[K]*flightCall[V])
+
+	for _, key := range keys {
+		if _, ok := out[key]; ok {
+			continue
+		}
+		if _, ok := calls[key]; ok {
+			continue
+		}
+		if _, ok := waiting[key]; ok {
+			continue
+		}
+		if val, ok := c.memory.get(key); ok {
+			out[key] = val
+			continue
+		}
+
+		call, loaded := c.flights.LoadOrCompute(key, func() (*flightCall[V], bool) {
+			fc := &flightCall[V]{}
+			fc.wg.Add(1)
+			return fc, false
+		})
+		if loaded {
+			c.memory.coalescedCalls.Add(1)
+			waiting[key] = call
+			continue
+		}
+
+		if val, ok := c.memory.get(key); ok {
+			out[key] = val
+			call.val = val
+			c.flights.Delete(key)
+			call.wg.Done()
+			continue
+		}
+		owned = append(owned, key)
+		calls[key] = call
+	}
+
+	// Finish our own flights before waiting on others so overlapping callers can't deadlock.
+	var firstErr error
+	if len(owned) > 0 {
+		c.memory.loaderCalls.Add(1)
+		vals, err := loader(owned)
+		exp := c.memory.toSec(c.memory.expiry(ttl, c.defaultTTL))
+		for _, key := range owned {
+			call := calls[key]
+			switch val, ok := vals[key]; {
+			case err != nil:
+				call.err = err
+			case ok:
+				c.memory.set(key, val, exp)
+				out[key] = val
+				call.val = val
+			default:
+				call.err = errNotLoaded
+			}
+			c.flights.Delete(key)
+			call.wg.Done()
+		}
+		firstErr = err
+	}
+
+	for key, call := range waiting {
+		call.wg.Wait()
+		switch {
+		case call.err == nil:
+			out[key] = call.val
+		case errors.Is(call.err, errNotLoaded):
+		case firstErr == nil:
+			firstErr = call.err
+		}
+	}
+
+	return out, firstErr
+}
+
 // Len returns the number of entries.
 func (c *Cache[K, V]) Len() int {
 	return c.memory.len()
 }
 
+// Stats returns a snapshot of cache counters. Safe for concurrent use.
+func (c *Cache[K, V]) Stats() Stats {
+	return c.memory.stats()
+}
+
 // Flush removes all entries. Returns count removed.
 func (c *Cache[K, V]) Flush() int {
 	return c.memory.flush()
 }
 
-// Range returns an iterator over all non-expired key-value pairs.
-// Iteration order is undefined. Safe for concurrent use.
+// FlushVolatile removes all entries that have a TTL and keeps entries that never
+// expire, such as loaded configuration. Returns count removed.
+func (c *Cache[K, V]) FlushVolatile() int {
+	return c.memory.flushVolatile()
+}
+
+// Cleanup removes expired entries, freeing their memory now rather than when they are
+// next evicted or overwritten. Returns count removed.
+func (c *Cache[K, V]) Cleanup() int {
+	return c.memory.removeExpired()
+}
+
+// Range returns an iterator over all non-expired key-value pairs. Evicted entries still
+// held on death row are skipped. Iteration order is undefined. Safe for concurrent use.
 // Changes during iteration may or may not be reflected.
 func (c *Cache[K, V]) Range() iter.Seq2[K, V] {
-	return func(yield func(K, V) bool) {
-		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-		now := uint32(time.Now().Unix())
-		c.memory.entries.Range(func(key K, e *entry[K, V]) bool {
-			// Skip expired entries.
-			expiry := e.expirySec.Load()
-			if expiry != 0 && expiry < now {
-				return true
-			}
+	return c.ForEach
+}
 
-			// Load value with seqlock.
-			v, ok := e.loadValue()
-			if !ok {
-				return true
-			}
+// ForEach calls fn for each non-expired entry until fn returns false. It is Range
+// without the iterator wrapper and allocates nothing per entry, for full scans on hot
+// paths. Iteration order is undefined. Safe for concurrent use.
+func (c *Cache[K, V]) ForEach(fn func(K, V) bool) {
+	now := c.memory.nowSec()
+	c.memory.entries.Range(func(key K, e *entry[K, V]) bool {
+		// Skip expired entries and evicted ones held on death row.
+		expiry := e.expirySec.Load()
+		if (expiry != 0 && expiry < now) || e.onDeathRow() {
+			return true
+		}
 
-			// Yield to caller.
-			return yield(key, v)
-		})
-	}
+		// Load value with seqlock.
+		v, ok := e.loadValue()
+		if !ok {
+			return true
+		}
+
+		return fn(key, v)
+	})
+}
+
+// CountFunc returns the number of non-expired entries for which pred returns true.
+// Like ForEach it allocates nothing per entry; the count is not a consistent snapshot
+// under concurrent writes.
+func (c *Cache[K, V]) CountFunc(pred func(K, V) bool) int {
+	n := 0
+	c.ForEach(func(k K, v V) bool {
+		if pred(k, v) {
+			n++
+		}
+		return true
+	})
+	return n
 }
 
 type config struct {
-	size       int
-	defaultTTL time.Duration
+	size            int
+	defaultTTL      time.Duration
+	minTTL          time.Duration
+	maxTTL          time.Duration
+	ttlJitter       float64
+	lazyMapGrowth   bool
+	presize         int
+	contentionStats bool
+	hitStats        bool
+	evictBatch      int
+	smallPerMille   int
+	maxFreq         uint32
+	ghostBudget     int64
+	strictDecode    bool
+	strictWrites    bool
+	readTimeout     time.Duration
+	asyncTimeout    time.Duration
+	asyncWorkers    int
+	asyncQueue      int
+	asyncDropFull   bool
+	persistMinTTL   time.Duration
+	writeBackEvery  time.Duration
+	writeBackBatch  int
+	cleanupEvery    time.Duration
+	warmup          int
+	warmupWorkers   int
+	warmupOrder     WarmupOrder
+	warmupTimeout   time.Duration
+	maxKeyBytes     int
+	keyValidator    any // func(K) error; see KeyValidator
+	hasher          any // func(K) uint64; see Hasher
+	costFn          any // func(V) int64; see Cost
+	maxCost         int64
+	rejectWhenFull  bool
+	writeNoBump     bool
+	monotonicExpiry bool
+	policy          Policy
+	sampleRate      float64
+	sampleSink      func(op string, d time.Duration)
+}
+
+// Merge copies other's live entries into c, keeping each entry's remaining TTL.
+// Entries other has already evicted are skipped.
+// If overwrite is false, keys already live in c are left unchanged.
+// Entries written to other during the merge may or may not be copied.
+func (c *Cache[K, V]) Merge(other *Cache[K, V], overwrite bool) {
+	if other == c {
+		return
+	}
+	now := other.memory.nowSec()
+	// The caches may run different expiry clocks (MonotonicExpiry), so carry over
+	// the remaining lifetime rather than the raw expiry.
+	shift := c.memory.nowSec() - now
+	other.memory.entries.Range(func(key K, e *entry[K, V]) bool {
+		expiry := e.expirySec.Load()
+		if (expiry != 0 && expiry < now) || e.onDeathRow() {
+			return true
+		}
+		v, ok := e.loadValue()
+		if !ok {
+			return true
+		}
+		if expiry != 0 {
+			expiry += shift
+		}
+		if overwrite {
+			c.memory.set(key, v, expiry)
+		} else {
+			c.memory.setIfAbsent(key, v, expiry)
+		}
+		return true
+	})
+}
+
+// Entry is a cache entry as captured by Snapshot. Its fields are exported so a
+// snapshot can be encoded with encoding/gob or encoding/json.
+type Entry[K comparable, V any] struct {
+	Key    K
+	Value  V
+	Expiry time.Time // zero for no expiry
+}
+
+// Snapshot returns c's live entries with their absolute expiry times, for saving
+// before shutdown and handing to Restore after restart. Order is undefined.
+// Entries written during the snapshot may or may not be included.
+func (c *Cache[K, V]) Snapshot() []Entry[K, V] {
+	out := make([]Entry[K, V], 0, c.Len())
+	now := c.memory.nowSec()
+	c.memory.entries.Range(func(key K, e *entry[K, V]) bool {
+		expiry := e.expirySec.Load()
+		if (expiry != 0 && expiry < now) || e.onDeathRow() {
+			return true
+		}
+		if v, ok := e.loadValue(); ok {
+			out = append(out, Entry[K, V]{Key: key, Value: v, Expiry: c.memory.fromSec(expiry)})
+		}
+		return true
+	})
+	return out
+}
+
+// Restore stores entries as Set would, each with its own expiry; entries already
+// expired are skipped. Restored values overwrite live ones for the same keys.
+func (c *Cache[K, V]) Restore(entries []Entry[K, V]) {
+	now := time.Now()
+	for _, e := range entries {
+		if !e.Expiry.IsZero() && !e.Expiry.After(now) {
+			continue
+		}
+		c.memory.set(e.Key, e.Value, c.memory.toSec(e.Expiry))
+	}
+}
+
+// ReadOnlyCache is the read-only subset of Cache, for handing to code that must not
+// write, delete, or flush.
+type ReadOnlyCache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Peek(key K) (V, bool)
+	Contains(key K) bool
+	Len() int
+	Range() iter.Seq2[K, V]
+	Stats() Stats
+}
+
+// readOnly wraps a Cache so callers cannot type-assert back to *Cache.
+type readOnly[K comparable, V any] struct {
+	c *Cache[K, V]
+}
+
+func (r readOnly[K, V]) Get(key K) (V, bool)    { return r.c.Get(key) }
+func (r readOnly[K, V]) Peek(key K) (V, bool)   { return r.c.Peek(key) }
+func (r readOnly[K, V]) Contains(key K) bool    { return r.c.Contains(key) }
+func (r readOnly[K, V]) Len() int               { return r.c.Len() }
+func (r readOnly[K, V]) Range() iter.Seq2[K, V] { return r.c.Range() }
+func (r readOnly[K, V]) Stats() Stats           { return r.c.Stats() }
+
+// ReadOnly returns a view of c that exposes only read methods.
+// The view shares c's contents; writes to c are visible through it.
+func (c *Cache[K, V]) ReadOnly() ReadOnlyCache[K, V] {
+	return readOnly[K, V]{c: c}
 }
 
 // Option configures a Cache.
 type Option func(*config)
 
-// Size sets maximum entries. Default 16384.
+// Size sets maximum entries. Default 16384; Cache treats n <= 0 as the default.
+// For TieredCache, Size(0) selects persistence-only mode: the memory tier stores
+// nothing, so every Get reads the store and writes go only to the store. Fetch still
+// deduplicates concurrent loads.
 func Size(n int) Option {
 	return func(c *config) { c.size = n }
 }
@@ -182,3 +951,311 @@ func Size(n int) Option {
 func TTL(d time.Duration) Option {
 	return func(c *config) { c.defaultTTL = d }
 }
+
+// MinTTL raises any positive TTL, explicit or default, to at least d, so a buggy
+// near-zero TTL cannot turn the cache into a no-op. Entries without expiry are
+// unaffected. Default 0 (no minimum).
+func MinTTL(d time.Duration) Option {
+	return func(c *config) { c.minTTL = d }
+}
+
+// MaxTTL lowers any positive TTL, explicit or default, to at most d. Entries without
+// expiry are unaffected. MaxTTL wins if it is below MinTTL. Default 0 (no maximum).
+func MaxTTL(d time.Duration) Option {
+	return func(c *config) { c.maxTTL = d }
+}
+
+// TTLJitter spreads each positive TTL, explicit or default, uniformly over
+// ttl ± fraction*ttl, so entries written together don't all expire, and hit the backend
+// for a refresh, in the same second. TTLJitter(0.1) spreads a 1h TTL over 54 to 66 minutes.
+// MinTTL and MaxTTL apply after jitter. Values outside (0, 1) are ignored. Default 0.
+func TTLJitter(fraction float64) Option {
+	return func(c *config) {
+		if fraction > 0 && fraction < 1 {
+			c.ttlJitter = fraction
+		}
+	}
+}
+
+// LazyMapGrowth starts the entry map small and lets it grow on demand instead of
+// presizing it to full capacity. Trades some rehash cost during fill for lower
+// idle memory, which helps when many large caches stay nearly empty.
+func LazyMapGrowth() Option {
+	return func(c *config) { c.lazyMapGrowth = true }
+}
+
+// Presize sizes the entry map for n entries up front instead of for Size, and takes
+// precedence over LazyMapGrowth. Use it when the number of keys a warmup or Restore
+// writes is known, so the map doesn't rehash as it fills, or to start a rarely full
+// cache smaller. The map still grows past n on demand. Values <= 0 are ignored.
+func Presize(n int) Option {
+	return func(c *config) { c.presize = n }
+}
+
+// EvictBatch reclaims up to n entries per eviction pass when the cache is full, so
+// the following inserts skip eviction entirely. Default 1. Larger batches trade a
+// slightly lower average fill for fewer eviction passes under insert-heavy load.
+func EvictBatch(n int) Option {
+	return func(c *config) { c.evictBatch = n }
+}
+
+// SmallQueueRatio sets the S3-FIFO small (probationary) queue's share of capacity, in
+// per-mille. Smaller windows suit scan-heavy workloads by admitting fewer one-hit
+// entries to main. Values outside (0, 1000) are ignored. Default: 122-152 depending on
+// Size, tuned on benchmark traces.
+func SmallQueueRatio(perMille int) Option {
+	return func(c *config) {
+		if perMille > 0 && perMille < 1000 {
+			c.smallPerMille = perMille
+		}
+	}
+}
+
+// MaxFreq sets the access count at which an entry's S3-FIFO frequency counter
+// saturates. Each eviction pass over main spends one count, so a higher cap lets
+// entries in heavily skewed workloads bank more accesses against eviction, at the
+// cost of formerly hot keys taking longer to age out. The counter lives in 4 bits of
+// each entry's existing flags, so raising it costs no memory. A cap of 1 would let
+// small-queue eviction promote entries forever without evicting any. Values outside
+// [2, 15] are ignored. Default: 5.
+func MaxFreq(n int) Option {
+	return func(c *config) {
+		if n >= 2 && n <= freqMask {
+			c.maxFreq = uint32(n)
+		}
+	}
+}
+
+// GhostMemoryBudget caps the memory held by the ghost filters, which remember recently
+// evicted keys so they can skip probation on return, at n bytes. By default they take
+// about 8 bytes per entry of Size. A tighter budget raises their false positive rate,
+// letting more new keys skip probation. The filters never shrink below 16 bytes in
+// total, so smaller budgets get that minimum. Stats.GhostBytes reports the actual size.
+func GhostMemoryBudget(n int64) Option {
+	return func(c *config) { c.ghostBudget = n }
+}
+
+// Warmup loads up to n of the store's most recently updated entries into memory in the
+// background after NewTiered returns; n is capped at Size. Requires a store implementing
+// RecentLoader, or FrequencyLoader with WarmupStrategy(WarmupByFrequency). Use
+// TieredCache.WarmupDone to wait for completion. TieredCache only.
+func Warmup(n int) Option {
+	return func(c *config) { c.warmup = n }
+}
+
+// WarmupConcurrency inserts warmup entries from n goroutines so store I/O and decoding
+// overlap with insertion. Default 1. TieredCache only.
+func WarmupConcurrency(n int) Option {
+	return func(c *config) { c.warmupWorkers = n }
+}
+
+// WarmupTimeout bounds how long background warmup may run; entries loaded before the
+// deadline stay in memory. Warmup runs on its own detached context, so this is the
+// only deadline it observes. Default 0 (no limit). TieredCache only.
+func WarmupTimeout(d time.Duration) Option {
+	return func(c *config) { c.warmupTimeout = d }
+}
+
+// WarmupOrder selects which store entries Warmup loads first.
+type WarmupOrder uint8
+
+const (
+	// WarmupByRecency loads the most recently written entries. Requires a RecentLoader.
+	WarmupByRecency WarmupOrder = iota
+	// WarmupByFrequency loads the most frequently read entries, which usually gives a
+	// better hit rate after restart for read-heavy caches. Requires a FrequencyLoader.
+	WarmupByFrequency
+)
+
+// WarmupStrategy sets the order in which Warmup loads entries. Default WarmupByRecency.
+// TieredCache only.
+func WarmupStrategy(o WarmupOrder) Option {
+	return func(c *config) { c.warmupOrder = o }
+}
+
+// StrictDecode makes TieredCache return store decode errors instead of treating
+// corrupt persisted entries as misses. TieredCache only.
+func StrictDecode() Option {
+	return func(c *config) { c.strictDecode = true }
+}
+
+// StrictWriteThrough makes TieredCache writes reach memory only after the store has
+// accepted them, so memory never holds a value the store rejected. A failed Set returns
+// the store error and leaves memory unchanged; SetAsync values appear in memory once
+// persisted; Fetch still returns a loaded value whose store write failed, but does not
+// cache it. Concurrent writes to a key are serialized, so memory ends up with the
+// value of the last store write. By default memory is written first and kept even if
+// the store fails. TieredCache only.
+func StrictWriteThrough() Option {
+	return func(c *config) { c.strictWrites = true }
+}
+
+// WriteBack switches TieredCache from write-through to write-back: store writes and
+// deletes are buffered in memory and flushed by a background goroutine every interval,
+// or sooner once maxBatch keys are pending (maxBatch <= 0 flushes on the interval only).
+// Writes to a key between flushes are coalesced into the last one, and reads that miss
+// memory see buffered writes before the store. Set, SetAsync, Fetch and Delete return
+// without waiting for the store; failed flushes are logged and retried on the next one.
+// Close flushes whatever is pending and reports writes that still fail.
+//
+// The tradeoff is durability: a crash or kill loses every write since the last flush.
+// StrictWriteThrough has no effect in this mode. An interval <= 0 disables write-back.
+// TieredCache only.
+func WriteBack(interval time.Duration, maxBatch int) Option {
+	return func(c *config) {
+		c.writeBackEvery = interval
+		c.writeBackBatch = maxBatch
+	}
+}
+
+// CleanupInterval removes expired entries in the background every d: from memory, as
+// Cleanup does for Cache, and from the store via Store.Cleanup. The goroutine starts
+// with the cache and stops on Close. Default 0 (no background cleanup). TieredCache only.
+func CleanupInterval(d time.Duration) Option {
+	return func(c *config) { c.cleanupEvery = d }
+}
+
+// StoreReadTimeout bounds how long Get and GetMany wait for the store on a memory miss.
+// Slower reads are reported as misses and left to finish in the background, filling
+// memory for the next call. Fetch is unaffected, so a slow read never triggers its
+// loader. Default 0 (wait for the store). TieredCache only.
+func StoreReadTimeout(d time.Duration) Option {
+	return func(c *config) { c.readTimeout = d }
+}
+
+// AsyncTimeout bounds each store operation the cache runs in the background: SetAsync
+// writes, WriteBack flushes (per key), and the reads StoreReadTimeout leaves running.
+// It also bounds the store read that concurrent Fetch misses on a key share, which
+// outlives the cancellation of the caller that started it.
+// A timed-out write fails like any other store error: SetAsync logs and drops it, and
+// WriteBack retries it on the next flush. Raise it for stores that slow down under
+// load. Values <= 0 are ignored. Default 5s. TieredCache only.
+func AsyncTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.asyncTimeout = d
+		}
+	}
+}
+
+// AsyncWorkers runs SetAsync store writes on n goroutines fed by a queue of up to
+// queue waiting writes, so a write burst against a slow store holds bounded memory
+// rather than a goroutine per call. A SetAsync that finds the queue full waits for
+// room, up to 100ms or until its context is done, then drops the store write with a
+// log line (see AsyncDropWhenFull). Close drains the queue. Values <= 0 keep
+// the defaults: GOMAXPROCS workers and a 4096-write queue. Unused with WriteBack.
+// TieredCache only.
+func AsyncWorkers(n, queue int) Option {
+	return func(c *config) {
+		c.asyncWorkers = n
+		c.asyncQueue = max(queue, 0)
+	}
+}
+
+// AsyncDropWhenFull makes SetAsync drop the store write, counted in Stats.AsyncDropped,
+// as soon as it finds the AsyncWorkers queue full, instead of waiting for room. The memory write
+// still happens unless StrictWriteThrough is set. TieredCache only.
+func AsyncDropWhenFull() Option {
+	return func(c *config) { c.asyncDropFull = true }
+}
+
+// PersistMinTTL keeps entries whose effective TTL is shorter than d in memory only,
+// skipping the store write: they would expire before ever being reloaded. A skipped
+// write deletes any value already stored for the key, so it can't resurface once the
+// memory entry is gone. Entries that never expire are always persisted. Default 0
+// (persist everything). TieredCache only.
+func PersistMinTTL(d time.Duration) Option {
+	return func(c *config) { c.persistMinTTL = d }
+}
+
+// MaxKeyBytes caps the length of string keys. Inserting a longer key is a no-op counted
+// in Stats.RejectedKeys; TieredCache writes and Fetch return an error instead. Keys of
+// other types are unaffected. Default 0 (unlimited).
+func MaxKeyBytes(n int) Option {
+	return func(c *config) { c.maxKeyBytes = n }
+}
+
+// KeyValidator rejects new keys for which fn returns an error, such as keys that break
+// an application naming scheme. Rejected Cache inserts are no-ops counted in
+// Stats.RejectedKeys; TieredCache writes and Fetch return the error, checked before the
+// store's own ValidateKey. Updates to keys already cached are not rechecked.
+// fn's key type must match the cache's: New panics and NewTiered fails otherwise.
+func KeyValidator[K comparable](fn func(K) error) Option {
+	return func(c *config) { c.keyValidator = fn }
+}
+
+// Hasher replaces the built-in key hash used for ghost admission and frequency tracking,
+// for example with a seeded hash so crafted keys can't collide with evicted hot keys.
+// fn must return the same hash for equal keys. A custom hasher disables the unsafe
+// fast paths for string and integer keys, so expect somewhat lower write throughput.
+// fn's key type must match the cache's: New panics and NewTiered fails otherwise.
+func Hasher[K comparable](fn func(K) uint64) Option {
+	return func(c *config) { c.hasher = fn }
+}
+
+// Cost sets the function that weighs each value against MaxCost, such as its size in
+// bytes. It must give the same result for a value every time it is called. Without
+// MaxCost it has no effect. fn's value type must match the cache's: New panics and
+// NewTiered fails otherwise.
+func Cost[V any](fn func(V) int64) Option {
+	return func(c *config) { c.costFn = fn }
+}
+
+// MaxCost bounds the summed Cost of the entries in memory, evicting as needed to stay
+// within it, for caches whose values vary widely in size. Size still caps the entry
+// count. A value costlier than n on its own is not stored, counted in
+// Stats.RejectedFull. With MaxCost set, updates to existing keys take the write lock.
+// Default 0 (count-based capacity only).
+func MaxCost(n int64) Option {
+	return func(c *config) { c.maxCost = n }
+}
+
+// EvictionPolicy selects the eviction algorithm. Default PolicyS3FIFO.
+func EvictionPolicy(p Policy) Option {
+	return func(c *config) { c.policy = p }
+}
+
+// MonotonicExpiry measures TTLs on the monotonic clock instead of wall time, so NTP
+// steps and VM suspend/resume neither expire nor extend entries. Time spent suspended
+// does not count toward TTLs. Absolute expiries loaded from a store are converted to
+// a remaining lifetime when they enter memory.
+func MonotonicExpiry() Option {
+	return func(c *config) { c.monotonicExpiry = true }
+}
+
+// RejectWhenFull refuses to insert new keys once the cache holds Size entries, instead
+// of evicting. Refused inserts are counted in Stats.RejectedFull; use TrySet to observe
+// them directly. Expired entries keep their slot until overwritten or deleted.
+func RejectWhenFull() Option {
+	return func(c *config) { c.rejectWhenFull = true }
+}
+
+// WriteNoBump stops updates to existing keys from counting as accesses, so entries
+// that are rewritten often but rarely read can still be evicted. Only Get and Fetch
+// hits raise an entry's eviction priority. Useful for write-through caches.
+func WriteNoBump() Option {
+	return func(c *config) { c.writeNoBump = true }
+}
+
+// LatencySampler times roughly rate (0 to 1) of Get and Set calls and passes each
+// duration to sink with op "get" or "set". TieredCache timings include store access.
+// sink runs on the calling goroutine and must be fast and safe for concurrent use.
+func LatencySampler(rate float64, sink func(op string, d time.Duration)) Option {
+	return func(c *config) {
+		c.sampleRate = rate
+		c.sampleSink = sink
+	}
+}
+
+// ContentionStats counts write-lock acquisitions that had to wait, reported as
+// Stats.LockContentions. Adds a TryLock attempt to each locked operation.
+func ContentionStats() Option {
+	return func(c *config) { c.contentionStats = true }
+}
+
+// HitStats counts memory lookups, reported as Stats.Hits and Stats.Misses. The counters
+// are striped to keep contention off the read path, but still add a few nanoseconds
+// to each Get, so they are off by default.
+func HitStats() Option {
+	return func(c *config) { c.hitStats = true }
+}
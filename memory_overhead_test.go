@@ -0,0 +1,43 @@
+//go:build memcheck
+
+package fido
+
+import (
+	"runtime"
+	"testing"
+)
+
+// maxOverheadBytes is the per-entry memory budget beyond the key and value themselves.
+// An int-keyed cache measured about 97 bytes when this was written; the slack absorbs
+// allocator noise, not new per-entry fields. Raise it only with a reason.
+const maxOverheadBytes = 104
+
+// TestCache_MemoryOverhead fails if a full cache costs more than maxOverheadBytes per
+// entry on top of its keys and values. Heap measurements vary a little with the Go
+// version and GC timing, so it only runs with -tags memcheck.
+func TestCache_MemoryOverhead(t *testing.T) {
+	const n = 100_000
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	cache := New[int, int](Size(n))
+	for i := range n {
+		cache.Set(i, i)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(cache)
+
+	if cache.Len() != n {
+		t.Fatalf("Len() = %d; want %d", cache.Len(), n)
+	}
+	const payload = 16 // int key + int value
+	perItem := float64(after.HeapAlloc-before.HeapAlloc)/n - payload
+	t.Logf("overhead: %.1f bytes/entry", perItem)
+	if perItem > maxOverheadBytes {
+		t.Errorf("overhead = %.1f bytes/entry; want <= %d", perItem, maxOverheadBytes)
+	}
+}
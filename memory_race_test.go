@@ -3,6 +3,8 @@
 package fido
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -57,3 +59,229 @@ func TestCache_Fetch_CacheHitDuringSingleflight(t *testing.T) {
 
 	t.Logf("loader calls: %d", loaderCalls.Load())
 }
+
+// ageSoft makes key's FetchStale entry stale now, as if softTTL had passed.
+func ageSoft(cache *Cache[string, int], key string) {
+	sd, _ := cache.soft.byKey.Load(key)
+	sd.staleSec = cache.memory.nowSec()
+	cache.soft.byKey.Store(key, sd)
+}
+
+func TestCache_FetchStale(t *testing.T) {
+	cache := New[string, int]()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 2, nil
+	}
+	one := func() (int, error) { return 1, nil }
+
+	// Fresh: within softTTL of being stored.
+	if v, err := cache.FetchStale("fresh", time.Minute, 2*time.Hour, one); err != nil || v != 1 {
+		t.Errorf("FetchStale(fresh) = %d, %v; want 1, nil", v, err)
+	}
+	if v, err := cache.FetchStale("fresh", time.Minute, 2*time.Hour, loader); err != nil || v != 1 {
+		t.Errorf("FetchStale(fresh) = %d, %v; want 1, nil", v, err)
+	}
+
+	// Stale: past softTTL.
+	cache.FetchStale("stale", time.Minute, 2*time.Hour, one) //nolint:errcheck // Test helper
+	ageSoft(cache, "stale")
+	for range 5 {
+		if v, err := cache.FetchStale("stale", time.Minute, 2*time.Hour, loader); err != nil || v != 1 {
+			t.Errorf("FetchStale(stale) = %d, %v; want stale 1, nil", v, err)
+		}
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if v, _ := cache.Get("stale"); v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh did not store the new value")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times; want 1 deduplicated refresh", n)
+	}
+	if _, exp, _ := cache.GetWithExpiry("stale"); time.Until(exp) < 119*time.Minute {
+		t.Errorf("refreshed entry expires in %v; want ~hardTTL", time.Until(exp))
+	}
+	for cache.soft.stale(cache.memory, "stale") {
+		if time.Now().After(deadline) {
+			t.Fatal("refreshed entry is still stale")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Absent: blocks on the loader.
+	if v, err := cache.FetchStale("absent", time.Minute, time.Hour, loader); err != nil || v != 2 {
+		t.Errorf("FetchStale(absent) = %d, %v; want 2, nil", v, err)
+	}
+
+	// Entries FetchStale did not store, or that were touched since, are not stale.
+	cache.SetTTL("set", 1, time.Second)
+	cache.FetchStale("touched", time.Minute, 2*time.Hour, one) //nolint:errcheck // Test helper
+	ageSoft(cache, "touched")
+	cache.Touch("touched", time.Hour)
+	for _, key := range []string{"set", "touched"} {
+		if cache.soft.stale(cache.memory, key) {
+			t.Errorf("FetchStale(%s) stale; want fresh", key)
+		}
+	}
+
+	// A failed refresh keeps the stale value.
+	cache.FetchStale("keep", time.Minute, 2*time.Hour, one) //nolint:errcheck // Test helper
+	ageSoft(cache, "keep")
+	done := make(chan struct{})
+	if v, _ := cache.FetchStale("keep", time.Minute, 2*time.Hour, func() (int, error) {
+		defer close(done)
+		return 0, errors.New("backend down")
+	}); v != 1 {
+		t.Errorf("FetchStale(keep) = %d; want 1", v)
+	}
+	<-done
+	if v, ok := cache.Get("keep"); !ok || v != 1 {
+		t.Errorf("Get(keep) after failed refresh = %d, %v; want 1, true", v, ok)
+	}
+}
+
+func TestCache_FetchStale_TTLOptions(t *testing.T) {
+	// Staleness follows softTTL, not the expiry that MaxTTL or TTLJitter produced.
+	cache := New[string, int](MaxTTL(30*time.Minute), TTLJitter(0.5))
+	var calls atomic.Int32
+	loader := func() (int, error) {
+		calls.Add(1)
+		return 1, nil
+	}
+	for range 3 {
+		if v, err := cache.FetchStale("k", time.Minute, 2*time.Hour, loader); err != nil || v != 1 {
+			t.Errorf("FetchStale = %d, %v; want 1, nil", v, err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times; want 1 (entry is still fresh)", n)
+	}
+}
+
+func TestCache_FetchContext(t *testing.T) {
+	cache := New[string, int]()
+	type ctxKey struct{}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		if ctx.Value(ctxKey{}) != "v" {
+			t.Error("loader context lost the caller's values")
+		}
+		close(started)
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	// The caller that started the load gives up; a second waiter still gets the value.
+	ctx1, cancel1 := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "v"))
+	errc := make(chan error, 1)
+	go func() {
+		_, err := cache.FetchContext(ctx1, "k", loader)
+		errc <- err
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		if v, err := cache.FetchContext(context.Background(), "k", loader); err != nil || v != 1 {
+			t.Errorf("FetchContext(waiter) = %d, %v; want 1, nil", v, err)
+		}
+	})
+	for cache.memory.coalescedCalls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel1()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchContext(cancelled) error = %v; want context.Canceled", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if v, ok := cache.Get("k"); !ok || v != 1 {
+		t.Errorf("Get(k) = %d, %v; want 1, true", v, ok)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times; want 1", n)
+	}
+
+	// Once every waiter gives up, the loader is cancelled and nothing is stored.
+	loaderDone := make(chan error, 1)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel2()
+	_, err := cache.FetchContext(ctx2, "slow", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		loaderDone <- ctx.Err()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FetchContext(slow) error = %v; want context.DeadlineExceeded", err)
+	}
+	select {
+	case err := <-loaderDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("loader context error = %v; want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("loader was not cancelled after its only waiter gave up")
+	}
+	if _, ok := cache.Get("slow"); ok {
+		t.Error("failed load should not be cached")
+	}
+}
+
+func TestCache_FetchContext_Panic(t *testing.T) {
+	cache := New[string, int]()
+	ctx := context.Background()
+	release := make(chan struct{})
+	loader := func(context.Context) (int, error) {
+		<-release
+		panic("boom")
+	}
+
+	var wg sync.WaitGroup
+	var panics atomic.Int32
+	for range 2 {
+		wg.Go(func() {
+			defer func() {
+				if r := recover(); r == "boom" {
+					panics.Add(1)
+				}
+			}()
+			cache.FetchContext(ctx, "k", loader) //nolint:errcheck // panics
+		})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.memory.coalescedCalls.Load() < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("second caller never joined the flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := panics.Load(); n != 2 {
+		t.Errorf("%d callers panicked; want 2", n)
+	}
+	if v, err := cache.FetchContext(ctx, "k", func(context.Context) (int, error) { return 1, nil }); err != nil || v != 1 {
+		t.Errorf("FetchContext after panic = %d, %v; want 1, nil", v, err)
+	}
+}
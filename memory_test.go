@@ -1,7 +1,11 @@
 package fido
 
 import (
+	"bytes"
+	"encoding/gob"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -520,6 +524,15 @@ func TestCache_Fetch_ThunderingHerd(t *testing.T) {
 	if loaderCalls != 1 {
 		t.Errorf("loader calls = %d; want 1 (thundering herd prevention failed)", loaderCalls)
 	}
+
+	// Stats agree; callers that arrived after the load finished hit memory instead.
+	st := cache.Stats()
+	if st.LoaderCalls != 1 {
+		t.Errorf("Stats().LoaderCalls = %d; want 1", st.LoaderCalls)
+	}
+	if st.CoalescedCalls < 1 || st.CoalescedCalls > 99 {
+		t.Errorf("Stats().CoalescedCalls = %d; want 1..99", st.CoalescedCalls)
+	}
 }
 
 func TestCache_Fetch_WithTTL(t *testing.T) {
@@ -1075,6 +1088,25 @@ func BenchmarkCache_Range_100k_EarlyTermination(b *testing.B) {
 	}
 }
 
+func BenchmarkCache_ForEach_100k(b *testing.B) {
+	cache := New[int, int](Size(100000))
+
+	// Populate with 100k entries
+	for i := range 100000 {
+		cache.Set(i, i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		count := 0
+		cache.ForEach(func(int, int) bool {
+			count++
+			return true
+		})
+	}
+}
+
 func BenchmarkCache_Range_KeysOnly(b *testing.B) {
 	cache := New[int, int](Size(100000))
 
@@ -1092,3 +1124,1930 @@ func BenchmarkCache_Range_KeysOnly(b *testing.B) {
 		}
 	}
 }
+
+func TestCache_UpdateMulti_Move(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("a", 10)
+
+	cache.UpdateMulti([]string{"a", "b"}, func(cur map[string]int) map[string]int {
+		if _, ok := cur["b"]; ok {
+			t.Error("b should not be present before move")
+		}
+		return map[string]int{"b": cur["a"]}
+	})
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("a should be deleted after move")
+	}
+	if v, ok := cache.Get("b"); !ok || v != 10 {
+		t.Errorf("Get(b) = %d, %v; want 10, true", v, ok)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d; want 1", cache.Len())
+	}
+}
+
+func TestCache_UpdateMulti_IgnoresUnlistedKeys(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("a", 1)
+
+	cache.UpdateMulti([]string{"a"}, func(cur map[string]int) map[string]int {
+		return map[string]int{"a": cur["a"] + 1, "other": 5}
+	})
+
+	if v, _ := cache.Get("a"); v != 2 {
+		t.Errorf("Get(a) = %d; want 2", v)
+	}
+	if _, ok := cache.Get("other"); ok {
+		t.Error("unlisted key should not be stored")
+	}
+}
+
+func TestCache_UpdateMulti_Concurrent(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("x", 1000)
+	cache.Set("y", 0)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			for range 20 {
+				cache.UpdateMulti([]string{"x", "y"}, func(cur map[string]int) map[string]int {
+					return map[string]int{"x": cur["x"] - 1, "y": cur["y"] + 1}
+				})
+			}
+		})
+	}
+	wg.Wait()
+
+	x, _ := cache.Get("x")
+	y, _ := cache.Get("y")
+	if x+y != 1000 || y != 1000 {
+		t.Errorf("x=%d y=%d; want x=0 y=1000", x, y)
+	}
+}
+
+func TestCache_FetchMany_Basic(t *testing.T) {
+	cache := New[int, string]()
+	cache.Set(1, "cached")
+
+	var batches [][]int
+	got, err := cache.FetchMany([]int{1, 2, 3, 2}, func(missing []int) (map[int]string, error) {
+		batches = append(batches, missing)
+		out := make(map[int]string)
+		for _, k := range missing {
+			if k != 3 { // 3 does not exist upstream
+				out[k] = fmt.Sprintf("loaded-%d", k)
+			}
+		}
+		return out, nil
+	})
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("loader batches = %v; want one batch of [2 3]", batches)
+	}
+	if got[1] != "cached" || got[2] != "loaded-2" {
+		t.Errorf("FetchMany = %v", got)
+	}
+	if _, ok := got[3]; ok {
+		t.Error("key missing from loader result should be omitted")
+	}
+	if v, ok := cache.Get(2); !ok || v != "loaded-2" {
+		t.Errorf("Get(2) = %q, %v; want loaded value cached", v, ok)
+	}
+	if _, ok := cache.Get(3); ok {
+		t.Error("key missing from loader result should not be cached")
+	}
+}
+
+func TestCache_FetchMany_LoaderError(t *testing.T) {
+	cache := New[int, int]()
+	cache.Set(1, 10)
+
+	got, err := cache.FetchMany([]int{1, 2}, func([]int) (map[int]int, error) {
+		return nil, fmt.Errorf("backend down")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got[1] != 10 {
+		t.Errorf("hits should still be returned on error, got %v", got)
+	}
+	if _, ok := cache.Get(2); ok {
+		t.Error("nothing should be cached on loader error")
+	}
+}
+
+func TestCache_FetchMany_WithTTL(t *testing.T) {
+	cache := New[int, int]()
+
+	if _, err := cache.FetchManyTTL([]int{1}, time.Second, func(missing []int) (map[int]int, error) {
+		return map[int]int{1: 1}, nil
+	}); err != nil {
+		t.Fatalf("FetchManyTTL: %v", err)
+	}
+
+	time.Sleep(2 * time.Second)
+	if _, ok := cache.Get(1); ok {
+		t.Error("value should expire after TTL")
+	}
+}
+
+func TestCache_FetchMany_OverlappingCallers(t *testing.T) {
+	cache := New[int, int]()
+
+	var mu sync.Mutex
+	loads := make(map[int]int)
+	loader := func(missing []int) (map[int]int, error) {
+		time.Sleep(20 * time.Millisecond)
+		out := make(map[int]int, len(missing))
+		mu.Lock()
+		for _, k := range missing {
+			loads[k]++
+			out[k] = k * 10
+		}
+		mu.Unlock()
+		return out, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			keys := []int{i % 5, (i + 1) % 5, (i + 2) % 5}
+			got, err := cache.FetchMany(keys, loader)
+			if err != nil {
+				t.Errorf("FetchMany: %v", err)
+				return
+			}
+			for _, k := range keys {
+				if got[k] != k*10 {
+					t.Errorf("got[%d] = %d; want %d", k, got[k], k*10)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	for k, n := range loads {
+		if n != 1 {
+			t.Errorf("key %d loaded %d times; want 1", k, n)
+		}
+	}
+}
+
+func TestCache_Fetch_WaitsOnFetchManyMissingKey(t *testing.T) {
+	cache := New[int, int]()
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		//nolint:errcheck // exercised for side effects
+		cache.FetchMany([]int{1}, func([]int) (map[int]int, error) {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return nil, nil // key 1 not found upstream
+		})
+	}()
+
+	<-started
+	val, err := cache.Fetch(1, func() (int, error) { return 7, nil })
+	if err != nil || val != 7 {
+		t.Errorf("Fetch = %d, %v; want 7, nil (own loader after batch miss)", val, err)
+	}
+	<-done
+}
+
+func TestCache_Stats_Len(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	st := cache.Stats()
+	if st.Len != 2 {
+		t.Errorf("Stats().Len = %d; want 2", st.Len)
+	}
+	if st.LockContentions != 0 {
+		t.Errorf("LockContentions = %d; want 0 when ContentionStats is off", st.LockContentions)
+	}
+}
+
+func TestCache_SetMany_GetMany(t *testing.T) {
+	cache := New[string, int](Size(100))
+	items := make(map[string]int)
+	for i := range 20 {
+		items[fmt.Sprintf("k%d", i)] = i
+	}
+	cache.Set("k0", -1)
+	cache.SetMany(items)
+	if n := cache.Len(); n != 20 {
+		t.Errorf("Len = %d; want 20", n)
+	}
+	if v, _ := cache.Get("k0"); v != 0 {
+		t.Errorf("Get(k0) = %d; want 0 (SetMany overwrites)", v)
+	}
+
+	got := cache.GetMany([]string{"k1", "k19", "missing"})
+	if len(got) != 2 || got["k1"] != 1 || got["k19"] != 19 {
+		t.Errorf("GetMany = %v; want k1 and k19 only", got)
+	}
+
+	cache.SetManyTTL(map[string]int{"short": 1}, time.Hour)
+	ent, ok := cache.memory.getEntry("short")
+	if !ok || ent.expirySec.Load() == 0 {
+		t.Fatal("SetManyTTL did not set an expiry")
+	}
+	ent.expirySec.Store(1)
+	if got := cache.GetMany([]string{"short"}); len(got) != 0 {
+		t.Errorf("GetMany returned expired entry: %v", got)
+	}
+}
+
+func BenchmarkCache_SetMany(b *testing.B) {
+	items := make(map[string]int, 256)
+	for i := range 256 {
+		items[fmt.Sprintf("batch-key-%d", i)] = i
+	}
+	b.Run("Set", func(b *testing.B) {
+		cache := New[string, int](Size(len(items)))
+		for range b.N {
+			cache.Flush()
+			for k, v := range items {
+				cache.Set(k, v)
+			}
+		}
+	})
+	b.Run("SetMany", func(b *testing.B) {
+		cache := New[string, int](Size(len(items)))
+		for range b.N {
+			cache.Flush()
+			cache.SetMany(items)
+		}
+	})
+}
+
+func TestCache_Stats_Hits(t *testing.T) {
+	cache := New[string, int](HitStats())
+	cache.Set("a", 1)
+	cache.SetTTL("old", 1, time.Hour)
+	ent, _ := cache.memory.getEntry("old")
+	ent.expirySec.Store(1)
+
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("missing")
+	cache.Get("old")
+
+	st := cache.Stats()
+	if st.Hits != 2 || st.Misses != 2 {
+		t.Errorf("Hits, Misses = %d, %d; want 2, 2", st.Hits, st.Misses)
+	}
+
+	plain := New[string, int]()
+	plain.Get("missing")
+	if st := plain.Stats(); st.Hits != 0 || st.Misses != 0 {
+		t.Errorf("Hits, Misses = %d, %d; want 0, 0 when HitStats is off", st.Hits, st.Misses)
+	}
+}
+
+func TestCache_ZeroValues(t *testing.T) {
+	t.Run("int", func(t *testing.T) {
+		cache := New[string, int]()
+		cache.Set("zero", 0)
+
+		if v, ok := cache.Get("zero"); !ok || v != 0 {
+			t.Errorf("Get = %d, %v; want 0, true", v, ok)
+		}
+
+		// Fetch must treat a cached zero as a hit.
+		calls := 0
+		v, err := cache.Fetch("zero", func() (int, error) {
+			calls++
+			return 99, nil
+		})
+		if err != nil || v != 0 || calls != 0 {
+			t.Errorf("Fetch = %d, %v (loader calls %d); want cached 0 without loading", v, err, calls)
+		}
+
+		// A loader returning zero is cached like any other value.
+		if _, err := cache.Fetch("loaded", func() (int, error) { return 0, nil }); err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		if v, ok := cache.Get("loaded"); !ok || v != 0 {
+			t.Errorf("Get(loaded) = %d, %v; want 0, true", v, ok)
+		}
+
+		got, err := cache.FetchMany([]string{"zero", "batch"}, func(missing []string) (map[string]int, error) {
+			return map[string]int{"batch": 0}, nil
+		})
+		if err != nil {
+			t.Fatalf("FetchMany: %v", err)
+		}
+		if v, ok := got["zero"]; !ok || v != 0 {
+			t.Errorf("FetchMany[zero] = %d, %v; want 0, true", v, ok)
+		}
+		if v, ok := got["batch"]; !ok || v != 0 {
+			t.Errorf("FetchMany[batch] = %d, %v; want 0, true", v, ok)
+		}
+
+		cache.UpdateMulti([]string{"zero", "absent"}, func(cur map[string]int) map[string]int {
+			if v, ok := cur["zero"]; !ok || v != 0 {
+				t.Errorf("UpdateMulti cur[zero] = %d, %v; want 0, true", v, ok)
+			}
+			if _, ok := cur["absent"]; ok {
+				t.Error("UpdateMulti should not report absent key")
+			}
+			return cur
+		})
+		if _, ok := cache.Get("zero"); !ok {
+			t.Error("zero value should survive UpdateMulti round trip")
+		}
+		if _, ok := cache.Get("absent"); ok {
+			t.Error("absent key should stay absent after UpdateMulti")
+		}
+
+		seen := false
+		for k, v := range cache.Range() {
+			if k == "zero" && v == 0 {
+				seen = true
+			}
+		}
+		if !seen {
+			t.Error("Range should yield cached zero value")
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		cache := New[int, string]()
+		cache.Set(1, "")
+
+		if v, ok := cache.Get(1); !ok || v != "" {
+			t.Errorf("Get = %q, %v; want \"\", true", v, ok)
+		}
+		if _, ok := cache.Get(2); ok {
+			t.Error("absent key should not be found")
+		}
+
+		calls := 0
+		v, err := cache.Fetch(1, func() (string, error) {
+			calls++
+			return "loaded", nil
+		})
+		if err != nil || v != "" || calls != 0 {
+			t.Errorf("Fetch = %q, %v (loader calls %d); want cached empty string", v, err, calls)
+		}
+	})
+}
+
+func TestCache_KeyHandle(t *testing.T) {
+	cache := New[string, int]()
+	h := cache.Handle("hot")
+
+	if h.Key() != "hot" {
+		t.Errorf("Key() = %q; want hot", h.Key())
+	}
+	if _, ok := cache.GetHandle(h); ok {
+		t.Error("GetHandle should miss before SetHandle")
+	}
+
+	cache.SetHandle(h, 1)
+	if v, ok := cache.GetHandle(h); !ok || v != 1 {
+		t.Errorf("GetHandle = %d, %v; want 1, true", v, ok)
+	}
+	if v, ok := cache.Get("hot"); !ok || v != 1 {
+		t.Errorf("Get = %d, %v; handle writes must be visible to plain Get", v, ok)
+	}
+
+	ent, _ := cache.memory.getEntry("hot")
+	if ent.hash64 != hashString("hot") {
+		t.Errorf("hash64 = %x; want precomputed hash %x", ent.hash64, hashString("hot"))
+	}
+}
+
+func TestCache_KeyHandle_DefaultTTL(t *testing.T) {
+	cache := New[int, int](TTL(time.Second))
+	cache.SetHandle(cache.Handle(1), 1)
+
+	time.Sleep(2 * time.Second)
+	if _, ok := cache.GetHandle(cache.Handle(1)); ok {
+		t.Error("SetHandle should apply the default TTL")
+	}
+}
+
+func BenchmarkCache_SetHandle_Insert(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("handle-key-%d", i)
+	}
+	b.Run("Set", func(b *testing.B) {
+		cache := New[string, int](Size(len(keys)))
+		for i := range b.N {
+			cache.Flush()
+			for _, k := range keys {
+				cache.Set(k, i)
+			}
+		}
+	})
+	b.Run("SetHandle", func(b *testing.B) {
+		cache := New[string, int](Size(len(keys)))
+		handles := make([]KeyHandle[string], len(keys))
+		for i, k := range keys {
+			handles[i] = cache.Handle(k)
+		}
+		b.ResetTimer()
+		for i := range b.N {
+			cache.Flush()
+			for _, h := range handles {
+				cache.SetHandle(h, i)
+			}
+		}
+	})
+}
+
+func TestCache_MaxKeyBytes(t *testing.T) {
+	cache := New[string, int](MaxKeyBytes(8))
+
+	cache.Set("short", 1)
+	cache.Set("exactly8", 2)
+	cache.Set("much-too-long", 3)
+	cache.SetHandle(cache.Handle("another-long-key"), 4)
+	if _, err := cache.Fetch("fetched-long-key", func() (int, error) { return 5, nil }); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	for _, k := range []string{"short", "exactly8"} {
+		if _, ok := cache.Get(k); !ok {
+			t.Errorf("Get(%q) missing; keys within the limit must be stored", k)
+		}
+	}
+	for _, k := range []string{"much-too-long", "another-long-key", "fetched-long-key"} {
+		if _, ok := cache.Get(k); ok {
+			t.Errorf("Get(%q) found; over-long keys must be rejected", k)
+		}
+	}
+	if got := cache.Stats().RejectedKeys; got != 3 {
+		t.Errorf("Stats().RejectedKeys = %d; want 3", got)
+	}
+}
+
+func TestCache_MaxKeyBytes_NonStringKeys(t *testing.T) {
+	cache := New[int, int](MaxKeyBytes(1))
+	cache.Set(123456789, 1)
+	if _, ok := cache.Get(123456789); !ok {
+		t.Error("MaxKeyBytes should not apply to non-string keys")
+	}
+}
+
+func TestCache_Merge(t *testing.T) {
+	for _, overwrite := range []bool{false, true} {
+		t.Run(fmt.Sprintf("overwrite=%v", overwrite), func(t *testing.T) {
+			old := New[string, int]()
+			old.Set("shared", 1)
+			old.Set("only-old", 2)
+			old.SetTTL("ttl", 3, time.Hour)
+
+			fresh := New[string, int]()
+			fresh.Set("shared", 100)
+			fresh.Merge(old, overwrite)
+
+			want := 100
+			if overwrite {
+				want = 1
+			}
+			if v, _ := fresh.Get("shared"); v != want {
+				t.Errorf("Get(shared) = %d; want %d", v, want)
+			}
+			if v, ok := fresh.Get("only-old"); !ok || v != 2 {
+				t.Errorf("Get(only-old) = %d, %v; want 2, true", v, ok)
+			}
+
+			ent, ok := fresh.memory.getEntry("ttl")
+			if !ok {
+				t.Fatal("ttl entry not merged")
+			}
+			remaining := time.Until(time.Unix(int64(ent.expirySec.Load()), 0))
+			if remaining < 59*time.Minute || remaining > time.Hour+time.Second {
+				t.Errorf("merged TTL remaining = %v; want about 1h", remaining)
+			}
+		})
+	}
+}
+
+func TestCache_Merge_SkipsExpired(t *testing.T) {
+	old := New[string, int]()
+	//nolint:gosec // G115: test value
+	old.memory.set("expired", 1, uint32(time.Now().Add(-time.Minute).Unix()))
+	old.Set("live", 2)
+
+	fresh := New[string, int]()
+	fresh.Merge(old, true)
+	fresh.Merge(fresh, true) // self-merge is a no-op
+
+	if _, ok := fresh.memory.getEntry("expired"); ok {
+		t.Error("expired entry should not be merged")
+	}
+	if fresh.Len() != 1 {
+		t.Errorf("Len() = %d; want 1", fresh.Len())
+	}
+}
+
+func TestCache_Merge_SkipsDeathRow(t *testing.T) {
+	old := New[string, int]()
+	old.Set("evicted", 1)
+	old.Set("live", 2)
+
+	ent, _ := old.memory.getEntry("evicted")
+	old.memory.mu.Lock()
+	old.memory.small.remove(ent)
+	ent.setFreqPeak(0, maxPeakFreq)
+	old.memory.sendToDeathRow(ent)
+	old.memory.mu.Unlock()
+	if !ent.onDeathRow() {
+		t.Fatal("evicted should be on death row")
+	}
+
+	fresh := New[string, int]()
+	fresh.Merge(old, true)
+	if _, ok := fresh.memory.getEntry("evicted"); ok {
+		t.Error("death row entry should not be merged")
+	}
+	if v, ok := fresh.Get("live"); !ok || v != 2 {
+		t.Errorf("Get(live) = %d, %v; want 2, true", v, ok)
+	}
+}
+
+func TestCache_RejectWhenFull(t *testing.T) {
+	cache := New[int, int](Size(10), RejectWhenFull())
+	for i := range 10 {
+		if !cache.TrySet(i, i) {
+			t.Fatalf("TrySet(%d) = false below capacity", i)
+		}
+	}
+
+	if cache.TrySet(100, 100) {
+		t.Error("TrySet on a full cache should return false")
+	}
+	cache.Set(101, 101)
+	for _, k := range []int{100, 101} {
+		if _, ok := cache.Get(k); ok {
+			t.Errorf("Get(%d) found; insert should have been refused", k)
+		}
+	}
+	for i := range 10 {
+		if _, ok := cache.Get(i); !ok {
+			t.Errorf("Get(%d) missing; nothing should be evicted", i)
+		}
+	}
+
+	// Updates to existing keys still succeed.
+	if !cache.TrySet(3, 33) {
+		t.Error("TrySet on an existing key should succeed when full")
+	}
+	if v, _ := cache.Get(3); v != 33 {
+		t.Errorf("Get(3) = %d; want 33", v)
+	}
+
+	// Deleting frees a slot.
+	cache.Delete(0)
+	if !cache.TrySet(100, 100) {
+		t.Error("TrySet should succeed after Delete frees a slot")
+	}
+
+	if got := cache.Stats().RejectedFull; got != 2 {
+		t.Errorf("Stats().RejectedFull = %d; want 2", got)
+	}
+}
+
+func TestCache_TrySet_Evicts(t *testing.T) {
+	cache := New[int, int](Size(10))
+	for i := range 20 {
+		if !cache.TrySet(i, i) {
+			t.Errorf("TrySet(%d) = false; without RejectWhenFull inserts evict instead", i)
+		}
+	}
+	if cache.Len() > 10 {
+		t.Errorf("Len() = %d; want <= 10", cache.Len())
+	}
+	if got := cache.Stats().RejectedFull; got != 0 {
+		t.Errorf("Stats().RejectedFull = %d; want 0", got)
+	}
+}
+
+func TestCache_LatencySampler(t *testing.T) {
+	var mu sync.Mutex
+	counts := map[string]int{}
+	sink := func(op string, d time.Duration) {
+		if d < 0 {
+			t.Errorf("negative duration %v for %s", d, op)
+		}
+		mu.Lock()
+		counts[op]++
+		mu.Unlock()
+	}
+
+	cache := New[int, int](LatencySampler(1, sink))
+	for i := range 100 {
+		cache.Set(i, i)
+		cache.Get(i)
+	}
+	if counts["get"] != 100 || counts["set"] != 100 {
+		t.Errorf("rate 1: counts = %v; want 100 get and 100 set", counts)
+	}
+
+	clear(counts)
+	cache = New[int, int](LatencySampler(0.1, sink))
+	for i := range 10000 {
+		cache.Get(i)
+	}
+	if n := counts["get"]; n < 700 || n > 1300 {
+		t.Errorf("rate 0.1: sampled %d of 10000 gets; want about 1000", n)
+	}
+
+	clear(counts)
+	cache = New[int, int](LatencySampler(0, sink))
+	cache.Set(1, 1)
+	cache.Get(1)
+	if len(counts) != 0 {
+		t.Errorf("rate 0: counts = %v; want none", counts)
+	}
+}
+
+func TestCache_Contains(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("a", 1)
+	//nolint:gosec // G115: test value
+	cache.memory.set("expired", 2, uint32(time.Now().Add(-time.Minute).Unix()))
+
+	if !cache.Contains("a") {
+		t.Error("Contains(a) = false; want true")
+	}
+	for _, k := range []string{"expired", "missing"} {
+		if cache.Contains(k) {
+			t.Errorf("Contains(%q) = true; want false", k)
+		}
+	}
+
+	ent, _ := cache.memory.getEntry("a")
+	before := ent.freqFlags.Load()
+	cache.Contains("a")
+	if ent.freqFlags.Load() != before {
+		t.Error("Contains should not bump access frequency")
+	}
+
+	cache.memory.mu.Lock()
+	cache.memory.small.remove(ent)
+	ent.setFreqPeak(0, maxPeakFreq)
+	cache.memory.sendToDeathRow(ent)
+	cache.memory.mu.Unlock()
+	if !ent.onDeathRow() {
+		t.Fatal("a should be on death row")
+	}
+	if cache.Contains("a") {
+		t.Error("Contains(a) = true for a death row entry; want false")
+	}
+	if !ent.onDeathRow() {
+		t.Error("Contains resurrected a death row entry")
+	}
+}
+
+func TestCache_SnapshotRestore(t *testing.T) {
+	src := New[string, int](Size(100))
+	src.Set("forever", 1)
+	src.SetTTL("hour", 2, time.Hour)
+	src.SetTTL("gone", 3, time.Hour)
+	ent, _ := src.memory.getEntry("gone")
+	ent.expirySec.Store(1)
+
+	snap := src.Snapshot()
+	if len(snap) != 2 {
+		t.Fatalf("Snapshot has %d entries; want 2 (expired skipped)", len(snap))
+	}
+
+	// Round-trip through gob, as a restart would.
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		t.Fatalf("gob encode: %v", err)
+	}
+	var decoded []Entry[string, int]
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("gob decode: %v", err)
+	}
+	decoded = append(decoded, Entry[string, int]{Key: "stale", Value: 4, Expiry: time.Now().Add(-time.Second)})
+
+	dst := New[string, int](Size(100))
+	dst.Restore(decoded)
+	if dst.Len() != 2 {
+		t.Errorf("Len after Restore = %d; want 2 (stale entry skipped)", dst.Len())
+	}
+	if v, exp, ok := dst.GetWithExpiry("forever"); !ok || v != 1 || !exp.IsZero() {
+		t.Errorf("forever = %d, %v, %v; want 1 with no expiry", v, exp, ok)
+	}
+	if v, exp, ok := dst.GetWithExpiry("hour"); !ok || v != 2 || time.Until(exp) < 59*time.Minute {
+		t.Errorf("hour = %d, expiry in %v, %v; want 2 with about 1h left", v, time.Until(exp), ok)
+	}
+}
+
+func TestCache_ReadOnly(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("a", 1)
+
+	ro := cache.ReadOnly()
+	if _, ok := ro.(*Cache[string, int]); ok {
+		t.Fatal("ReadOnly view should not be assertable to *Cache")
+	}
+	if v, ok := ro.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v; want 1, true", v, ok)
+	}
+	if !ro.Contains("a") || ro.Len() != 1 || ro.Stats().Len != 1 {
+		t.Error("ReadOnly view should reflect cache contents")
+	}
+
+	cache.Set("b", 2)
+	n := 0
+	for range ro.Range() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Range yielded %d entries; want 2 (view must see later writes)", n)
+	}
+}
+
+func TestCache_Stats_RemovalCauses(t *testing.T) {
+	cache := New[int, int](Size(10), EvictionPolicy(PolicyFIFO))
+	//nolint:gosec // G115: test value
+	past := uint32(time.Now().Add(-time.Minute).Unix())
+	for i := range 10 {
+		if i < 3 {
+			cache.memory.set(i, i, past) // already expired
+		} else {
+			cache.Set(i, i)
+		}
+	}
+
+	cache.Delete(9)
+	cache.Delete(9) // absent: not counted
+	for i := 10; i < 16; i++ {
+		cache.Set(i, i) // first fills the freed slot, then evicts 0..4 in order
+	}
+
+	st := cache.Stats()
+	if st.ExpiredEvictions != 3 {
+		t.Errorf("ExpiredEvictions = %d; want 3", st.ExpiredEvictions)
+	}
+	if st.CapacityEvictions != 2 {
+		t.Errorf("CapacityEvictions = %d; want 2", st.CapacityEvictions)
+	}
+	if st.ExplicitDeletes != 1 {
+		t.Errorf("ExplicitDeletes = %d; want 1", st.ExplicitDeletes)
+	}
+}
+
+func TestCache_Stats_CapacityEvictions(t *testing.T) {
+	cache := New[int, int](Size(100))
+	for i := range 1000 {
+		cache.Set(i, i)
+	}
+	st := cache.Stats()
+	// Some evicted entries may still sit on death row, so the count can lag slightly.
+	if st.CapacityEvictions == 0 || st.CapacityEvictions > 900 {
+		t.Errorf("CapacityEvictions = %d; want (0, 900]", st.CapacityEvictions)
+	}
+	if st.ExpiredEvictions != 0 || st.ExplicitDeletes != 0 {
+		t.Errorf("unexpected removals: %+v", st)
+	}
+}
+
+func TestCache_FlushVolatile(t *testing.T) {
+	cache := New[string, int](Size(100))
+	for i := range 10 {
+		cache.Set(fmt.Sprintf("config%d", i), i)
+		cache.SetTTL(fmt.Sprintf("api%d", i), i, time.Hour)
+	}
+
+	if n := cache.FlushVolatile(); n != 10 {
+		t.Errorf("FlushVolatile() = %d; want 10", n)
+	}
+	if cache.Len() != 10 {
+		t.Errorf("Len() = %d; want 10", cache.Len())
+	}
+	for i := range 10 {
+		if _, ok := cache.Get(fmt.Sprintf("config%d", i)); !ok {
+			t.Errorf("config%d should survive FlushVolatile", i)
+		}
+		if _, ok := cache.Get(fmt.Sprintf("api%d", i)); ok {
+			t.Errorf("api%d should be removed by FlushVolatile", i)
+		}
+	}
+
+	// Queues stay consistent: filling past capacity still evicts normally.
+	for i := range 200 {
+		cache.SetTTL(fmt.Sprintf("more%d", i), i, time.Hour)
+	}
+	if cache.Len() > 100 {
+		t.Errorf("Len() = %d; want <= 100", cache.Len())
+	}
+	if n := cache.FlushVolatile(); n == 0 {
+		t.Error("second FlushVolatile should remove the new TTL entries")
+	}
+}
+
+func TestCache_FetchScoped(t *testing.T) {
+	cache := New[string, string]()
+
+	release := make(chan struct{})
+	calls := map[string]*atomic.Int32{"tenant-a": {}, "tenant-b": {}}
+	loader := func(scope string) func() (string, error) {
+		return func() (string, error) {
+			calls[scope].Add(1)
+			<-release
+			return "value-from-" + scope, nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan string, 20)
+	for i := range 20 {
+		scope := "tenant-a"
+		if i%2 == 1 {
+			scope = "tenant-b"
+		}
+		wg.Go(func() {
+			v, err := cache.FetchScoped("key", scope, loader(scope))
+			if err != nil {
+				t.Errorf("FetchScoped: %v", err)
+			}
+			results <- v
+		})
+	}
+	time.Sleep(50 * time.Millisecond) // let callers pile up on their flights
+	close(release)
+	wg.Wait()
+	close(results)
+
+	// Each scope coalesces on its own flight, so both loaders run exactly once.
+	for scope, n := range calls {
+		if got := n.Load(); got != 1 {
+			t.Errorf("loader for %s ran %d times; want 1", scope, got)
+		}
+	}
+
+	// The result is cached under the plain key and shared across scopes.
+	cached, ok := cache.Get("key")
+	if !ok {
+		t.Fatal("FetchScoped result should be cached under key")
+	}
+	v, err := cache.FetchScoped("key", "tenant-c", func() (string, error) {
+		t.Error("loader should not run on a cache hit")
+		return "", nil
+	})
+	if err != nil || v != cached {
+		t.Errorf("FetchScoped hit = %q, %v; want %q, nil", v, err, cached)
+	}
+}
+
+func TestCache_FetchScopedTTL_Error(t *testing.T) {
+	cache := New[string, int]()
+	wantErr := errors.New("denied")
+	if _, err := cache.FetchScopedTTL("k", "s", time.Hour, func() (int, error) { return 0, wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("err = %v; want %v", err, wantErr)
+	}
+	if _, ok := cache.Get("k"); ok {
+		t.Error("failed loads should not be cached")
+	}
+
+	if _, err := cache.FetchScopedTTL("k", "s", time.Hour, func() (int, error) { return 7, nil }); err != nil {
+		t.Fatalf("FetchScopedTTL: %v", err)
+	}
+	ent, _ := cache.memory.getEntry("k")
+	if ent.expirySec.Load() == 0 {
+		t.Error("FetchScopedTTL should store the value with an expiry")
+	}
+}
+
+func TestCache_InvalidateTag(t *testing.T) {
+	cache := New[string, int]()
+	cache.SetWithTags("user:1:profile", 1, []string{"user:1"})
+	cache.SetWithTags("user:1:posts", 2, []string{"user:1", "posts"})
+	cache.SetWithTagsTTL("user:2:posts", 3, []string{"user:2", "posts"}, time.Hour)
+	cache.Set("untagged", 4)
+
+	if n := cache.InvalidateTag("user:1"); n != 2 {
+		t.Errorf("InvalidateTag(user:1) = %d; want 2", n)
+	}
+	for _, k := range []string{"user:1:profile", "user:1:posts"} {
+		if _, ok := cache.Get(k); ok {
+			t.Errorf("%s should be invalidated", k)
+		}
+	}
+	for _, k := range []string{"user:2:posts", "untagged"} {
+		if _, ok := cache.Get(k); !ok {
+			t.Errorf("%s should survive", k)
+		}
+	}
+
+	// user:1:posts was removed along with its other tags.
+	if n := cache.InvalidateTag("posts"); n != 1 {
+		t.Errorf("InvalidateTag(posts) = %d; want 1", n)
+	}
+	if n := cache.InvalidateTag("posts"); n != 0 {
+		t.Errorf("second InvalidateTag(posts) = %d; want 0", n)
+	}
+}
+
+func TestCache_InvalidateTag_Retagging(t *testing.T) {
+	cache := New[string, int]()
+
+	// Plain Set keeps the tags of an existing entry.
+	cache.SetWithTags("a", 1, []string{"t"})
+	cache.Set("a", 2)
+	if n := cache.InvalidateTag("t"); n != 1 {
+		t.Errorf("InvalidateTag after update = %d; want 1", n)
+	}
+
+	// SetWithTags replaces tags.
+	cache.SetWithTags("b", 1, []string{"old"})
+	cache.SetWithTags("b", 2, []string{"new"})
+	if n := cache.InvalidateTag("old"); n != 0 {
+		t.Errorf("InvalidateTag(old) = %d; want 0", n)
+	}
+
+	// A deleted and re-added key does not inherit stale tags.
+	cache.SetWithTags("c", 1, []string{"gone"})
+	cache.Delete("c")
+	cache.Set("c", 2)
+	if n := cache.InvalidateTag("gone"); n != 0 {
+		t.Errorf("InvalidateTag(gone) = %d; want 0", n)
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Error("re-added key should survive its old tag's invalidation")
+	}
+}
+
+func TestCache_InvalidateTag_RecycledEntry(t *testing.T) {
+	cache := New[string, int](Size(1), EvictionPolicy(PolicyFIFO))
+	cache.SetWithTags("a", 1, []string{"t"})
+	old, _ := cache.memory.getEntry("a")
+
+	// Evicting a recycles its entry, which the next insert reuses for a again.
+	cache.Set("b", 2)
+	cache.Set("a", 3)
+	if cur, _ := cache.memory.getEntry("a"); cur != old {
+		t.Fatal("re-added key should reuse its recycled entry")
+	}
+
+	if n := cache.InvalidateTag("t"); n != 0 {
+		t.Errorf("InvalidateTag(t) = %d; want 0 for an untagged re-add", n)
+	}
+	if v, ok := cache.Get("a"); !ok || v != 3 {
+		t.Errorf("Get(a) = %d, %v; want 3, true", v, ok)
+	}
+}
+
+func TestCache_SetWithTags_IndexBounded(t *testing.T) {
+	cache := New[int, int](Size(100))
+	for i := range 10000 {
+		cache.SetWithTags(i, i, []string{"bulk"})
+	}
+	cache.tags.mu.Lock()
+	n := len(cache.tags.byKey)
+	cache.tags.mu.Unlock()
+	if n > 2*100+1 {
+		t.Errorf("tag index holds %d keys; want <= %d after pruning", n, 2*100+1)
+	}
+	if got := cache.InvalidateTag("bulk"); int64(got) != cache.Stats().ExplicitDeletes || got == 0 {
+		t.Errorf("InvalidateTag(bulk) = %d; ExplicitDeletes = %d", got, cache.Stats().ExplicitDeletes)
+	}
+}
+
+func TestCache_MonotonicExpiry(t *testing.T) {
+	cache := New[string, int](MonotonicExpiry())
+	cache.SetTTL("short", 1, time.Second)
+	cache.Set("forever", 2)
+
+	if _, ok := cache.Get("short"); !ok {
+		t.Fatal("short should be present before its TTL")
+	}
+	time.Sleep(2100 * time.Millisecond)
+	if _, ok := cache.Get("short"); ok {
+		t.Error("short should expire on the monotonic clock")
+	}
+	if _, ok := cache.Get("forever"); !ok {
+		t.Error("entries without TTL never expire")
+	}
+}
+
+func TestCache_MonotonicExpiry_WallTimes(t *testing.T) {
+	cache := New[string, int](MonotonicExpiry())
+
+	// Absolute expiries without a monotonic reading (as loaded from a store) keep
+	// their remaining lifetime.
+	wall := time.Now().Add(time.Hour).Round(0)
+	sec := cache.memory.toSec(wall)
+	if remaining := sec - cache.memory.nowSec(); remaining < 3590 || remaining > 3601 {
+		t.Errorf("remaining = %ds; want about 3600", remaining)
+	}
+
+	past := cache.memory.toSec(time.Now().Add(-time.Hour).Round(0))
+	if past == 0 || past >= cache.memory.nowSec() {
+		t.Errorf("past expiry mapped to %d; want a nonzero value before now (%d)", past, cache.memory.nowSec())
+	}
+	if cache.memory.toSec(time.Time{}) != 0 {
+		t.Error("zero time should mean no expiry")
+	}
+
+	// Merging across clocks carries remaining TTL.
+	cache.SetTTL("k", 1, time.Hour)
+	wallCache := New[string, int]()
+	wallCache.Merge(cache, true)
+	ent, _ := wallCache.memory.getEntry("k")
+	remaining := time.Until(time.Unix(int64(ent.expirySec.Load()), 0))
+	if remaining < 59*time.Minute || remaining > time.Hour+2*time.Second {
+		t.Errorf("merged remaining TTL = %v; want about 1h", remaining)
+	}
+}
+
+func TestCache_Stats_Queues(t *testing.T) {
+	cache := New[int, int](Size(1000))
+	for i := range 5000 {
+		cache.Set(i, i)
+		cache.Get(i % 50) // hot keys get promoted to main
+	}
+
+	st := cache.Stats()
+	if st.SmallLen+st.MainLen != st.Len {
+		t.Errorf("SmallLen(%d) + MainLen(%d) != Len(%d)", st.SmallLen, st.MainLen, st.Len)
+	}
+	if st.SmallLen == 0 || st.MainLen == 0 {
+		t.Errorf("both queues should be populated after churn: %+v", st)
+	}
+	if st.GhostEntries == 0 {
+		t.Error("GhostEntries should be nonzero after evictions")
+	}
+	if st.DeathRowLen > len(cache.memory.deathRow) {
+		t.Errorf("DeathRowLen = %d; exceeds death row size %d", st.DeathRowLen, len(cache.memory.deathRow))
+	}
+}
+
+func TestCache_TTLJitter(t *testing.T) {
+	cache := New[string, int](TTLJitter(0.1), TTL(time.Hour))
+	remaining := func(key string) time.Duration {
+		t.Helper()
+		_, exp, ok := cache.GetWithExpiry(key)
+		if !ok {
+			t.Fatalf("%s missing", key)
+		}
+		if exp.IsZero() {
+			return 0
+		}
+		return time.Until(exp)
+	}
+
+	for _, tc := range []struct {
+		key  string
+		rnd  float64
+		want time.Duration
+	}{
+		{"low", 0, 54 * time.Minute},
+		{"mid", 0.5, time.Hour},
+		{"high", 0.999999, 66 * time.Minute},
+	} {
+		cache.memory.jitterRnd = func() float64 { return tc.rnd }
+		cache.Set(tc.key, 1)
+		if r := remaining(tc.key); r < tc.want-2*time.Second || r > tc.want+time.Second {
+			t.Errorf("%s remaining = %v; want about %v", tc.key, r, tc.want)
+		}
+	}
+
+	cache.memory.jitterRnd = func() float64 { return 0 }
+	cache.SetTTL("explicit", 1, 10*time.Minute)
+	if r := remaining("explicit"); r < 9*time.Minute-2*time.Second || r > 9*time.Minute+time.Second {
+		t.Errorf("explicit remaining = %v; want about 9m", r)
+	}
+	cache.SetTTL("forever", 1, 0)
+	if r := remaining("forever"); r != 0 {
+		t.Errorf("forever remaining = %v; no-expiry entries must not be jittered", r)
+	}
+
+	for _, f := range []float64{-0.5, 0, 1, 2} {
+		if c := New[string, int](TTLJitter(f)); c.memory.jitter != 0 {
+			t.Errorf("TTLJitter(%v) = %v; want ignored", f, c.memory.jitter)
+		}
+	}
+}
+
+func TestCache_MinMaxTTL(t *testing.T) {
+	cache := New[string, int](MinTTL(time.Minute), MaxTTL(time.Hour), TTL(time.Nanosecond))
+	if cache.defaultTTL != time.Minute {
+		t.Errorf("defaultTTL = %v; want clamped to 1m", cache.defaultTTL)
+	}
+
+	remaining := func(key string) time.Duration {
+		t.Helper()
+		ent, ok := cache.memory.getEntry(key)
+		if !ok {
+			t.Fatalf("%s missing", key)
+		}
+		if ent.expirySec.Load() == 0 {
+			return 0
+		}
+		return time.Until(time.Unix(int64(ent.expirySec.Load()), 0))
+	}
+
+	cache.Set("default", 1)
+	cache.SetTTL("tiny", 2, time.Nanosecond)
+	cache.SetTTL("huge", 3, 365*24*time.Hour)
+	cache.SetTTL("forever", 4, 0)
+	if _, err := cache.FetchTTL("fetched", time.Millisecond, func() (int, error) { return 5, nil }); err != nil {
+		t.Fatalf("FetchTTL: %v", err)
+	}
+
+	for _, k := range []string{"default", "tiny", "fetched"} {
+		if r := remaining(k); r < 58*time.Second || r > time.Minute+time.Second {
+			t.Errorf("%s remaining = %v; want about 1m (MinTTL)", k, r)
+		}
+	}
+	if r := remaining("huge"); r < 59*time.Minute || r > time.Hour+time.Second {
+		t.Errorf("huge remaining = %v; want about 1h (MaxTTL)", r)
+	}
+	if r := remaining("forever"); r != 0 {
+		t.Errorf("forever remaining = %v; no-expiry entries must not be clamped", r)
+	}
+}
+
+func TestCache_WriteNoBump(t *testing.T) {
+	// A key rewritten many times but never read, followed by a stream of read-once keys.
+	run := func(opts ...Option) bool {
+		cache := New[int, int](append([]Option{Size(100)}, opts...)...)
+		for i := range 50 {
+			cache.Set(-1, i)
+		}
+		for i := range 500 {
+			cache.Set(i, i)
+			cache.Get(i)
+		}
+		return cache.Contains(-1)
+	}
+
+	if !run() {
+		t.Error("without WriteNoBump, rewrites should keep the key resident")
+	}
+	if run(WriteNoBump()) {
+		t.Error("with WriteNoBump, an unread key should be evicted under pressure")
+	}
+}
+
+func TestCache_Swap(t *testing.T) {
+	cache := New[string, int]()
+
+	if old, had := cache.Swap("a", 1); had || old != 0 {
+		t.Errorf("Swap on absent key = %d, %v; want 0, false", old, had)
+	}
+	if old, had := cache.Swap("a", 2); !had || old != 1 {
+		t.Errorf("Swap = %d, %v; want 1, true", old, had)
+	}
+	if v, _ := cache.Get("a"); v != 2 {
+		t.Errorf("Get after Swap = %d; want 2", v)
+	}
+
+	cache.SetTTL("expired", 1, time.Second)
+	ent, _ := cache.memory.getEntry("expired")
+	ent.expirySec.Store(1)
+	if _, had := cache.SwapTTL("expired", 2, time.Hour); had {
+		t.Error("SwapTTL should not report an expired value")
+	}
+}
+
+func TestCache_Swap_Concurrent(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("k", 0)
+
+	// Each Swap stores a unique value, so every previous value is seen exactly once.
+	const n = 1000
+	seen := make([]atomic.Int32, n+1)
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Go(func() {
+			old, had := cache.Swap("k", i)
+			if had {
+				seen[old].Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	last, _ := cache.Get("k")
+	for i := range seen {
+		want := int32(1)
+		if i == last {
+			want = 0
+		}
+		if got := seen[i].Load(); got != want {
+			t.Fatalf("value %d returned %d times; want %d", i, got, want)
+		}
+	}
+}
+
+func TestCache_ForEach(t *testing.T) {
+	cache := New[int, int]()
+	for i := range 10 {
+		cache.Set(i, i*10)
+	}
+	cache.SetTTL(100, 1, time.Second)
+	ent, _ := cache.memory.getEntry(100)
+	ent.expirySec.Store(1)
+
+	got := make(map[int]int)
+	cache.ForEach(func(k, v int) bool {
+		got[k] = v
+		return true
+	})
+	if len(got) != 10 {
+		t.Fatalf("ForEach visited %d entries; want 10 (expired skipped)", len(got))
+	}
+	for k, v := range got {
+		if v != k*10 {
+			t.Errorf("ForEach(%d) = %d; want %d", k, v, k*10)
+		}
+	}
+
+	n := 0
+	cache.ForEach(func(int, int) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("ForEach visited %d entries after early stop; want 3", n)
+	}
+}
+
+func TestCache_ForEach_SkipsDeathRow(t *testing.T) {
+	cache := New[int, int](Size(100))
+	for i := range 10 {
+		cache.Set(i, i)
+	}
+	cache.memory.mu.Lock()
+	ent, _ := cache.memory.getEntry(0)
+	cache.memory.small.remove(ent)
+	ent.setFreqPeak(0, maxPeakFreq)
+	cache.memory.sendToDeathRow(ent)
+	cache.memory.mu.Unlock()
+	if !ent.onDeathRow() {
+		t.Fatal("key 0 should be on death row")
+	}
+
+	for k := range cache.Range() {
+		if k == 0 {
+			t.Error("Range yielded a death row entry")
+		}
+	}
+	if n := cache.CountFunc(func(int, int) bool { return true }); n != cache.Len() {
+		t.Errorf("CountFunc(all) = %d; want Len() = %d", n, cache.Len())
+	}
+}
+
+func TestCache_GetWithExpiry(t *testing.T) {
+	for _, opts := range [][]Option{nil, {MonotonicExpiry()}} {
+		cache := New[string, int](opts...)
+		cache.Set("forever", 1)
+		cache.SetTTL("hour", 2, time.Hour)
+		cache.SetTTL("gone", 3, time.Hour)
+		ent, _ := cache.memory.getEntry("gone")
+		ent.expirySec.Store(1)
+
+		if v, exp, ok := cache.GetWithExpiry("forever"); !ok || v != 1 || !exp.IsZero() {
+			t.Errorf("GetWithExpiry(forever) = %d, %v, %v; want 1, zero time, true", v, exp, ok)
+		}
+		v, exp, ok := cache.GetWithExpiry("hour")
+		if !ok || v != 2 {
+			t.Errorf("GetWithExpiry(hour) = %d, %v; want 2, true", v, ok)
+		}
+		if left := time.Until(exp); left < 59*time.Minute || left > time.Hour+time.Second {
+			t.Errorf("GetWithExpiry(hour) expiry in %v; want about 1h", left)
+		}
+		if _, _, ok := cache.GetWithExpiry("gone"); ok {
+			t.Error("GetWithExpiry returned an expired entry")
+		}
+		if _, _, ok := cache.GetWithExpiry("missing"); ok {
+			t.Error("GetWithExpiry found a missing key")
+		}
+	}
+}
+
+func TestCache_Touch(t *testing.T) {
+	cache := New[string, int](Size(100), TTL(time.Minute))
+	cache.SetTTL("k", 1, time.Second)
+
+	if !cache.Touch("k", time.Hour) {
+		t.Fatal("Touch(k) = false; want true")
+	}
+	if v, exp, ok := cache.GetWithExpiry("k"); !ok || v != 1 || time.Until(exp) < 59*time.Minute {
+		t.Errorf("after Touch: %d, expiry in %v, %v; want 1, about 1h, true", v, time.Until(exp), ok)
+	}
+
+	// ttl <= 0 falls back to the default TTL.
+	cache.Touch("k", 0)
+	if _, exp, _ := cache.GetWithExpiry("k"); time.Until(exp) > time.Minute+time.Second {
+		t.Errorf("Touch(k, 0) expiry in %v; want default TTL of 1m", time.Until(exp))
+	}
+
+	if cache.Touch("missing", time.Hour) {
+		t.Error("Touch(missing) = true; want false")
+	}
+	cache.Set("old", 2)
+	ent, _ := cache.memory.getEntry("old")
+	ent.expirySec.Store(1)
+	if cache.Touch("old", time.Hour) {
+		t.Error("Touch revived an expired entry")
+	}
+
+	// A cached miss keeps its miss TTL; Touch must not make it outlive that.
+	missExp := uint32(time.Now().Add(time.Minute).Unix()) //nolint:gosec // G115: test value
+	cache.memory.setMiss("negative", missExp)
+	if cache.Touch("negative", time.Hour) {
+		t.Error("Touch(negative) = true for a cached miss; want false")
+	}
+	if ent, _ := cache.memory.getEntry("negative"); ent.expirySec.Load() != missExp {
+		t.Error("Touch extended a cached miss")
+	}
+}
+
+func TestCache_Peek(t *testing.T) {
+	cache := New[int, int](Size(100))
+	for i := range 10 {
+		cache.Set(i, i*10)
+	}
+	ent, _ := cache.memory.getEntry(1)
+	freq, peak := ent.freq(), ent.peakFreq()
+	for range 3 {
+		if v, ok := cache.Peek(1); !ok || v != 10 {
+			t.Fatalf("Peek(1) = %d, %v; want 10, true", v, ok)
+		}
+	}
+	if ent.freq() != freq || ent.peakFreq() != peak {
+		t.Errorf("Peek changed freq %d->%d, peak %d->%d", freq, ent.freq(), peak, ent.peakFreq())
+	}
+	if _, ok := cache.ReadOnly().Peek(99); ok {
+		t.Error("Peek(99) found a missing key")
+	}
+
+	cache.memory.mu.Lock()
+	dr, _ := cache.memory.getEntry(0)
+	cache.memory.small.remove(dr)
+	dr.setFreqPeak(0, maxPeakFreq)
+	cache.memory.sendToDeathRow(dr)
+	cache.memory.mu.Unlock()
+	if v, ok := cache.Peek(0); !ok || v != 0 {
+		t.Errorf("Peek(death row) = %d, %v; want 0, true", v, ok)
+	}
+	if !dr.onDeathRow() {
+		t.Error("Peek resurrected a death row entry")
+	}
+}
+
+func TestCache_CountFunc(t *testing.T) {
+	cache := New[int, int]()
+	for i := range 10 {
+		cache.Set(i, i)
+	}
+	cache.SetTTL(100, 100, time.Second)
+	ent, _ := cache.memory.getEntry(100)
+	ent.expirySec.Store(1)
+
+	if n := cache.CountFunc(func(_, v int) bool { return v%2 == 0 }); n != 5 {
+		t.Errorf("CountFunc(even) = %d; want 5", n)
+	}
+	if n := cache.CountFunc(func(int, int) bool { return true }); n != 10 {
+		t.Errorf("CountFunc(all) = %d; want 10 (expired skipped)", n)
+	}
+}
+
+func TestCache_Cleanup(t *testing.T) {
+	cache := New[string, int](Size(100))
+	for i := range 10 {
+		cache.Set(fmt.Sprintf("forever%d", i), i)
+		cache.SetTTL(fmt.Sprintf("live%d", i), i, time.Hour)
+		cache.SetTTL(fmt.Sprintf("expired%d", i), i, time.Hour)
+		ent, _ := cache.memory.getEntry(fmt.Sprintf("expired%d", i))
+		ent.expirySec.Store(1)
+	}
+
+	if n := cache.Cleanup(); n != 10 {
+		t.Errorf("Cleanup() = %d; want 10", n)
+	}
+	if cache.Len() != 20 {
+		t.Errorf("Len() = %d; want 20", cache.Len())
+	}
+	st := cache.Stats()
+	if st.SmallLen+st.MainLen != 20 {
+		t.Errorf("queues hold %d entries; want 20", st.SmallLen+st.MainLen)
+	}
+	if n := cache.Cleanup(); n != 0 {
+		t.Errorf("second Cleanup() = %d; want 0", n)
+	}
+}
+
+func TestCache_GhostMemoryBudget(t *testing.T) {
+	unbounded := New[int, int](Size(16384)).Stats().GhostBytes
+	if unbounded == 0 {
+		t.Fatal("GhostBytes = 0; want the default filter size")
+	}
+
+	cache := New[int, int](Size(16384), GhostMemoryBudget(10000))
+	got := cache.Stats().GhostBytes
+	if got > 10000 || got == 0 {
+		t.Errorf("GhostBytes = %d; want within budget 10000", got)
+	}
+	if got >= unbounded {
+		t.Errorf("GhostBytes = %d; want below unbounded %d", got, unbounded)
+	}
+
+	// The cache still works with shrunken filters.
+	for i := range 50000 {
+		cache.Set(i, i)
+	}
+	if cache.Len() > 16384 {
+		t.Errorf("Len() = %d; want <= 16384", cache.Len())
+	}
+
+	// Budgets too small to split still cap the filters, at their 16-byte minimum.
+	for _, n := range []int64{1, 2, 16} {
+		if got := New[int, int](Size(16384), GhostMemoryBudget(n)).Stats().GhostBytes; got != 16 {
+			t.Errorf("GhostMemoryBudget(%d): GhostBytes = %d; want 16", n, got)
+		}
+	}
+}
+
+func TestCache_KeyValidator(t *testing.T) {
+	errBadKey := errors.New("key must start with user:")
+	cache := New[string, int](KeyValidator(func(k string) error {
+		if !strings.HasPrefix(k, "user:") {
+			return errBadKey
+		}
+		return nil
+	}))
+
+	cache.Set("user:1", 1)
+	cache.Set("order:1", 2)
+	if ok := cache.TrySet("order:2", 3); ok {
+		t.Error("TrySet should report a rejected key")
+	}
+	if _, err := cache.Fetch("order:3", func() (int, error) { return 4, nil }); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	if _, ok := cache.Get("user:1"); !ok {
+		t.Error("valid key should be stored")
+	}
+	for _, k := range []string{"order:1", "order:2", "order:3"} {
+		if _, ok := cache.Get(k); ok {
+			t.Errorf("Get(%q) found; invalid keys must be rejected", k)
+		}
+	}
+	if got := cache.Stats().RejectedKeys; got != 3 {
+		t.Errorf("Stats().RejectedKeys = %d; want 3", got)
+	}
+}
+
+func TestCache_KeyValidator_TypeMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New should panic when the validator's key type differs")
+		}
+	}()
+	New[string, int](KeyValidator(func(int) error { return nil }))
+}
+
+func TestCache_Hasher(t *testing.T) {
+	var calls atomic.Int64
+	cache := New[string, int](Size(10), Hasher(func(k string) uint64 {
+		calls.Add(1)
+		return uint64(len(k)) + 1
+	}))
+
+	for i := range 20 {
+		cache.Set(fmt.Sprint(i), i)
+	}
+	if calls.Load() == 0 {
+		t.Error("custom hasher was never called")
+	}
+	if got, want := cache.memory.hasher("abc"), uint64(4); got != want {
+		t.Errorf("hasher(abc) = %d; want %d", got, want)
+	}
+	if cache.memory.strHash {
+		t.Error("string fast path should be disabled with a custom hasher")
+	}
+	if v, ok := cache.Get("19"); !ok || v != 19 {
+		t.Errorf("Get(19) = %d, %v; want 19, true", v, ok)
+	}
+}
+
+func TestCache_Hasher_TypeMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New should panic when the hasher's key type differs")
+		}
+	}()
+	New[string, int](Hasher(func(int) uint64 { return 0 }))
+}
+
+func TestCache_SetIfAbsent(t *testing.T) {
+	cache := New[string, int]()
+	if !cache.SetIfAbsent("k", 1) {
+		t.Fatal("SetIfAbsent(new key) = false; want true")
+	}
+	if cache.SetIfAbsent("k", 2) {
+		t.Error("SetIfAbsent(existing key) = true; want false")
+	}
+	if v, _ := cache.Get("k"); v != 1 {
+		t.Errorf("Get(k) = %d; want 1 (first value kept)", v)
+	}
+
+	// Expired entries don't block the insert.
+	cache.SetTTL("old", 1, time.Hour)
+	ent, _ := cache.memory.getEntry("old")
+	ent.expirySec.Store(1)
+	if !cache.SetIfAbsentTTL("old", 2, time.Hour) {
+		t.Error("SetIfAbsentTTL over an expired entry = false; want true")
+	}
+
+	// Evicted entries on death row are absent, as Contains reports, so the insert wins
+	// and Get returns the new value rather than resurrecting the old one.
+	cache.Set("evicted", 1)
+	ent, _ = cache.memory.getEntry("evicted")
+	cache.memory.mu.Lock()
+	cache.memory.small.remove(ent)
+	ent.setFreqPeak(0, maxPeakFreq)
+	cache.memory.sendToDeathRow(ent)
+	cache.memory.mu.Unlock()
+	if cache.Contains("evicted") {
+		t.Fatal("Contains(evicted) = true for a death row entry")
+	}
+	if !cache.SetIfAbsent("evicted", 2) {
+		t.Error("SetIfAbsent over a death row entry = false; want true")
+	}
+	if v, ok := cache.Get("evicted"); !ok || v != 2 {
+		t.Errorf("Get(evicted) = %d, %v; want 2, true", v, ok)
+	}
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			if cache.SetIfAbsent("race", i) {
+				wins.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if n := wins.Load(); n != 1 {
+		t.Errorf("%d concurrent SetIfAbsent calls won; want exactly 1", n)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	cache := New[string, int64](TTL(time.Minute))
+	if got := Add(cache, "n", 5); got != 5 {
+		t.Errorf("Add(new) = %d; want 5", got)
+	}
+	cache.SetTTL("n", 5, time.Hour)
+	if got := Add(cache, "n", -2); got != 3 {
+		t.Errorf("Add(-2) = %d; want 3", got)
+	}
+	if _, exp, _ := cache.GetWithExpiry("n"); time.Until(exp) < 59*time.Minute {
+		t.Errorf("Add changed expiry to %v from now; want the existing 1h kept", time.Until(exp))
+	}
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Go(func() { Add(cache, "hits", 1) })
+	}
+	wg.Wait()
+	if v, _ := cache.Get("hits"); v != 100 {
+		t.Errorf("after 100 concurrent Adds: %d; want 100", v)
+	}
+	if _, exp, _ := cache.GetWithExpiry("hits"); exp.IsZero() || time.Until(exp) > time.Minute {
+		t.Errorf("new counter expires in %v; want the default TTL of 1m", time.Until(exp))
+	}
+
+	floats := New[string, float64]()
+	Add(floats, "f", 0.5)
+	if got := Add(floats, "f", 0.25); got != 0.75 {
+		t.Errorf("Add(float) = %v; want 0.75", got)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	cache := New[string, string]()
+	if CompareAndSwap(cache, "k", "", "x") {
+		t.Error("CompareAndSwap on a missing key succeeded")
+	}
+	if _, ok := cache.Get("k"); ok {
+		t.Error("failed CompareAndSwap created the key")
+	}
+
+	cache.SetTTL("k", "v1", time.Hour)
+	if CompareAndSwap(cache, "k", "stale", "v2") {
+		t.Error("CompareAndSwap with a stale old value succeeded")
+	}
+	if !CompareAndSwap(cache, "k", "v1", "v2") {
+		t.Fatal("CompareAndSwap with the current value failed")
+	}
+	if v, exp, _ := cache.GetWithExpiry("k"); v != "v2" || time.Until(exp) < 59*time.Minute {
+		t.Errorf("after CompareAndSwap: %q, expiry in %v; want v2 with the 1h expiry kept", v, time.Until(exp))
+	}
+
+	// Only one of many concurrent swaps from the same old value can win.
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			if CompareAndSwap(cache, "k", "v2", fmt.Sprint(i)) {
+				wins.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if n := wins.Load(); n != 1 {
+		t.Errorf("%d concurrent CompareAndSwap calls won; want exactly 1", n)
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	cache := New[string, int](Size(100))
+	for i := range 5 {
+		cache.Set(fmt.Sprintf("tenant:1:user:%d", i), i)
+		cache.Set(fmt.Sprintf("tenant:2:user:%d", i), i)
+	}
+	if n := DeletePrefix(cache, "tenant:1:"); n != 5 {
+		t.Errorf("DeletePrefix = %d; want 5", n)
+	}
+	if n := cache.Len(); n != 5 {
+		t.Errorf("Len = %d; want 5", n)
+	}
+	for k := range cache.Range() {
+		if strings.HasPrefix(k, "tenant:1:") {
+			t.Errorf("key %q survived DeletePrefix", k)
+		}
+	}
+	if got := cache.Stats().ExplicitDeletes; got != 5 {
+		t.Errorf("ExplicitDeletes = %d; want 5", got)
+	}
+}
+
+func TestCache_GetOrSet(t *testing.T) {
+	cache := New[string, int]()
+
+	if v, loaded := cache.GetOrSet("a", 1); loaded || v != 1 {
+		t.Errorf("GetOrSet on absent key = %d, %v; want 1, false", v, loaded)
+	}
+	if v, loaded := cache.GetOrSet("a", 2); !loaded || v != 1 {
+		t.Errorf("GetOrSet on present key = %d, %v; want 1, true", v, loaded)
+	}
+
+	cache.SetTTL("expired", 1, time.Second)
+	ent, _ := cache.memory.getEntry("expired")
+	ent.expirySec.Store(1)
+	if v, loaded := cache.GetOrSetTTL("expired", 2, time.Hour); loaded || v != 2 {
+		t.Errorf("GetOrSetTTL on expired key = %d, %v; want 2, false", v, loaded)
+	}
+	if v, _ := cache.Get("expired"); v != 2 {
+		t.Errorf("Get(expired) = %d; want replaced value 2", v)
+	}
+}
+
+func TestCache_GetOrSet_Concurrent(t *testing.T) {
+	cache := New[string, int]()
+
+	const n = 100
+	results := make([]int, n)
+	var stored atomic.Int32
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			v, loaded := cache.GetOrSet("k", i)
+			if !loaded {
+				stored.Add(1)
+			}
+			results[i] = v
+		})
+	}
+	wg.Wait()
+
+	if stored.Load() != 1 {
+		t.Errorf("%d callers stored; want exactly 1", stored.Load())
+	}
+	for i, v := range results {
+		if v != results[0] {
+			t.Fatalf("caller %d saw %d, caller 0 saw %d; all must see the winner", i, v, results[0])
+		}
+	}
+}
+
+func TestCache_Stats_GhostSaturation(t *testing.T) {
+	cache := New[int, int](Size(1000))
+	if st := cache.Stats(); st.GhostFill != 0 || st.GhostFPRate != 0 {
+		t.Errorf("empty cache GhostFill = %v, GhostFPRate = %v; want 0, 0", st.GhostFill, st.GhostFPRate)
+	}
+
+	for i := range 3000 {
+		cache.Set(i, i)
+	}
+	st := cache.Stats()
+	if st.GhostFill <= 0 || st.GhostFill > 1 {
+		t.Errorf("GhostFill = %v; want in (0, 1]", st.GhostFill)
+	}
+	if st.GhostFPRate <= 0 || st.GhostFPRate > 0.01 {
+		t.Errorf("GhostFPRate = %v; want small but nonzero at default sizing", st.GhostFPRate)
+	}
+
+	// Starving the filters of memory pushes the estimate up.
+	tight := New[int, int](Size(1000), GhostMemoryBudget(64))
+	for i := range 3000 {
+		tight.Set(i, i)
+	}
+	if got := tight.Stats().GhostFPRate; got <= st.GhostFPRate {
+		t.Errorf("GhostFPRate with 64-byte budget = %v; want above default %v", got, st.GhostFPRate)
+	}
+}
+
+func TestCache_Stats_FetchMany_LoaderCalls(t *testing.T) {
+	cache := New[int, int]()
+	loader := func(keys []int) (map[int]int, error) {
+		out := make(map[int]int, len(keys))
+		for _, k := range keys {
+			out[k] = k
+		}
+		return out, nil
+	}
+
+	if _, err := cache.FetchMany([]int{1, 2, 3}, loader); err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	if _, err := cache.Fetch(4, func() (int, error) { return 4, nil }); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	// Hits never call the loader.
+	if _, err := cache.FetchMany([]int{1, 2, 3, 4}, loader); err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+
+	st := cache.Stats()
+	if st.LoaderCalls != 2 || st.CoalescedCalls != 0 {
+		t.Errorf("LoaderCalls, CoalescedCalls = %d, %d; want 2, 0", st.LoaderCalls, st.CoalescedCalls)
+	}
+}
+
+func TestCache_MaxCost(t *testing.T) {
+	size := func(v []byte) int64 { return int64(len(v)) }
+	for _, policy := range []Policy{PolicyS3FIFO, PolicyLRU} {
+		cache := New[int, []byte](Size(100), Cost(size), MaxCost(1000), EvictionPolicy(policy))
+		for i := range 20 {
+			cache.Set(i, make([]byte, 100))
+		}
+		st := cache.Stats()
+		if st.Cost > 1000 || st.Len > 10 {
+			t.Errorf("policy %d: Cost, Len = %d, %d; want <= 1000, <= 10", policy, st.Cost, st.Len)
+		}
+		if st.Cost != int64(st.Len)*100 {
+			t.Errorf("policy %d: Cost = %d; want %d for %d entries", policy, st.Cost, st.Len*100, st.Len)
+		}
+
+		// Growing a value is charged and can evict others.
+		cache.Set(19, make([]byte, 500))
+		if st := cache.Stats(); st.Cost > 1000 {
+			t.Errorf("policy %d: Cost after growing update = %d; want <= 1000", policy, st.Cost)
+		}
+
+		// A value over budget on its own is refused.
+		if cache.TrySet(-1, make([]byte, 1001)) {
+			t.Errorf("policy %d: TrySet of an oversized value succeeded", policy)
+		}
+		if got := cache.Stats().RejectedFull; got != 1 {
+			t.Errorf("policy %d: RejectedFull = %d; want 1", policy, got)
+		}
+
+		cache.Flush()
+		if got := cache.Stats().Cost; got != 0 {
+			t.Errorf("policy %d: Cost after Flush = %d; want 0", policy, got)
+		}
+	}
+}
+
+func TestCache_MaxCost_Delete(t *testing.T) {
+	cache := New[string, string](Cost(func(v string) int64 { return int64(len(v)) }), MaxCost(100))
+	cache.Set("a", "0123456789")
+	cache.Set("b", "01234")
+	cache.Set("a", "012")
+	if got := cache.Stats().Cost; got != 8 {
+		t.Errorf("Cost = %d; want 8", got)
+	}
+	cache.Delete("b")
+	if got := cache.Stats().Cost; got != 3 {
+		t.Errorf("Cost after Delete = %d; want 3", got)
+	}
+}
+
+func TestCache_Cost_TypeMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New should panic when the cost function's value type differs")
+		}
+	}()
+	New[string, int](Cost(func(string) int64 { return 1 }), MaxCost(10))
+}
+
+func TestCache_SmallQueueRatio(t *testing.T) {
+	if got := New[int, int](Size(10000), SmallQueueRatio(50)).memory.smallThresh; got != 500 {
+		t.Errorf("smallThresh = %d; want 500 (5%% of 10000)", got)
+	}
+	def := New[int, int](Size(10000)).memory.smallThresh
+	for _, bad := range []int{0, -1, 1000, 5000} {
+		if got := New[int, int](Size(10000), SmallQueueRatio(bad)).memory.smallThresh; got != def {
+			t.Errorf("SmallQueueRatio(%d): smallThresh = %d; want default %d", bad, got, def)
+		}
+	}
+}
+
+func TestCache_MaxFreq(t *testing.T) {
+	cache := New[string, int](MaxFreq(12))
+	cache.Set("a", 1)
+	for range 20 {
+		cache.Get("a")
+	}
+	ent, _ := cache.memory.getEntry("a")
+	if f := ent.freq(); f != 12 {
+		t.Errorf("freq after 20 hits = %d; want saturated at 12", f)
+	}
+
+	for _, bad := range []int{0, 1, 16, 255} {
+		if got := New[int, int](MaxFreq(bad)).memory.maxFreq; got != defaultMaxFreq {
+			t.Errorf("MaxFreq(%d): maxFreq = %d; want default %d", bad, got, defaultMaxFreq)
+		}
+	}
+}
+
+func TestCache_SetMiss(t *testing.T) {
+	cache := New[string, int](HitStats())
+	cache.Set("a", 1)
+	cache.SetMiss("gone", time.Hour)
+
+	if v, found, known := cache.GetOrMiss("gone"); found || !known || v != 0 {
+		t.Errorf("GetOrMiss(gone) = %d, %v, %v; want 0, false, true", v, found, known)
+	}
+	if v, found, known := cache.GetOrMiss("a"); !found || !known || v != 1 {
+		t.Errorf("GetOrMiss(a) = %d, %v, %v; want 1, true, true", v, found, known)
+	}
+	if _, found, known := cache.GetOrMiss("never"); found || known {
+		t.Errorf("GetOrMiss(never) = _, %v, %v; want false, false", found, known)
+	}
+
+	if _, ok := cache.Get("gone"); ok {
+		t.Error("Get should report a cached miss as absent")
+	}
+	if _, ok := cache.Peek("gone"); ok {
+		t.Error("Peek should report a cached miss as absent")
+	}
+	if cache.Contains("gone") {
+		t.Error("Contains should report a cached miss as absent")
+	}
+	for k := range cache.Range() {
+		if k == "gone" {
+			t.Error("Range should skip cached misses")
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d; want 2 (cached misses occupy a slot)", cache.Len())
+	}
+	if s := cache.Stats(); s.Hits != 1 || s.Misses != 3 {
+		t.Errorf("Stats() hits = %d, misses = %d; want 1, 3", s.Hits, s.Misses)
+	}
+
+	// A cached miss doesn't block inserts.
+	if !cache.SetIfAbsent("gone", 2) {
+		t.Error("SetIfAbsent should replace a cached miss")
+	}
+	if v, found, known := cache.GetOrMiss("gone"); !found || !known || v != 2 {
+		t.Errorf("GetOrMiss(gone) after SetIfAbsent = %d, %v, %v; want 2, true, true", v, found, known)
+	}
+
+	// Set replaces a miss on the lock-free update path, and SetMiss replaces a value.
+	cache.SetMiss("gone", time.Hour)
+	cache.Set("gone", 3)
+	if v, ok := cache.Get("gone"); !ok || v != 3 {
+		t.Errorf("Get(gone) after Set = %d, %v; want 3, true", v, ok)
+	}
+	cache.SetMiss("a", time.Hour)
+	if _, found, known := cache.GetOrMiss("a"); found || !known {
+		t.Errorf("GetOrMiss(a) after SetMiss = _, %v, %v; want false, true", found, known)
+	}
+
+	// Expired misses are unknown.
+	//nolint:gosec // G115: test value
+	cache.memory.setMiss("stale", uint32(time.Now().Add(-time.Minute).Unix()))
+	if _, found, known := cache.GetOrMiss("stale"); found || known {
+		t.Errorf("GetOrMiss(stale) = _, %v, %v; want false, false", found, known)
+	}
+}
@@ -1,24 +1,72 @@
 package fido
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"hash/maphash"
 	"iter"
 	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v4"
 )
 
-const asyncTimeout = 5 * time.Second
+// defaultAsyncTimeout bounds background store operations unless AsyncTimeout is set.
+// It is the timeout SetAsync and WriteBack always used; raising it would let a stalled
+// store hold Close, which waits for pending writes, that much longer.
+const defaultAsyncTimeout = 5 * time.Second
+
+// defaultAsyncQueue is how many SetAsync writes may wait for a worker unless
+// AsyncWorkers sets it.
+const defaultAsyncQueue = 4096
+
+// asyncEnqueueWait is how long SetAsync waits for room in a full AsyncWorkers queue
+// before dropping the store write. It is short because SetAsync callers expect not to
+// block on the store.
+const asyncEnqueueWait = 100 * time.Millisecond
+
+// getManyWorkers bounds concurrent store reads in GetMany.
+const getManyWorkers = 16
+
+// strictStripes is how many locks StrictWriteThrough writes are spread over by key.
+const strictStripes = 64
+
+// timedReadSlots bounds concurrent detached store reads under StoreReadTimeout.
+const timedReadSlots = 64
 
 // TieredCache combines an in-memory cache with persistent storage.
 type TieredCache[K comparable, V any] struct {
-	Store      Store[K, V] // direct access to persistence layer
-	flights    *xsync.Map[K, *flightCall[V]]
-	memory     *s3fifo[K, V]
-	defaultTTL time.Duration
+	Store        Store[K, V] // direct access to persistence layer
+	flights      *xsync.Map[K, *flightCall[V]]
+	reads        *xsync.Map[K, *timedRead[V]] // StoreReadTimeout reads in flight
+	readSlots    chan struct{}                // bounds reads; see timedReadSlots
+	memory       *s3fifo[K, V]
+	sampler      *latencySampler
+	loader       loadFunc[K, V] // warmup source for the WarmupStrategy; nil if unsupported
+	loadWorkers  int
+	writeBack    *writeBack[K, V]    // WriteBack; nil writes through
+	async        *asyncWriters[K, V] // SetAsync worker pool; nil with WriteBack
+	defaultTTL   time.Duration
+	warmupDone   chan struct{}
+	stopCleanup  context.CancelFunc
+	cleanupDone  chan struct{}
+	strictDecode bool
+	strictWrites bool
+	strictMu     []sync.Mutex // StrictWriteThrough; per-key write serialization
+	seed         maphash.Seed
+	readTimeout  time.Duration // StoreReadTimeout; 0 means none
+	asyncTimeout time.Duration // AsyncTimeout
+	minPersist   time.Duration // PersistMinTTL; 0 means persist everything
+	decodeErrors atomic.Int64
+	storeHits    atomic.Int64
+	storeMisses  atomic.Int64
+	pending      atomic.Int64 // SetAsync writes not yet finished
+	asyncDropped atomic.Int64 // SetAsync store writes never queued
 }
 
 // NewTiered creates a cache backed by the given store.
@@ -31,21 +79,203 @@ func NewTiered[K comparable, V any](store Store[K, V], opts ...Option) (*TieredC
 	if store == nil {
 		return nil, errors.New("store cannot be nil")
 	}
+	if err := checkOptionTypes[K, V](cfg); err != nil {
+		return nil, err
+	}
 
+	// Size(0) is persistence-only: keep a minimal memory tier that never stores entries.
+	passthrough := cfg.size == 0
+	if passthrough {
+		cfg.size = 1
+	}
+	memory := newS3FIFO[K, V](cfg)
+	memory.disabled = passthrough
 	cache := &TieredCache[K, V]{
-		Store:      store,
-		flights:    xsync.NewMap[K, *flightCall[V]](),
-		memory:     newS3FIFO[K, V](cfg),
-		defaultTTL: cfg.defaultTTL,
+		Store:        store,
+		flights:      xsync.NewMap[K, *flightCall[V]](),
+		reads:        xsync.NewMap[K, *timedRead[V]](),
+		readSlots:    make(chan struct{}, timedReadSlots),
+		memory:       memory,
+		sampler:      newLatencySampler(cfg.sampleRate, cfg.sampleSink),
+		defaultTTL:   memory.clampTTL(cfg.defaultTTL),
+		warmupDone:   make(chan struct{}),
+		strictDecode: cfg.strictDecode,
+		strictWrites: cfg.strictWrites,
+		seed:         maphash.MakeSeed(),
+		readTimeout:  cfg.readTimeout,
+		asyncTimeout: cmp.Or(cfg.asyncTimeout, defaultAsyncTimeout),
+		minPersist:   cfg.persistMinTTL,
+		loadWorkers:  cfg.warmupWorkers,
+	}
+	if cfg.strictWrites {
+		cache.strictMu = make([]sync.Mutex, strictStripes)
+	}
+	if cfg.writeBackEvery > 0 {
+		cache.writeBack = newWriteBack(store, cfg.writeBackEvery, cfg.writeBackBatch, cache.asyncTimeout)
+	} else {
+		workers := cfg.asyncWorkers
+		if workers <= 0 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		cache.async = newAsyncWriters(workers, cmp.Or(cfg.asyncQueue, defaultAsyncQueue),
+			asyncEnqueueWait, cfg.asyncDropFull, cache.writeAsync)
+	}
+	if cfg.cleanupEvery > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		cache.stopCleanup = cancel
+		cache.cleanupDone = make(chan struct{})
+		go cache.runCleanup(ctx, cfg.cleanupEvery)
+	}
+
+	switch cfg.warmupOrder {
+	case WarmupByRecency:
+		if rl, ok := store.(RecentLoader[K, V]); ok {
+			cache.loader = rl.LoadRecent
+		}
+	case WarmupByFrequency:
+		if fl, ok := store.(FrequencyLoader[K, V]); ok {
+			cache.loader = fl.LoadByFrequency
+		}
+	}
+	if cfg.warmup > 0 && cache.loader != nil {
+		go func() {
+			ctx := context.Background()
+			if cfg.warmupTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, cfg.warmupTimeout)
+				defer cancel()
+			}
+			cache.warmup(ctx, min(cfg.warmup, cache.memory.capacity))
+		}()
+	} else {
+		close(cache.warmupDone)
 	}
 
 	return cache, nil
 }
 
+// loadFunc is the signature shared by RecentLoader.LoadRecent and FrequencyLoader.LoadByFrequency.
+type loadFunc[K comparable, V any] func(ctx context.Context, limit int, fn func(key K, value V, expiry time.Time) bool) error
+
+// warmup runs the background warmup configured by the Warmup option, then closes warmupDone.
+func (c *TieredCache[K, V]) warmup(ctx context.Context, limit int) {
+	defer close(c.warmupDone)
+
+	n, err := c.fill(ctx, limit)
+	if err != nil {
+		slog.Warn("cache warmup failed", "loaded", n, "error", err)
+		return
+	}
+	slog.Debug("cache warmup complete", "loaded", n)
+}
+
+// Warmup loads up to limit entries from the store into memory and waits for it to
+// finish, in the same order, and with the same WarmupConcurrency, as the Warmup option.
+// Expired entries and keys already in memory are skipped. A limit <= 0 or above Size
+// loads entries until memory is full. It returns the store loader's error, or an error
+// if the store doesn't implement RecentLoader (or FrequencyLoader with WarmupByFrequency).
+func (c *TieredCache[K, V]) Warmup(ctx context.Context, limit int) error {
+	if c.loader == nil {
+		return errors.New("store cannot load entries for warmup")
+	}
+	if limit <= 0 || limit > c.memory.capacity {
+		limit = c.memory.capacity
+	}
+	if _, err := c.fill(ctx, limit); err != nil {
+		return fmt.Errorf("warmup: %w", err)
+	}
+	return nil
+}
+
+// fill inserts up to limit entries from the loader into memory and returns how many
+// were added. Keys already in memory are left alone. With loadWorkers > 1, entries are
+// handed off to that many goroutines so the loader's I/O and decoding overlap with insertion.
+func (c *TieredCache[K, V]) fill(ctx context.Context, limit int) (int64, error) {
+	load, workers := c.loader, c.loadWorkers
+	var n atomic.Int64
+	now := time.Now()
+	insert := func(key K, value V, expiry time.Time) {
+		if !expiry.IsZero() && expiry.Before(now) {
+			return
+		}
+		if c.memory.setIfAbsent(key, value, c.memory.toSec(expiry)) {
+			n.Add(1)
+		}
+	}
+
+	var err error
+	if workers <= 1 {
+		err = load(ctx, limit, func(key K, value V, expiry time.Time) bool {
+			insert(key, value, expiry)
+			return true
+		})
+	} else {
+		type item struct {
+			expiry time.Time
+			key    K
+			value  V
+		}
+		items := make(chan item, workers*16)
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Go(func() {
+				for it := range items {
+					insert(it.key, it.value, it.expiry)
+				}
+			})
+		}
+		err = load(ctx, limit, func(key K, value V, expiry time.Time) bool {
+			items <- item{key: key, value: value, expiry: expiry}
+			return true
+		})
+		close(items)
+		wg.Wait()
+	}
+	return n.Load(), err
+}
+
+// Ping checks that the store's backend is reachable, for readiness probes.
+// Returns nil if the store does not implement HealthChecker.
+func (c *TieredCache[K, V]) Ping(ctx context.Context) error {
+	hc, ok := c.Store.(HealthChecker)
+	if !ok {
+		return nil
+	}
+	if err := hc.Ping(ctx); err != nil {
+		return fmt.Errorf("store ping: %w", err)
+	}
+	return nil
+}
+
+// WarmupDone returns a channel that is closed once background warmup has finished.
+// It is already closed when no warmup was configured or the store cannot load entries
+// in the configured WarmupStrategy order.
+func (c *TieredCache[K, V]) WarmupDone() <-chan struct{} {
+	return c.warmupDone
+}
+
+// validateWriteKey checks key against MaxKeyBytes, KeyValidator and the store's own rules.
+func (c *TieredCache[K, V]) validateWriteKey(key K) error {
+	if c.memory.keyTooLong(key) {
+		c.memory.rejectedKeys.Add(1)
+		return fmt.Errorf("key exceeds %d bytes", c.memory.maxKeyBytes)
+	}
+	if c.memory.validateKey != nil {
+		if err := c.memory.validateKey(key); err != nil {
+			c.memory.rejectedKeys.Add(1)
+			return fmt.Errorf("invalid key: %w", err)
+		}
+	}
+	return c.Store.ValidateKey(key)
+}
+
 // Get checks memory, then persistence. Found values are cached in memory.
 //
 //nolint:gocritic // unnamedResult: public API signature is intentionally clear
 func (c *TieredCache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	if c.sampler.sample() {
+		defer c.sampler.record("get", time.Now())
+	}
 	if val, ok := c.memory.get(key); ok {
 		return val, true, nil
 	}
@@ -55,7 +285,7 @@ func (c *TieredCache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
 		return zero, false, fmt.Errorf("invalid key: %w", err)
 	}
 
-	val, expiry, found, err := c.Store.Get(ctx, key)
+	val, expiry, found, err := c.readStore(ctx, key)
 	if err != nil {
 		return zero, false, fmt.Errorf("persistence load: %w", err)
 	}
@@ -63,10 +293,244 @@ func (c *TieredCache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
 		return zero, false, nil
 	}
 
-	c.memory.set(key, val, timeToSec(expiry))
+	c.memory.set(key, val, c.memory.toSec(expiry))
 	return val, true, nil
 }
 
+// GetFromStore reads key straight from the store, neither consulting nor filling
+// memory, and returns the persisted value and its expiry. It is meant for checking
+// memory against the store. Unlike Get, undecodable entries are reported as errors
+// and left in place.
+//
+//nolint:revive // function-result-limit: mirrors Store.Get
+func (c *TieredCache[K, V]) GetFromStore(ctx context.Context, key K) (V, time.Time, bool, error) {
+	var zero V
+	if err := c.Store.ValidateKey(key); err != nil {
+		return zero, time.Time{}, false, fmt.Errorf("invalid key: %w", err)
+	}
+	val, expiry, found, err := c.Store.Get(ctx, key)
+	if err != nil {
+		return zero, time.Time{}, false, fmt.Errorf("persistence load: %w", err)
+	}
+	return val, expiry, found, nil
+}
+
+// GetMany returns the values found for keys, checking memory first and then reading
+// the misses from the store concurrently, so a remote store costs about one round trip
+// rather than one per key. Found values are cached in memory; absent keys are omitted.
+// On error the returned map still holds every value that was found.
+func (c *TieredCache[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, error) {
+	out := make(map[K]V, len(keys))
+	var missing []K
+	for _, key := range keys {
+		if val, ok := c.memory.get(key); ok {
+			out[key] = val
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	type result struct {
+		err   error
+		key   K
+		val   V
+		found bool
+	}
+	results := make([]result, len(missing))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(getManyWorkers, len(missing)) {
+		wg.Go(func() {
+			for i := range next {
+				key := missing[i]
+				r := result{key: key}
+				if err := c.Store.ValidateKey(key); err != nil {
+					r.err = fmt.Errorf("invalid key: %w", err)
+				} else {
+					var expiry time.Time
+					r.val, expiry, r.found, r.err = c.readStore(ctx, key)
+					if r.err != nil {
+						r.err = fmt.Errorf("persistence load: %w", r.err)
+					} else if r.found {
+						c.memory.set(key, r.val, c.memory.toSec(expiry))
+					}
+				}
+				results[i] = r
+			}
+		})
+	}
+	var err error
+feed:
+	for i := range missing {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	for _, r := range results {
+		if r.found {
+			out[r.key] = r.val
+		} else if r.err != nil && err == nil {
+			err = r.err
+		}
+	}
+	return out, err
+}
+
+// readStore is storeGet bounded by StoreReadTimeout. When the store is slower than
+// the timeout, readStore reports a miss and lets the read finish in the background,
+// caching the result so a later Get can hit memory.
+//
+//nolint:revive // function-result-limit: mirrors Store.Get
+func (c *TieredCache[K, V]) readStore(ctx context.Context, key K) (V, time.Time, bool, error) {
+	val, expiry, found, err := c.readStoreTimeout(ctx, key)
+	c.countStore(found, err)
+	return val, expiry, found, err
+}
+
+// countStore records the outcome of a store read after a memory miss.
+func (c *TieredCache[K, V]) countStore(found bool, err error) {
+	switch {
+	case err != nil:
+	case found:
+		c.storeHits.Add(1)
+	default:
+		c.storeMisses.Add(1)
+	}
+}
+
+// timedRead is a store read under StoreReadTimeout, shared by concurrent readers of
+// a key and left running in the background if they give up on it.
+type timedRead[V any] struct {
+	done   chan struct{}
+	expiry time.Time
+	err    error
+	val    V
+	found  bool
+	stale  atomic.Bool // key written since the read began; don't fill memory
+}
+
+// readStoreTimeout is readStore without the StoreHits/StoreMisses accounting.
+// Reads that outlive StoreReadTimeout report a miss and finish in the background,
+// filling memory unless the key was written in the meantime.
+//
+//nolint:revive // function-result-limit: mirrors Store.Get
+func (c *TieredCache[K, V]) readStoreTimeout(ctx context.Context, key K) (V, time.Time, bool, error) {
+	if c.readTimeout <= 0 {
+		return c.storeGet(ctx, key)
+	}
+
+	r, ok := c.reads.Load(key)
+	if !ok {
+		select {
+		case c.readSlots <- struct{}{}:
+		default:
+			return c.readStoreInline(ctx, key)
+		}
+		var loaded bool
+		r, loaded = c.reads.LoadOrCompute(key, func() (*timedRead[V], bool) {
+			return &timedRead[V]{done: make(chan struct{})}, false
+		})
+		if loaded {
+			<-c.readSlots
+		} else {
+			go c.runTimedRead(context.WithoutCancel(ctx), key, r)
+		}
+	}
+
+	timer := time.NewTimer(c.readTimeout)
+	defer timer.Stop()
+	var zero V
+	select {
+	case <-r.done:
+		return r.val, r.expiry, r.found, r.err
+	case <-ctx.Done():
+		return zero, time.Time{}, false, ctx.Err()
+	case <-timer.C:
+		return zero, time.Time{}, false, nil
+	}
+}
+
+// runTimedRead performs r, fills memory with its result unless the key was written
+// meanwhile, then releases r's read slot.
+func (c *TieredCache[K, V]) runTimedRead(ctx context.Context, key K, r *timedRead[V]) {
+	defer func() { <-c.readSlots }()
+	rctx, cancel := context.WithTimeout(ctx, c.asyncTimeout)
+	r.val, r.expiry, r.found, r.err = c.storeGet(rctx, key)
+	cancel()
+	close(r.done)
+
+	// Writers mark r stale before touching memory, and r stays registered until the
+	// fill is done, so a write either sees the fill or the fill sees the write.
+	if r.err == nil && r.found {
+		c.memory.setIfAbsentUnless(key, r.val, c.memory.toSec(r.expiry), r.stale.Load)
+	}
+	c.reads.Delete(key)
+}
+
+// readStoreInline reads key within StoreReadTimeout when every read slot is taken,
+// abandoning the read at the deadline instead of finishing it in the background.
+//
+//nolint:revive // function-result-limit: mirrors Store.Get
+func (c *TieredCache[K, V]) readStoreInline(ctx context.Context, key K) (V, time.Time, bool, error) {
+	rctx, cancel := context.WithTimeout(ctx, c.readTimeout)
+	defer cancel()
+	val, expiry, found, err := c.storeGet(rctx, key)
+	if err != nil && ctx.Err() == nil && errors.Is(rctx.Err(), context.DeadlineExceeded) {
+		var zero V
+		return zero, time.Time{}, false, nil
+	}
+	return val, expiry, found, err
+}
+
+// invalidateReads marks in-flight StoreReadTimeout reads of key stale so their late
+// results don't overwrite a write. Writers call it before touching memory.
+func (c *TieredCache[K, V]) invalidateReads(key K) {
+	if c.readTimeout <= 0 {
+		return
+	}
+	if r, ok := c.reads.Load(key); ok {
+		r.stale.Store(true)
+	}
+}
+
+// storeGet reads key from the store, or from writes WriteBack has yet to flush.
+// Undecodable entries are logged, counted, deleted best-effort, and reported as misses,
+// unless StrictDecode is set.
+//
+//nolint:revive // function-result-limit: mirrors Store.Get
+func (c *TieredCache[K, V]) storeGet(ctx context.Context, key K) (V, time.Time, bool, error) {
+	if c.writeBack != nil {
+		if op, ok := c.writeBack.lookup(key); ok {
+			if op.del || (!op.expiry.IsZero() && time.Now().After(op.expiry)) {
+				var zero V
+				return zero, time.Time{}, false, nil
+			}
+			return op.value, op.expiry, true, nil
+		}
+	}
+	val, expiry, found, err := c.Store.Get(ctx, key)
+	if err == nil || c.strictDecode || !isDecodeError(err) {
+		return val, expiry, found, err
+	}
+
+	c.decodeErrors.Add(1)
+	slog.Warn("discarding undecodable persisted entry", "key", key, "error", err)
+	if err := c.Store.Delete(ctx, key); err != nil {
+		slog.Warn("delete undecodable persisted entry failed", "key", key, "error", err)
+	}
+	var zero V
+	return zero, time.Time{}, false, nil
+}
+
 // Set stores to memory first (always), then persistence.
 // Uses the default TTL specified at cache creation.
 func (c *TieredCache[K, V]) Set(ctx context.Context, key K, value V) error {
@@ -76,13 +540,45 @@ func (c *TieredCache[K, V]) Set(ctx context.Context, key K, value V) error {
 // SetTTL stores to memory first (always), then persistence with explicit TTL.
 // A zero or negative TTL means the entry never expires.
 func (c *TieredCache[K, V]) SetTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
-	expiry := calculateExpiry(ttl, c.defaultTTL)
+	if c.sampler.sample() {
+		defer c.sampler.record("set", time.Now())
+	}
+	expiry := c.memory.expiry(ttl, c.defaultTTL)
 
-	if err := c.Store.ValidateKey(key); err != nil {
+	if err := c.validateWriteKey(key); err != nil {
 		return err
 	}
+	c.invalidateReads(key)
+	defer c.lockStrict(key)()
+
+	if !c.persists(expiry) {
+		// Drop any stored value so it can't outlive this memory-only entry.
+		c.memory.set(key, value, c.memory.toSec(expiry))
+		if c.writeBack != nil {
+			c.writeBack.add(key, writeOp[V]{del: true})
+			return nil
+		}
+		if err := c.Store.Delete(ctx, key); err != nil {
+			return fmt.Errorf("persistence delete failed: %w", err)
+		}
+		return nil
+	}
 
-	c.memory.set(key, value, timeToSec(expiry))
+	if c.writeBack != nil {
+		c.memory.set(key, value, c.memory.toSec(expiry))
+		c.writeBack.add(key, writeOp[V]{value: value, expiry: expiry})
+		return nil
+	}
+
+	if c.strictWrites {
+		if err := c.Store.Set(ctx, key, value, expiry); err != nil {
+			return fmt.Errorf("persistence store failed: %w", err)
+		}
+		c.memory.set(key, value, c.memory.toSec(expiry))
+		return nil
+	}
+
+	c.memory.set(key, value, c.memory.toSec(expiry))
 
 	if err := c.Store.Set(ctx, key, value, expiry); err != nil {
 		return fmt.Errorf("persistence store failed: %w", err)
@@ -91,7 +587,11 @@ func (c *TieredCache[K, V]) SetTTL(ctx context.Context, key K, value V, ttl time
 }
 
 // SetAsync stores to memory synchronously, persistence asynchronously.
+// With StrictWriteThrough, memory is updated only once the store write succeeds.
 // Uses the default TTL. Persistence errors are logged, not returned.
+// If the AsyncWorkers queue is full, SetAsync waits up to 100ms, or until ctx is done,
+// for room before dropping the store write; dropped writes are counted in
+// Stats.AsyncDropped.
 func (c *TieredCache[K, V]) SetAsync(ctx context.Context, key K, value V) error {
 	return c.SetAsyncTTL(ctx, key, value, 0)
 }
@@ -99,27 +599,94 @@ func (c *TieredCache[K, V]) SetAsync(ctx context.Context, key K, value V) error
 // SetAsyncTTL stores to memory synchronously, persistence asynchronously with explicit TTL.
 // Persistence errors are logged, not returned.
 func (c *TieredCache[K, V]) SetAsyncTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
-	expiry := calculateExpiry(ttl, c.defaultTTL)
+	expiry := c.memory.expiry(ttl, c.defaultTTL)
 
-	if err := c.Store.ValidateKey(key); err != nil {
+	if err := c.validateWriteKey(key); err != nil {
 		return err
 	}
+	c.invalidateReads(key)
 
-	c.memory.set(key, value, timeToSec(expiry))
-
-	go func() {
-		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncTimeout)
-		defer cancel()
-		if err := c.Store.Set(storeCtx, key, value, expiry); err != nil {
-			slog.Error("async persistence failed", "key", key, "error", err)
+	persist := c.persists(expiry)
+	if !c.strictWrites || !persist || c.writeBack != nil {
+		unlock := c.lockStrict(key)
+		c.memory.set(key, value, c.memory.toSec(expiry))
+		unlock()
+	}
+	if c.writeBack != nil {
+		if persist {
+			c.writeBack.add(key, writeOp[V]{value: value, expiry: expiry})
+		} else {
+			c.writeBack.add(key, writeOp[V]{del: true})
 		}
-	}()
+		return nil
+	}
 
+	c.pending.Add(1)
+	op := asyncOp[K, V]{ctx: context.WithoutCancel(ctx), key: key, value: value, expiry: expiry, del: !persist}
+	if err := c.async.add(ctx, op); err != nil {
+		c.pending.Add(-1)
+		c.asyncDropped.Add(1)
+		slog.Error("async persistence dropped", "key", key, "error", err)
+	}
 	return nil
 }
 
-// Fetch returns cached value or calls loader. Concurrent calls share one loader.
-// Computed values are stored with the default TTL.
+// writeAsync performs a queued SetAsync store write.
+func (c *TieredCache[K, V]) writeAsync(op asyncOp[K, V]) {
+	defer c.pending.Add(-1)
+	defer c.lockStrict(op.key)()
+	ctx, cancel := context.WithTimeout(op.ctx, c.asyncTimeout)
+	defer cancel()
+	if op.del {
+		if err := c.Store.Delete(ctx, op.key); err != nil {
+			slog.Error("async persistence delete failed", "key", op.key, "error", err)
+		}
+		return
+	}
+	if err := c.Store.Set(ctx, op.key, op.value, op.expiry); err != nil {
+		slog.Error("async persistence failed", "key", op.key, "error", err)
+		return
+	}
+	if c.strictWrites {
+		c.memory.set(op.key, op.value, c.memory.toSec(op.expiry))
+	}
+}
+
+// lockStrict serializes StrictWriteThrough writes to key, so concurrent writers update
+// memory in the order their store writes landed. It returns the unlock function and
+// does nothing without StrictWriteThrough.
+func (c *TieredCache[K, V]) lockStrict(key K) func() {
+	if c.strictMu == nil {
+		return func() {}
+	}
+	mu := &c.strictMu[maphash.Comparable(c.seed, key)%strictStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// persists reports whether an entry expiring at expiry is long-lived enough to write
+// to the store (see PersistMinTTL).
+func (c *TieredCache[K, V]) persists(expiry time.Time) bool {
+	return c.minPersist <= 0 || expiry.IsZero() || time.Until(expiry) >= c.minPersist
+}
+
+// PendingWrites returns the number of SetAsync writes queued or in flight to the store,
+// plus, with WriteBack, the number of keys whose latest write has not been flushed.
+// A steadily climbing value means the store is slower than the write rate, or failing
+// and waiting out timeouts, so memory and store are drifting apart. Writes SetAsync
+// could not queue are not pending; Stats.AsyncDropped counts them.
+func (c *TieredCache[K, V]) PendingWrites() int {
+	n := int(c.pending.Load())
+	if c.writeBack != nil {
+		n += c.writeBack.len()
+	}
+	return n
+}
+
+// Fetch returns the value from memory, then the store, or else calls loader.
+// Concurrent misses on a key share one store read and at most one loader call, whose
+// result is written to memory and the store. Computed values are stored with the
+// default TTL.
 func (c *TieredCache[K, V]) Fetch(ctx context.Context, key K, loader func(context.Context) (V, error)) (V, error) {
 	return c.getSet(ctx, key, loader, 0)
 }
@@ -136,19 +703,13 @@ func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(conte
 		return val, nil
 	}
 
-	if err := c.Store.ValidateKey(key); err != nil {
-		return zero, fmt.Errorf("invalid key: %w", err)
-	}
-
-	val, expiry, found, err := c.Store.Get(ctx, key)
-	if err != nil {
-		return zero, fmt.Errorf("persistence load: %w", err)
-	}
-	if found {
-		c.memory.set(key, val, timeToSec(expiry))
-		return val, nil
+	// Loaded values are written back, so keys go through the same checks as Set.
+	if err := c.validateWriteKey(key); err != nil {
+		return zero, err
 	}
 
+	// Only the flight's leader reads the store, so concurrent misses on a key cost one
+	// store read as well as at most one loader call.
 	call, loaded := c.flights.LoadOrCompute(key, func() (*flightCall[V], bool) {
 		fc := &flightCall[V]{}
 		fc.wg.Add(1)
@@ -156,6 +717,7 @@ func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(conte
 	})
 
 	if loaded {
+		c.memory.coalescedCalls.Add(1)
 		call.wg.Wait()
 		return call.val, call.err
 	}
@@ -167,7 +729,12 @@ func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(conte
 		return v, nil
 	}
 
-	val, expiry, found, err = c.Store.Get(ctx, key)
+	// The read is shared with the flight's waiters, so the leader's cancellation must
+	// not fail it for them.
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.asyncTimeout)
+	val, expiry, found, err := c.storeGet(rctx, key)
+	cancel()
+	c.countStore(found, err)
 	if err != nil {
 		call.err = fmt.Errorf("persistence load: %w", err)
 		c.flights.Delete(key)
@@ -175,13 +742,14 @@ func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(conte
 		return zero, call.err
 	}
 	if found {
-		c.memory.set(key, val, timeToSec(expiry))
+		c.memory.set(key, val, c.memory.toSec(expiry))
 		call.val = val
 		c.flights.Delete(key)
 		call.wg.Done()
 		return val, nil
 	}
 
+	c.memory.loaderCalls.Add(1)
 	val, err = loader(ctx)
 	if err != nil {
 		call.err = err
@@ -190,12 +758,25 @@ func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(conte
 		return zero, err
 	}
 
-	exp := calculateExpiry(ttl, c.defaultTTL)
-	c.memory.set(key, val, timeToSec(exp))
+	exp := c.memory.expiry(ttl, c.defaultTTL)
+	persist := c.persists(exp)
+	c.invalidateReads(key)
+	unlock := c.lockStrict(key)
+	if !c.strictWrites || !persist || c.writeBack != nil {
+		c.memory.set(key, val, c.memory.toSec(exp))
+	}
 
-	if err := c.Store.Set(ctx, key, val, exp); err != nil {
-		slog.Warn("Fetch persistence failed", "key", key, "error", err)
+	// A memory-only result needs no store delete: the read above found no live value.
+	if persist && c.writeBack != nil {
+		c.writeBack.add(key, writeOp[V]{value: val, expiry: exp})
+	} else if persist {
+		if err := c.Store.Set(ctx, key, val, exp); err != nil {
+			slog.Warn("Fetch persistence failed", "key", key, "error", err)
+		} else if c.strictWrites {
+			c.memory.set(key, val, c.memory.toSec(exp))
+		}
 	}
+	unlock()
 
 	call.val = val
 	c.flights.Delete(key)
@@ -206,11 +787,17 @@ func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(conte
 
 // Delete removes from memory and persistence.
 func (c *TieredCache[K, V]) Delete(ctx context.Context, key K) error {
+	c.invalidateReads(key)
+	defer c.lockStrict(key)()
 	c.memory.del(key)
 
 	if err := c.Store.ValidateKey(key); err != nil {
 		return fmt.Errorf("invalid key: %w", err)
 	}
+	if c.writeBack != nil {
+		c.writeBack.add(key, writeOp[V]{del: true})
+		return nil
+	}
 	if err := c.Store.Delete(ctx, key); err != nil {
 		return fmt.Errorf("persistence delete: %w", err)
 	}
@@ -219,6 +806,16 @@ func (c *TieredCache[K, V]) Delete(ctx context.Context, key K) error {
 
 // Flush clears memory and persistence. Returns total entries removed.
 func (c *TieredCache[K, V]) Flush(ctx context.Context) (int, error) {
+	if c.writeBack != nil {
+		// Hold off the flusher so no buffered write lands after the store is cleared.
+		c.writeBack.flushMu.Lock()
+		defer c.writeBack.flushMu.Unlock()
+		c.writeBack.discard()
+	}
+	c.reads.Range(func(_ K, r *timedRead[V]) bool {
+		r.stale.Store(true)
+		return true
+	})
 	memoryRemoved := c.memory.flush()
 	persistRemoved, err := c.Store.Flush(ctx)
 	if err != nil {
@@ -232,18 +829,27 @@ func (c *TieredCache[K, V]) Len() int {
 	return c.memory.len()
 }
 
-// Range returns an iterator over all non-expired key-value pairs in memory.
-// Does not iterate the persistence layer.
+// Stats returns a snapshot of cache counters. Safe for concurrent use.
+func (c *TieredCache[K, V]) Stats() Stats {
+	st := c.memory.stats()
+	st.DecodeErrors = c.decodeErrors.Load()
+	st.StoreHits = c.storeHits.Load()
+	st.StoreMisses = c.storeMisses.Load()
+	st.AsyncDropped = c.asyncDropped.Load()
+	return st
+}
+
+// Range returns an iterator over all non-expired key-value pairs in memory, skipping
+// evicted entries still held on death row. Does not iterate the persistence layer.
 // Iteration order is undefined. Safe for concurrent use.
 // Changes during iteration may or may not be reflected.
 func (c *TieredCache[K, V]) Range() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
-		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-		now := uint32(time.Now().Unix())
+		now := c.memory.nowSec()
 		c.memory.entries.Range(func(key K, e *entry[K, V]) bool {
-			// Skip expired entries.
+			// Skip expired entries and evicted ones held on death row.
 			expiry := e.expirySec.Load()
-			if expiry != 0 && expiry < now {
+			if (expiry != 0 && expiry < now) || e.onDeathRow() {
 				return true
 			}
 
@@ -259,10 +865,79 @@ func (c *TieredCache[K, V]) Range() iter.Seq2[K, V] {
 	}
 }
 
-// Close releases store resources.
+// runCleanup removes expired entries from memory and the store every interval, until
+// ctx is canceled by Close.
+func (c *TieredCache[K, V]) runCleanup(ctx context.Context, interval time.Duration) {
+	defer close(c.cleanupDone)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		mem := c.memory.removeExpired()
+		n, err := c.Store.Cleanup(ctx, 0)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("background store cleanup failed", "removed", n, "error", err)
+			continue
+		}
+		slog.Debug("background cleanup complete", "memory", mem, "store", n)
+	}
+}
+
+// Close releases store resources. Queued SetAsync writes finish first, and with
+// WriteBack, pending writes are flushed; with CleanupInterval, background cleanup is
+// stopped.
 func (c *TieredCache[K, V]) Close() error {
+	if c.stopCleanup != nil {
+		c.stopCleanup()
+		<-c.cleanupDone
+	}
+	var errs []error
+	if c.async != nil {
+		c.async.close()
+	}
+	if c.writeBack != nil {
+		if err := c.writeBack.close(); err != nil {
+			errs = append(errs, fmt.Errorf("flush pending writes: %w", err))
+		}
+	}
 	if err := c.Store.Close(); err != nil {
-		return fmt.Errorf("close persistence: %w", err)
+		errs = append(errs, fmt.Errorf("close persistence: %w", err))
 	}
-	return nil
+	return errors.Join(errs...)
+}
+
+// LoadingCache is a TieredCache whose Get loads missing keys with a fixed loader,
+// the read-through pattern most callers build by hand around Fetch.
+type LoadingCache[K comparable, V any] struct {
+	*TieredCache[K, V]
+	loader func(context.Context, K) (V, error)
+}
+
+// NewLoadingCache creates a cache backed by store whose Get calls loader on a miss in
+// both memory and store. Options are those of NewTiered, such as TTL, Warmup and
+// StoreReadTimeout.
+func NewLoadingCache[K comparable, V any](
+	store Store[K, V], loader func(ctx context.Context, key K) (V, error), opts ...Option,
+) (*LoadingCache[K, V], error) {
+	if loader == nil {
+		return nil, errors.New("loader cannot be nil")
+	}
+	tc, err := NewTiered(store, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &LoadingCache[K, V]{TieredCache: tc, loader: loader}, nil
+}
+
+// Get returns the value for key, loading and storing it with the default TTL if it is
+// in neither memory nor the store. Concurrent misses for a key share one loader call.
+// Loader errors are returned and nothing is cached.
+func (c *LoadingCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return c.Fetch(ctx, key, func(ctx context.Context) (V, error) {
+		return c.loader(ctx, key)
+	})
 }
@@ -2,7 +2,10 @@ package fido
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -240,15 +243,14 @@ func (m *sequenceMockStore[K, V]) Close() error {
 	return nil
 }
 
-// injectingMockStore is a mock that can inject values into cache memory during Get.
+// injectingMockStore is a mock that injects a value into cache memory during
+// ValidateKey, after Fetch's first memory check but before it starts a flight.
 type injectingMockStore[K comparable, V any] struct {
 	*mockStore[K, V]
 
 	cache       *TieredCache[K, V] // Set after cache creation
 	injectKey   K
 	injectValue V
-	injectOnGet int // Inject on Nth Get call
-	getCalls    atomic.Int32
 }
 
 func newInjectingMockStore[K comparable, V any]() *injectingMockStore[K, V] {
@@ -257,24 +259,19 @@ func newInjectingMockStore[K comparable, V any]() *injectingMockStore[K, V] {
 	}
 }
 
-func (m *injectingMockStore[K, V]) Get(ctx context.Context, key K) (v V, expiry time.Time, found bool, err error) {
-	callNum := m.getCalls.Add(1)
-
-	// Inject value into cache memory at specified call
-	if m.cache != nil && m.injectOnGet > 0 && int(callNum) == m.injectOnGet {
+func (m *injectingMockStore[K, V]) ValidateKey(key K) error {
+	if m.cache != nil {
 		m.cache.memory.set(m.injectKey, m.injectValue, 0)
 	}
-
-	return m.mockStore.Get(ctx, key)
+	return m.mockStore.ValidateKey(key)
 }
 
 func TestTieredCache_Fetch_SecondMemoryCheck(t *testing.T) {
-	// This test triggers the second memory check path in getSet (line 166-171)
-	// by injecting a value into memory during the first store.Get call.
+	// This test triggers the second memory check inside the flight in getSet
+	// by injecting a value into memory after the first check.
 	store := newInjectingMockStore[string, int]()
 	store.injectKey = "key1"
 	store.injectValue = 77
-	store.injectOnGet = 1 // Inject during first Get
 
 	cache, err := NewTiered[string, int](store)
 	if err != nil {
@@ -1845,11 +1842,10 @@ func TestTieredCache_Fetch_SecondCheckMemory(t *testing.T) {
 	}
 }
 
-// TestTieredCache_Fetch_SecondStoreGetError tests when second store.Get fails.
-func TestTieredCache_Fetch_SecondStoreGetError(t *testing.T) {
+// TestTieredCache_Fetch_StoreGetError tests when the flight's store.Get fails.
+func TestTieredCache_Fetch_StoreGetError(t *testing.T) {
 	store := newSequenceMockStore[string, int]()
-	// First Get returns not found, second Get fails
-	store.failOnGetN = 2
+	store.failOnGetN = 1
 
 	cache, err := NewTiered[string, int](store)
 	if err != nil {
@@ -1863,17 +1859,16 @@ func TestTieredCache_Fetch_SecondStoreGetError(t *testing.T) {
 		return 42, nil
 	})
 
-	// The second store.Get fails, which should return an error
+	// The store.Get fails, which should return an error
 	if err == nil {
-		t.Error("expected error from second store.Get failure")
+		t.Error("expected error from store.Get failure")
 	}
 }
 
-// TestTieredCache_Fetch_SecondStoreGetFound tests when second store.Get finds value.
-func TestTieredCache_Fetch_SecondStoreGetFound(t *testing.T) {
+// TestTieredCache_Fetch_StoreGetFound tests when the flight's store.Get finds value.
+func TestTieredCache_Fetch_StoreGetFound(t *testing.T) {
 	store := newSequenceMockStore[string, int]()
-	// First Get returns not found, second Get returns value
-	store.returnOnGetN = 2
+	store.returnOnGetN = 1
 	store.valueToReturn = 99
 
 	cache, err := NewTiered[string, int](store)
@@ -1893,12 +1888,1708 @@ func TestTieredCache_Fetch_SecondStoreGetFound(t *testing.T) {
 		t.Fatalf("Fetch failed: %v", err)
 	}
 
-	// Should return value from second store.Get, not from loader
+	// Should return value from store.Get, not from loader
 	if val != 99 {
 		t.Errorf("val = %d; want 99", val)
 	}
 
 	if loaderCalled {
-		t.Error("loader should not be called when second store.Get finds value")
+		t.Error("loader should not be called when store.Get finds value")
+	}
+	if store.getCalls != 1 {
+		t.Errorf("store.Get called %d times; want 1", store.getCalls)
+	}
+}
+
+// slowGetMockStore counts Gets and holds each one until gate is closed.
+type slowGetMockStore struct {
+	*mockStore[string, int]
+	gate chan struct{}
+	gets atomic.Int32
+}
+
+func (m *slowGetMockStore) Get(ctx context.Context, key string) (int, time.Time, bool, error) {
+	m.gets.Add(1)
+	select {
+	case <-m.gate:
+	case <-ctx.Done():
+		return 0, time.Time{}, false, ctx.Err()
+	}
+	return m.mockStore.Get(ctx, key)
+}
+
+// waitCoalesced waits until n Fetch calls have joined another caller's flight.
+func waitCoalesced(t *testing.T, cache *TieredCache[string, int], n int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.memory.coalescedCalls.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("coalesced calls = %d; want %d", cache.memory.coalescedCalls.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestTieredCache_Fetch_DedupesStoreReads tests that concurrent misses share one
+// store read as well as one loader call.
+func TestTieredCache_Fetch_DedupesStoreReads(t *testing.T) {
+	ctx := context.Background()
+	for _, inStore := range []bool{true, false} {
+		store := &slowGetMockStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+		if inStore {
+			store.mockStore.Set(ctx, "k", 7, time.Time{}) //nolint:errcheck // Test helper
+		}
+		cache, err := NewTiered[string, int](store)
+		if err != nil {
+			t.Fatalf("NewTiered: %v", err)
+		}
+
+		var loaderCalls atomic.Int32
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				v, err := cache.Fetch(ctx, "k", func(context.Context) (int, error) {
+					loaderCalls.Add(1)
+					return 7, nil
+				})
+				if err != nil || v != 7 {
+					t.Errorf("Fetch = %d, %v; want 7, nil", v, err)
+				}
+			})
+		}
+		waitCoalesced(t, cache, 9)
+		close(store.gate)
+		wg.Wait()
+
+		if n := store.gets.Load(); n != 1 {
+			t.Errorf("inStore=%v: store.Get called %d times; want 1", inStore, n)
+		}
+		if n, want := loaderCalls.Load(), map[bool]int32{true: 0, false: 1}[inStore]; n != want {
+			t.Errorf("inStore=%v: loader called %d times; want %d", inStore, n, want)
+		}
+		cache.Close() //nolint:errcheck // Test cleanup
+	}
+}
+
+// TestTieredCache_Fetch_LeaderCanceled tests that the caller whose Fetch reads the
+// store can give up without failing the read for the callers sharing it.
+func TestTieredCache_Fetch_LeaderCanceled(t *testing.T) {
+	ctx := context.Background()
+	store := &slowGetMockStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	store.mockStore.Set(ctx, "k", 7, time.Time{}) //nolint:errcheck // Test helper
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	loader := func(context.Context) (int, error) { return 0, errors.New("loader called") }
+	lctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		cache.Fetch(lctx, "k", loader) //nolint:errcheck // Test helper
+	})
+	for store.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for range 3 {
+		wg.Go(func() {
+			if v, err := cache.Fetch(ctx, "k", loader); err != nil || v != 7 {
+				t.Errorf("Fetch = %d, %v; want 7, nil", v, err)
+			}
+		})
+	}
+	waitCoalesced(t, cache, 3)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(store.gate)
+	wg.Wait()
+}
+
+func TestTieredCache_ZeroValues(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	if err := store.Set(ctx, "persisted", 0, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	if v, ok, err := cache.Get(ctx, "persisted"); err != nil || !ok || v != 0 {
+		t.Errorf("Get(persisted) = %d, %v, %v; want 0, true, nil", v, ok, err)
+	}
+
+	if err := cache.Set(ctx, "zero", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	calls := 0
+	v, err := cache.Fetch(ctx, "zero", func(context.Context) (int, error) {
+		calls++
+		return 5, nil
+	})
+	if err != nil || v != 0 || calls != 0 {
+		t.Errorf("Fetch = %d, %v (loader calls %d); want cached 0", v, err, calls)
+	}
+}
+
+// decodeFailError mimics compress.DecodeError without importing the store modules.
+type decodeFailError struct{}
+
+func (decodeFailError) Error() string       { return "unmarshal value: corrupt" }
+func (decodeFailError) DecodeFailure() bool { return true }
+
+// corruptMockStore returns a decode failure for every Get and records deletes.
+type corruptMockStore[K comparable, V any] struct {
+	*mockStore[K, V]
+	deletes atomic.Int32
+}
+
+func (*corruptMockStore[K, V]) Get(context.Context, K) (v V, expiry time.Time, found bool, err error) {
+	return v, time.Time{}, false, fmt.Errorf("datastore get: %w", decodeFailError{})
+}
+
+func (m *corruptMockStore[K, V]) Delete(ctx context.Context, key K) error {
+	m.deletes.Add(1)
+	return m.mockStore.Delete(ctx, key)
+}
+
+func TestTieredCache_DecodeErrorIsMiss(t *testing.T) {
+	ctx := context.Background()
+	store := &corruptMockStore[string, int]{mockStore: newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	_, found, err := cache.Get(ctx, "bad")
+	if err != nil || found {
+		t.Errorf("Get = found %v, err %v; want miss without error", found, err)
+	}
+	if got := cache.Stats().DecodeErrors; got != 1 {
+		t.Errorf("DecodeErrors = %d; want 1", got)
+	}
+	if store.deletes.Load() != 1 {
+		t.Errorf("deletes = %d; want bad entry deleted once", store.deletes.Load())
+	}
+
+	// Fetch falls through to the loader.
+	val, err := cache.Fetch(ctx, "bad", func(context.Context) (int, error) { return 7, nil })
+	if err != nil || val != 7 {
+		t.Errorf("Fetch = %d, %v; want 7, nil", val, err)
+	}
+}
+
+func TestTieredCache_StrictDecode(t *testing.T) {
+	ctx := context.Background()
+	store := &corruptMockStore[string, int]{mockStore: newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store, StrictDecode())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	if _, _, err := cache.Get(ctx, "bad"); !isDecodeError(err) {
+		t.Errorf("Get error = %v; want decode error surfaced", err)
+	}
+	if got := cache.Stats().DecodeErrors; got != 0 {
+		t.Errorf("DecodeErrors = %d; want 0 in strict mode", got)
+	}
+}
+
+func TestTieredCache_NonDecodeErrorStillFails(t *testing.T) {
+	store := newMockStore[string, int]()
+	store.setFailGet(true)
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	if _, _, err := cache.Get(context.Background(), "k"); err == nil {
+		t.Error("non-decode store errors should still be returned")
+	}
+}
+
+func TestTieredCache_Get_InheritsRemainingStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, TTL(time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	// Entry with 10 seconds left in the store.
+	expiry := time.Now().Add(10 * time.Second)
+	if err := store.Set(ctx, "k", 1, expiry); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	if _, found, err := cache.Get(ctx, "k"); err != nil || !found {
+		t.Fatalf("Get: found=%v err=%v", found, err)
+	}
+
+	ent, ok := cache.memory.getEntry("k")
+	if !ok {
+		t.Fatal("entry not promoted to memory")
+	}
+	if got, want := ent.expirySec.Load(), timeToSec(expiry); got != want {
+		t.Errorf("memory expiry = %d; want %d (store expiry, not default TTL)", got, want)
+	}
+}
+
+// recentMockStore adds RecentLoader support to mockStore for warmup tests.
+type recentMockStore[V any] struct {
+	*mockStore[string, V]
+	recent []recentMockEntry[V] // newest first
+	err    error
+	gate   chan struct{} // if set, LoadRecent blocks until closed or ctx is done
+}
+
+type recentMockEntry[V any] struct {
+	key    string
+	value  V
+	expiry time.Time
+}
+
+func (m *recentMockStore[V]) LoadRecent(ctx context.Context, limit int, fn func(string, V, time.Time) bool) error {
+	if m.gate != nil {
+		select {
+		case <-m.gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for i, e := range m.recent {
+		if limit > 0 && i >= limit {
+			break
+		}
+		if !fn(e.key, e.value, e.expiry) {
+			break
+		}
+	}
+	return m.err
+}
+
+func TestTieredCache_Warmup(t *testing.T) {
+	store := &recentMockStore[int]{
+		mockStore: newMockStore[string, int](),
+		recent: []recentMockEntry[int]{
+			{key: "a", value: 1},
+			{key: "b", value: 2, expiry: time.Now().Add(time.Hour)},
+			{key: "expired", value: 3, expiry: time.Now().Add(-time.Hour)},
+			{key: "c", value: 4},
+			{key: "over-limit", value: 5},
+		},
+	}
+
+	cache, err := NewTiered[string, int](store, Warmup(4))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+	<-cache.WarmupDone()
+
+	for k, want := range map[string]int{"a": 1, "b": 2, "c": 4} {
+		if v, ok := cache.memory.get(k); !ok || v != want {
+			t.Errorf("memory[%q] = %v, %v; want %d, true", k, v, ok, want)
+		}
+	}
+	for _, k := range []string{"expired", "over-limit"} {
+		if _, ok := cache.memory.get(k); ok {
+			t.Errorf("memory[%q] should not be warmed", k)
+		}
+	}
+	if got := cache.Len(); got != 3 {
+		t.Errorf("Len() = %d; want 3", got)
+	}
+}
+
+// freqMockStore adds FrequencyLoader support to recentMockStore.
+type freqMockStore struct {
+	*recentMockStore[int]
+	hot []recentMockEntry[int] // most read first
+}
+
+func (m *freqMockStore) LoadByFrequency(_ context.Context, limit int, fn func(string, int, time.Time) bool) error {
+	for i, e := range m.hot {
+		if limit > 0 && i >= limit {
+			break
+		}
+		if !fn(e.key, e.value, e.expiry) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestTieredCache_WarmupStrategy(t *testing.T) {
+	newStore := func() *freqMockStore {
+		return &freqMockStore{
+			recentMockStore: &recentMockStore[int]{
+				mockStore: newMockStore[string, int](),
+				recent:    []recentMockEntry[int]{{key: "new", value: 1}},
+			},
+			hot: []recentMockEntry[int]{{key: "hot", value: 2}},
+		}
+	}
+	tests := []struct {
+		name  string
+		store Store[string, int]
+		opts  []Option
+		want  string // key expected in memory after warmup; "" for none
+	}{
+		{"default recency", newStore(), nil, "new"},
+		{"frequency", newStore(), []Option{WarmupStrategy(WarmupByFrequency)}, "hot"},
+		{"frequency unsupported", newStore().recentMockStore, []Option{WarmupStrategy(WarmupByFrequency)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewTiered(tt.store, append([]Option{Warmup(10)}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("NewTiered: %v", err)
+			}
+			defer cache.Close() //nolint:errcheck // Test cleanup
+			<-cache.WarmupDone()
+
+			want := 0
+			if tt.want != "" {
+				want = 1
+				if _, ok := cache.memory.get(tt.want); !ok {
+					t.Errorf("memory[%q] should be warmed", tt.want)
+				}
+			}
+			if got := cache.Len(); got != want {
+				t.Errorf("Len() = %d; want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestTieredCache_Warmup_KeepsNewerValues(t *testing.T) {
+	store := &recentMockStore[int]{
+		mockStore: newMockStore[string, int](),
+		recent:    []recentMockEntry[int]{{key: "a", value: 1}},
+		gate:      make(chan struct{}),
+	}
+
+	cache, err := NewTiered[string, int](store, Warmup(10))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	// A write that lands before warmup reaches the key must win.
+	if err := cache.Set(context.Background(), "a", 99); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	close(store.gate)
+	<-cache.WarmupDone()
+
+	if v, _ := cache.memory.get("a"); v != 99 {
+		t.Errorf("memory[a] = %d; want 99 (warmup must not clobber newer writes)", v)
+	}
+}
+
+func TestTieredCache_Warmup_Error(t *testing.T) {
+	store := &recentMockStore[int]{
+		mockStore: newMockStore[string, int](),
+		recent:    []recentMockEntry[int]{{key: "a", value: 1}},
+		err:       errors.New("scan failed"),
+	}
+
+	cache, err := NewTiered[string, int](store, Warmup(10))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	select {
+	case <-cache.WarmupDone():
+	case <-time.After(5 * time.Second):
+		t.Fatal("WarmupDone not closed after LoadRecent error")
+	}
+	if _, ok := cache.memory.get("a"); !ok {
+		t.Error("entries delivered before the error should still be warmed")
+	}
+}
+
+func TestTieredCache_WarmupMethod(t *testing.T) {
+	ctx := context.Background()
+	store := &recentMockStore[int]{
+		mockStore: newMockStore[string, int](),
+		recent: []recentMockEntry[int]{
+			{key: "a", value: 1},
+			{key: "b", value: 2, expiry: time.Now().Add(-time.Minute)},
+			{key: "c", value: 3, expiry: time.Now().Add(time.Hour)},
+			{key: "d", value: 4},
+		},
+	}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	if err := cache.Warmup(ctx, 2); err != nil {
+		t.Fatalf("Warmup(2): %v", err)
+	}
+	if _, ok := cache.memory.get("a"); !ok || cache.Len() != 1 {
+		t.Errorf("after Warmup(2): Len() = %d; want only a (b is expired)", cache.Len())
+	}
+
+	if err := cache.Warmup(ctx, 0); err != nil {
+		t.Fatalf("Warmup(0): %v", err)
+	}
+	if cache.Len() != 3 {
+		t.Errorf("after Warmup(0): Len() = %d; want 3 unexpired entries", cache.Len())
+	}
+
+	store.err = errors.New("scan failed")
+	if err := cache.Warmup(ctx, 0); !errors.Is(err, store.err) {
+		t.Errorf("Warmup() = %v; want the loader's error", err)
+	}
+
+	plain, err := NewTiered[string, int](newMockStore[string, int]())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer plain.Close() //nolint:errcheck // Test cleanup
+	if err := plain.Warmup(ctx, 0); err == nil {
+		t.Error("Warmup() should fail for a store without RecentLoader")
+	}
+}
+
+func TestTieredCache_WarmupTimeout(t *testing.T) {
+	store := &recentMockStore[int]{
+		mockStore: newMockStore[string, int](),
+		recent:    []recentMockEntry[int]{{key: "a", value: 1}},
+		gate:      make(chan struct{}), // never opened: only the timeout ends warmup
+	}
+
+	cache, err := NewTiered[string, int](store, Warmup(10), WarmupTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	select {
+	case <-cache.WarmupDone():
+	case <-time.After(5 * time.Second):
+		t.Fatal("WarmupDone not closed after WarmupTimeout")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d; want 0 after warmup timed out", cache.Len())
+	}
+}
+
+func TestTieredCache_WarmupDone_NoWarmup(t *testing.T) {
+	tests := []struct {
+		name  string
+		store Store[string, int]
+		opts  []Option
+	}{
+		{"not configured", &recentMockStore[int]{mockStore: newMockStore[string, int]()}, nil},
+		{"store lacks LoadRecent", newMockStore[string, int](), []Option{Warmup(10)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewTiered[string, int](tt.store, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewTiered: %v", err)
+			}
+			defer cache.Close() //nolint:errcheck // Test cleanup
+
+			select {
+			case <-cache.WarmupDone():
+			default:
+				t.Error("WarmupDone should be closed immediately")
+			}
+		})
+	}
+}
+
+func TestTieredCache_MaxKeyBytes(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, MaxKeyBytes(4))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.Set(ctx, "ok", 1); err != nil {
+		t.Fatalf("Set(ok): %v", err)
+	}
+	if err := cache.Set(ctx, "too-long", 2); err == nil {
+		t.Error("Set with over-long key should fail")
+	}
+	if err := cache.SetAsync(ctx, "too-long", 2); err == nil {
+		t.Error("SetAsync with over-long key should fail")
+	}
+	if _, err := cache.Fetch(ctx, "too-long", func(context.Context) (int, error) { return 3, nil }); err == nil {
+		t.Error("Fetch with over-long key should fail")
+	}
+	if _, ok := store.data["too-long"]; ok {
+		t.Error("over-long key should not reach the store")
+	}
+	if got := cache.Stats().RejectedKeys; got != 3 {
+		t.Errorf("Stats().RejectedKeys = %d; want 3", got)
+	}
+}
+
+func TestTieredCache_KeyValidator(t *testing.T) {
+	errBadKey := errors.New("no spaces")
+	var order []string
+	store := &orderValidatingStore{mockStore: newMockStore[string, int](), order: &order}
+	cache, err := NewTiered[string, int](store, KeyValidator(func(k string) error {
+		order = append(order, "validator")
+		if strings.Contains(k, " ") {
+			return errBadKey
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.Set(ctx, "bad key", 1); !errors.Is(err, errBadKey) {
+		t.Errorf("Set error = %v; want %v", err, errBadKey)
+	}
+	if len(order) != 1 {
+		t.Errorf("calls = %v; the store must not see keys the validator rejects", order)
+	}
+
+	order = nil
+	if err := cache.Set(ctx, "good", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if len(order) < 2 || order[0] != "validator" || order[1] != "store" {
+		t.Errorf("calls = %v; want validator before store", order)
+	}
+
+	// Fetch writes loaded values back, so it rejects the same keys as Set.
+	loaded := false
+	_, err = cache.Fetch(ctx, "bad key", func(context.Context) (int, error) {
+		loaded = true
+		return 1, nil
+	})
+	if !errors.Is(err, errBadKey) {
+		t.Errorf("Fetch error = %v; want %v", err, errBadKey)
+	}
+	if loaded {
+		t.Error("Fetch called the loader for a rejected key")
+	}
+	if _, ok := store.data["bad key"]; ok {
+		t.Error("Fetch persisted a rejected key")
+	}
+
+	if _, err := NewTiered[string, int](store, KeyValidator(func(int) error { return nil })); err == nil {
+		t.Error("NewTiered should reject a validator with the wrong key type")
+	}
+}
+
+// orderValidatingStore records when ValidateKey runs.
+type orderValidatingStore struct {
+	*mockStore[string, int]
+	order *[]string
+}
+
+func (m *orderValidatingStore) ValidateKey(string) error {
+	*m.order = append(*m.order, "store")
+	return nil
+}
+
+func TestTieredCache_LatencySampler(t *testing.T) {
+	var ops []string
+	sink := func(op string, _ time.Duration) { ops = append(ops, op) }
+
+	cache, err := NewTiered[string, int](newMockStore[string, int](), LatencySampler(1, sink))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.Set(ctx, "a", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, _, err := cache.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, _, err := cache.Get(ctx, "missing"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if want := []string{"set", "get", "get"}; !slices.Equal(ops, want) {
+		t.Errorf("sampled ops = %v; want %v", ops, want)
+	}
+}
+
+func TestTieredCache_WarmupConcurrency(t *testing.T) {
+	store := &recentMockStore[int]{mockStore: newMockStore[string, int]()}
+	for i := range 5000 {
+		store.recent = append(store.recent, recentMockEntry[int]{key: fmt.Sprintf("key%d", i), value: i})
+	}
+
+	cache, err := NewTiered[string, int](store, Size(10000), Warmup(10000), WarmupConcurrency(8))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+	<-cache.WarmupDone()
+
+	if got := cache.Len(); got != 5000 {
+		t.Errorf("Len() = %d; want 5000", got)
+	}
+	for i := range 5000 {
+		k := fmt.Sprintf("key%d", i)
+		if v, ok := cache.memory.get(k); !ok || v != i {
+			t.Fatalf("memory[%q] = %d, %v; want %d, true", k, v, ok, i)
+		}
+	}
+}
+
+// pingMockStore adds HealthChecker support to mockStore.
+type pingMockStore struct {
+	*mockStore[string, int]
+	err error
+}
+
+func (m *pingMockStore) Ping(context.Context) error { return m.err }
+
+func TestTieredCache_Ping(t *testing.T) {
+	down := errors.New("connection refused")
+	tests := []struct {
+		name    string
+		store   Store[string, int]
+		wantErr error
+	}{
+		{"healthy", &pingMockStore{mockStore: newMockStore[string, int]()}, nil},
+		{"down", &pingMockStore{mockStore: newMockStore[string, int](), err: down}, down},
+		{"no HealthChecker", newMockStore[string, int](), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewTiered[string, int](tt.store)
+			if err != nil {
+				t.Fatalf("NewTiered: %v", err)
+			}
+			defer cache.Close() //nolint:errcheck // Test cleanup
+
+			err = cache.Ping(context.Background())
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Ping() = %v; want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Ping() = %v; want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTieredCache_MinTTL(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, MinTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetTTL(context.Background(), "k", 1, time.Nanosecond); err != nil {
+		t.Fatalf("SetTTL: %v", err)
+	}
+	_, expiry, found, err := store.Get(context.Background(), "k")
+	if err != nil || !found {
+		t.Fatalf("store.Get: found=%v err=%v", found, err)
+	}
+	if r := time.Until(expiry); r < 59*time.Second {
+		t.Errorf("store expiry in %v; want about 1m (MinTTL applies to the store too)", r)
+	}
+}
+
+// slowMockStore delays Get and records peak concurrency.
+type slowMockStore struct {
+	*mockStore[string, int]
+	delay    time.Duration
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (m *slowMockStore) Get(ctx context.Context, key string) (int, time.Time, bool, error) {
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		p := m.peak.Load()
+		if n <= p || m.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(m.delay)
+	return m.mockStore.Get(ctx, key)
+}
+
+func TestTieredCache_GetMany(t *testing.T) {
+	store := &slowMockStore{mockStore: newMockStore[string, int](), delay: 20 * time.Millisecond}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	var keys []string
+	for i := range 40 {
+		k := fmt.Sprintf("key%d", i)
+		keys = append(keys, k)
+		if err := store.Set(ctx, k, i, time.Time{}); err != nil {
+			t.Fatalf("store.Set: %v", err)
+		}
+	}
+	cache.memory.set("key0", 100, 0) // memory wins over store
+	keys = append(keys, "missing")
+
+	start := time.Now()
+	got, err := cache.GetMany(ctx, keys)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("GetMany: %v", err)
+	}
+
+	if len(got) != 40 {
+		t.Errorf("GetMany returned %d values; want 40", len(got))
+	}
+	if got["key0"] != 100 || got["key7"] != 7 {
+		t.Errorf("got key0=%d key7=%d; want 100, 7", got["key0"], got["key7"])
+	}
+	if _, ok := got["missing"]; ok {
+		t.Error("absent keys should be omitted")
+	}
+	if p := store.peak.Load(); p < 2 || p > getManyWorkers {
+		t.Errorf("peak concurrent store reads = %d; want 2..%d", p, getManyWorkers)
+	}
+	if elapsed > 40*store.delay/2 {
+		t.Errorf("GetMany took %v; store reads should overlap", elapsed)
+	}
+	if _, ok := cache.memory.get("key7"); !ok {
+		t.Error("values read from the store should be cached in memory")
+	}
+}
+
+func TestTieredCache_GetMany_Errors(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	cache.memory.set("hit", 1, 0)
+	store.setFailGet(true)
+
+	got, err := cache.GetMany(ctx, []string{"hit", "a", "b"})
+	if err == nil {
+		t.Error("GetMany should report store errors")
+	}
+	if got["hit"] != 1 || len(got) != 1 {
+		t.Errorf("GetMany = %v; want the memory hit despite the error", got)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	got, err = cache.GetMany(canceled, []string{"hit", "a"})
+	if err == nil {
+		t.Error("GetMany should fail on a canceled context with store misses")
+	}
+	if got["hit"] != 1 {
+		t.Errorf("GetMany = %v; want the memory hit", got)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name  string
+		store Store[string, int]
+		want  CapabilitySet
+		str   string
+	}{
+		{"plain", newMockStore[string, int](), 0, "none"},
+		{"health", &pingMockStore{mockStore: newMockStore[string, int]()}, CapHealthCheck, "HealthCheck"},
+		{"recent", &recentMockStore[int]{mockStore: newMockStore[string, int]()}, CapRecentLoad, "RecentLoad"},
+		{"recent+frequency", &freqMockStore{recentMockStore: &recentMockStore[int]{mockStore: newMockStore[string, int]()}},
+			CapRecentLoad | CapFrequencyLoad, "RecentLoad|FrequencyLoad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Capabilities(tt.store)
+			if got != tt.want {
+				t.Errorf("Capabilities = %v; want %v", got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %q; want %q", got.String(), tt.str)
+			}
+		})
+	}
+
+	all := CapPrefixScan | CapHealthCheck | CapRecentLoad
+	if !all.Has(CapHealthCheck | CapRecentLoad) {
+		t.Error("Has should accept a subset")
+	}
+	if CapHealthCheck.Has(CapHealthCheck | CapRecentLoad) {
+		t.Error("Has should require every requested capability")
+	}
+	if all.String() != "PrefixScan|HealthCheck|RecentLoad" {
+		t.Errorf("String() = %q", all.String())
+	}
+}
+
+// gatedSetMockStore blocks Set until gate is closed.
+type gatedSetMockStore struct {
+	*mockStore[string, int]
+	gate chan struct{}
+}
+
+func (m *gatedSetMockStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	<-m.gate
+	return m.mockStore.Set(ctx, key, value, expiry)
+}
+
+func TestTieredCache_PendingWrites(t *testing.T) {
+	store := &gatedSetMockStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	for i := range 3 {
+		if err := cache.SetAsync(ctx, fmt.Sprintf("k%d", i), i); err != nil {
+			t.Fatalf("SetAsync: %v", err)
+		}
+	}
+	if got := cache.PendingWrites(); got != 3 {
+		t.Errorf("PendingWrites() = %d; want 3 while the store is blocked", got)
+	}
+
+	close(store.gate)
+	deadline := time.Now().Add(time.Second)
+	for cache.PendingWrites() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("PendingWrites() = %d; want 0 after the store unblocks", cache.PendingWrites())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTieredCache_GetFromStore(t *testing.T) {
+	ctx := context.Background()
+	store := &validatingMockStore[string, int]{mockStore: newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	// Memory and store disagree: GetFromStore must show the store's side.
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := store.Set(ctx, "k", 1, exp); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	cache.memory.set("k", 2, 0)
+
+	v, gotExp, found, err := cache.GetFromStore(ctx, "k")
+	if err != nil || !found || v != 1 || !gotExp.Equal(exp) {
+		t.Errorf("GetFromStore = %d, %v, %v, %v; want 1, %v, true, nil", v, gotExp, found, err, exp)
+	}
+
+	// Store-only keys are not copied into memory.
+	if err := store.Set(ctx, "store-only", 3, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	if _, _, found, _ := cache.GetFromStore(ctx, "store-only"); !found {
+		t.Error("GetFromStore should find store-only key")
+	}
+	if _, ok := cache.memory.get("store-only"); ok {
+		t.Error("GetFromStore must not populate memory")
+	}
+
+	if _, _, _, err := cache.GetFromStore(ctx, "bad/key"); err == nil {
+		t.Error("GetFromStore should reject keys the store rejects")
+	}
+}
+
+func TestTieredCache_GetFromStore_DecodeError(t *testing.T) {
+	store := &corruptMockStore[string, int]{mockStore: newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	if _, _, _, err := cache.GetFromStore(context.Background(), "k"); !isDecodeError(err) {
+		t.Errorf("GetFromStore error = %v; want the decode error", err)
+	}
+	if store.deletes.Load() != 0 {
+		t.Error("GetFromStore must not delete undecodable entries")
+	}
+}
+
+func TestTieredCache_StrictWriteThrough(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, StrictWriteThrough())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "k", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	store.setFailSet(true)
+	if err := cache.Set(ctx, "k", 2); err == nil {
+		t.Error("Set should return the store error")
+	}
+	if v, _ := cache.memory.get("k"); v != 1 {
+		t.Errorf("memory[k] = %d; want 1 (unchanged after failed store write)", v)
+	}
+	if err := cache.Set(ctx, "new", 3); err == nil {
+		t.Error("Set should return the store error")
+	}
+	if _, ok := cache.memory.get("new"); ok {
+		t.Error("memory must not hold a value the store rejected")
+	}
+
+	v, err := cache.Fetch(ctx, "fetched", func(context.Context) (int, error) { return 4, nil })
+	if err != nil || v != 4 {
+		t.Errorf("Fetch = %d, %v; want 4, nil", v, err)
+	}
+	if _, ok := cache.memory.get("fetched"); ok {
+		t.Error("Fetch must not cache a value the store rejected")
+	}
+
+	if err := cache.SetAsync(ctx, "async", 5); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	for cache.PendingWrites() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := cache.memory.get("async"); ok {
+		t.Error("SetAsync must not cache a value the store rejected")
+	}
+
+	store.setFailSet(false)
+	if err := cache.SetAsync(ctx, "async", 6); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	for cache.PendingWrites() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if v, _ := cache.memory.get("async"); v != 6 {
+		t.Errorf("memory[async] = %d; want 6 once persisted", v)
+	}
+}
+
+// pausingSetMockStore holds Set calls for value 1 after the write lands, until gate
+// is closed.
+type pausingSetMockStore struct {
+	*mockStore[string, int]
+	gate chan struct{}
+}
+
+func (m *pausingSetMockStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	err := m.mockStore.Set(ctx, key, value, expiry)
+	if value == 1 {
+		<-m.gate
+	}
+	return err
+}
+
+func TestTieredCache_StrictWriteThrough_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	store := &pausingSetMockStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store, StrictWriteThrough())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		cache.Set(ctx, "k", 1) //nolint:errcheck // Test helper
+	})
+	for {
+		if v, _, _, _ := store.Get(ctx, "k"); v == 1 { //nolint:errcheck // Test helper
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The second write reaches the store last, so memory must end up with it.
+	wg.Go(func() {
+		cache.Set(ctx, "k", 2) //nolint:errcheck // Test helper
+	})
+	time.Sleep(10 * time.Millisecond)
+	close(store.gate)
+	wg.Wait()
+
+	stored, _, _, _ := store.Get(ctx, "k") //nolint:errcheck // Test helper
+	if v, ok := cache.memory.get("k"); !ok || v != stored {
+		t.Errorf("memory[k] = %d, %v; want %d, the last value stored", v, ok, stored)
+	}
+}
+
+func TestTieredCache_StoreReadTimeout(t *testing.T) {
+	ctx := context.Background()
+	store := &slowMockStore{mockStore: newMockStore[string, int](), delay: 100 * time.Millisecond}
+	if err := store.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	cache, err := NewTiered[string, int](store, StoreReadTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	start := time.Now()
+	_, found, err := cache.Get(ctx, "k")
+	if err != nil || found {
+		t.Errorf("Get = found %v, err %v; want a miss after the read timeout", found, err)
+	}
+	if d := time.Since(start); d > 80*time.Millisecond {
+		t.Errorf("Get took %v; want about the 10ms read timeout", d)
+	}
+
+	// The abandoned read finishes in the background and fills memory.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if v, ok := cache.memory.get("k"); ok {
+			if v != 1 {
+				t.Errorf("backfilled value = %d; want 1", v)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background read never filled memory")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Fast reads are unaffected.
+	store.delay = 0
+	if err := store.Set(ctx, "fast", 2, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	if v, found, err := cache.Get(ctx, "fast"); err != nil || !found || v != 2 {
+		t.Errorf("Get(fast) = %d, %v, %v; want 2, true, nil", v, found, err)
+	}
+}
+
+func TestTieredCache_StoreReadTimeout_Coalesced(t *testing.T) {
+	ctx := context.Background()
+	store := &slowMockStore{mockStore: newMockStore[string, int](), delay: 50 * time.Millisecond}
+	if err := store.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	cache, err := NewTiered[string, int](store, StoreReadTimeout(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			cache.Get(ctx, "k") //nolint:errcheck // Test helper
+		})
+	}
+	wg.Wait()
+	if p := store.peak.Load(); p != 1 {
+		t.Errorf("peak concurrent store reads = %d; want 1 for one key", p)
+	}
+}
+
+func TestTieredCache_StoreReadTimeout_StaleFill(t *testing.T) {
+	ctx := context.Background()
+	store := &slowMockStore{mockStore: newMockStore[string, int](), delay: 50 * time.Millisecond}
+	for _, key := range []string{"deleted", "set"} {
+		if err := store.Set(ctx, key, 1, time.Time{}); err != nil {
+			t.Fatalf("store.Set: %v", err)
+		}
+	}
+	cache, err := NewTiered[string, int](store, StoreReadTimeout(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	for _, key := range []string{"deleted", "set"} {
+		if _, found, err := cache.Get(ctx, key); err != nil || found {
+			t.Fatalf("Get(%s) = found %v, err %v; want a miss after the read timeout", key, found, err)
+		}
+	}
+	if err := cache.Delete(ctx, "deleted"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := cache.Set(ctx, "set", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	cache.memory.del("set") // as if evicted before the late read lands
+
+	deadline := time.Now().Add(2 * time.Second)
+	for cache.reads.Size() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("background reads never finished")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for _, key := range []string{"deleted", "set"} {
+		if v, ok := cache.memory.get(key); ok {
+			t.Errorf("memory[%s] = %d; want no fill from a read that began before the write", key, v)
+		}
+	}
+}
+
+func TestTieredCache_PersistMinTTL(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, PersistMinTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetTTL(ctx, "short", 1, time.Second); err != nil {
+		t.Fatalf("SetTTL: %v", err)
+	}
+	if err := cache.SetAsyncTTL(ctx, "short-async", 2, time.Second); err != nil {
+		t.Fatalf("SetAsyncTTL: %v", err)
+	}
+	if _, err := cache.FetchTTL(ctx, "short-fetch", time.Second, func(context.Context) (int, error) { return 3, nil }); err != nil {
+		t.Fatalf("FetchTTL: %v", err)
+	}
+	if err := cache.SetTTL(ctx, "long", 4, time.Hour); err != nil {
+		t.Fatalf("SetTTL: %v", err)
+	}
+	if err := cache.Set(ctx, "forever", 5); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	for _, key := range []string{"short", "short-async", "short-fetch"} {
+		if _, ok := cache.memory.get(key); !ok {
+			t.Errorf("memory[%s] missing; short-lived entries stay in memory", key)
+		}
+		if _, _, found, _ := store.Get(ctx, key); found { //nolint:errcheck // Test helper
+			t.Errorf("store[%s] written; want entries under PersistMinTTL kept out of the store", key)
+		}
+	}
+	for _, key := range []string{"long", "forever"} {
+		if _, _, found, _ := store.Get(ctx, key); !found { //nolint:errcheck // Test helper
+			t.Errorf("store[%s] missing; want entries at or above PersistMinTTL persisted", key)
+		}
+	}
+}
+
+func TestTieredCache_PersistMinTTL_Overwrite(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, PersistMinTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	for _, key := range []string{"sync", "async"} {
+		if err := cache.SetTTL(ctx, key, 1, time.Hour); err != nil {
+			t.Fatalf("SetTTL: %v", err)
+		}
+	}
+	if err := cache.SetTTL(ctx, "sync", 2, time.Second); err != nil {
+		t.Fatalf("SetTTL: %v", err)
+	}
+	if err := cache.SetAsyncTTL(ctx, "async", 2, time.Second); err != nil {
+		t.Fatalf("SetAsyncTTL: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for cache.PendingWrites() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("PendingWrites() = %d; want 0", cache.PendingWrites())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, key := range []string{"sync", "async"} {
+		if _, _, found, _ := store.Get(ctx, key); found { //nolint:errcheck // Test helper
+			t.Errorf("store[%s] still holds the old value; want it deleted by the memory-only write", key)
+		}
+		cache.memory.del(key)
+		if v, ok, err := cache.Get(ctx, key); err != nil || ok {
+			t.Errorf("Get(%s) = %d, %v, %v; want miss once the memory-only entry is gone", key, v, ok, err)
+		}
+	}
+}
+
+func TestLoadingCache(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	var calls atomic.Int32
+	cache, err := NewLoadingCache[string, int](store, func(_ context.Context, key string) (int, error) {
+		calls.Add(1)
+		if key == "bad" {
+			return 0, errors.New("load failed")
+		}
+		return len(key), nil
+	})
+	if err != nil {
+		t.Fatalf("NewLoadingCache: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	for range 3 {
+		if v, err := cache.Get(ctx, "abc"); err != nil || v != 3 {
+			t.Fatalf("Get(abc) = %d, %v; want 3, nil", v, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("loader called %d times; want 1", n)
+	}
+	if _, _, found, _ := store.Get(ctx, "abc"); !found { //nolint:errcheck // Test helper
+		t.Error("loaded value was not written to the store")
+	}
+
+	if _, err := cache.Get(ctx, "bad"); err == nil {
+		t.Error("Get(bad) should return the loader error")
+	}
+	if _, ok := cache.memory.get("bad"); ok {
+		t.Error("failed load must not be cached")
+	}
+
+	if _, err := NewLoadingCache[string, int](store, nil); err == nil {
+		t.Error("NewLoadingCache with nil loader should fail")
+	}
+}
+
+func TestTieredCache_Stats_StoreHits(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, HitStats())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	if err := store.Set(ctx, "stored", 1, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	cache.Get(ctx, "stored")  //nolint:errcheck // Test helper
+	cache.Get(ctx, "stored")  //nolint:errcheck // Test helper
+	cache.Get(ctx, "missing") //nolint:errcheck // Test helper
+
+	st := cache.Stats()
+	if st.Hits != 1 || st.Misses != 2 {
+		t.Errorf("Hits, Misses = %d, %d; want 1, 2", st.Hits, st.Misses)
+	}
+	if st.StoreHits != 1 || st.StoreMisses != 1 {
+		t.Errorf("StoreHits, StoreMisses = %d, %d; want 1, 1", st.StoreHits, st.StoreMisses)
+	}
+}
+
+func TestTieredCache_WriteBack(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, WriteBack(time.Hour, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	for i := range 3 {
+		if err := cache.Set(ctx, fmt.Sprintf("k%d", i), i); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := cache.Set(ctx, "k0", 10); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.Delete(ctx, "k2"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n, _ := store.Len(ctx); n != 0 {
+		t.Errorf("store.Len() = %d; want 0 before the first flush", n)
+	}
+	if got := cache.PendingWrites(); got != 3 {
+		t.Errorf("PendingWrites() = %d; want 3 coalesced keys", got)
+	}
+
+	// A key evicted from memory before its flush is still read from the buffer.
+	cache.memory.del("k0")
+	if v, ok, err := cache.Get(ctx, "k0"); err != nil || !ok || v != 10 {
+		t.Errorf("Get(k0) = %d, %v, %v; want 10, true, nil from the write buffer", v, ok, err)
+	}
+	if _, ok, _ := cache.Get(ctx, "k2"); ok {
+		t.Error("Get(k2) found; a buffered delete should hide the key")
+	}
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for k, want := range map[string]int{"k0": 10, "k1": 1} {
+		if v, _, ok, _ := store.Get(ctx, k); !ok || v != want { //nolint:errcheck // Test helper
+			t.Errorf("store.Get(%s) = %d, %v after Close; want %d, true", k, v, ok, want)
+		}
+	}
+	if _, _, ok, _ := store.Get(ctx, "k2"); ok { //nolint:errcheck // Test helper
+		t.Error("store still has k2; the buffered delete should be flushed on Close")
+	}
+}
+
+func TestTieredCache_WriteBack_ExpiredBeforeFlush(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	store.Set(ctx, "k", 1, time.Now().Add(time.Hour)) //nolint:errcheck // Test helper
+	cache, err := NewTiered[string, int](store, WriteBack(time.Hour, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	if err := cache.SetTTL(ctx, "k", 2, 20*time.Millisecond); err != nil {
+		t.Fatalf("SetTTL: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if v, _, ok, _ := store.Get(ctx, "k"); ok { //nolint:errcheck // Test helper
+		t.Errorf("store.Get(k) = %d after flushing an expired write; want the old value deleted", v)
+	}
+}
+
+func TestTieredCache_WriteBack_BatchAndRetry(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, WriteBack(20*time.Millisecond, 3))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	waitPending := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for cache.PendingWrites() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("PendingWrites() = %d; want %d", cache.PendingWrites(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	store.setFailSet(true)
+	if err := cache.SetAsync(ctx, "a", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond) // a few failed flushes
+	if got := cache.PendingWrites(); got != 1 {
+		t.Errorf("PendingWrites() = %d; want 1 while the store fails", got)
+	}
+
+	store.setFailSet(false)
+	waitPending(0)
+	if v, _, ok, _ := store.Get(ctx, "a"); !ok || v != 1 { //nolint:errcheck // Test helper
+		t.Errorf("store.Get(a) = %d, %v; want the retried write", v, ok)
+	}
+
+	// Flush clears buffered writes along with the store.
+	if err := cache.Set(ctx, "b", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := cache.PendingWrites(); got != 0 {
+		t.Errorf("PendingWrites() = %d after Flush; want 0", got)
+	}
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("Get(b) found after Flush")
+	}
+}
+
+func TestTieredCache_WriteBack_MaxBatch(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, WriteBack(time.Hour, 3))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	for i := range 3 {
+		if err := cache.Set(ctx, fmt.Sprintf("k%d", i), i); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if n, _ := store.Len(ctx); n == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("a full batch should be flushed without waiting for the interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTieredCache_PersistenceOnly(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, Size(0), HitStats())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "a", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.SetAsync(ctx, "b", 2); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d; want 0 with the memory tier disabled", cache.Len())
+	}
+
+	for range 2 {
+		if v, ok, err := cache.Get(ctx, "a"); err != nil || !ok || v != 1 {
+			t.Errorf("Get(a) = %d, %v, %v; want 1, true, nil", v, ok, err)
+		}
+	}
+	if st := cache.Stats(); st.Hits != 0 || st.StoreHits != 2 {
+		t.Errorf("Stats() Hits = %d, StoreHits = %d; want every Get served by the store", st.Hits, st.StoreHits)
+	}
+
+	// Nothing is kept in memory, so once the store loses a key Fetch loads it again.
+	calls := 0
+	loader := func(context.Context) (int, error) { calls++; return 3, nil }
+	for range 2 {
+		if _, err := cache.Fetch(ctx, "c", loader); err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		if err := store.Delete(ctx, "c"); err != nil {
+			t.Fatalf("store.Delete: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("loader calls = %d; want 2 with nothing held in memory", calls)
+	}
+}
+
+func TestTieredCache_CleanupInterval(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	if err := store.Set(ctx, "expired", 1, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	if err := store.Set(ctx, "live", 2, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+
+	cache, err := NewTiered[string, int](store, CleanupInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	cache.memory.set("mem-expired", 3, timeToSec(time.Now().Add(-time.Second)))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n, _ := store.Len(ctx) //nolint:errcheck // Test helper
+		if n == 1 && cache.memory.len() == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background cleanup did not run: store has %d entries, memory %d", n, cache.memory.len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// No cleanup runs after Close.
+	if err := store.Set(ctx, "expired", 1, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n, _ := store.Len(ctx); n != 2 { //nolint:errcheck // Test helper
+		t.Errorf("store has %d entries after Close; want 2 (cleanup should have stopped)", n)
+	}
+}
+
+func TestTieredCache_TTLJitter(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, TTLJitter(0.5), TTL(time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // Test cleanup
+	cache.memory.jitterRnd = func() float64 { return 0 }
+
+	if err := cache.Set(context.Background(), "k", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_, expiry, found, err := store.Get(context.Background(), "k")
+	if err != nil || !found {
+		t.Fatalf("store.Get: found=%v err=%v", found, err)
+	}
+	if r := time.Until(expiry); r < 29*time.Minute || r > 30*time.Minute {
+		t.Errorf("store expiry in %v; want about 30m (jitter applies to the store too)", r)
+	}
+}
+
+// deadlineMockStore reports the time left on each Set's context deadline.
+type deadlineMockStore struct {
+	*mockStore[string, int]
+	left chan time.Duration
+}
+
+func (m *deadlineMockStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	d, _ := ctx.Deadline()
+	m.left <- time.Until(d)
+	return m.mockStore.Set(ctx, key, value, expiry)
+}
+
+func TestTieredCache_AsyncTimeout(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want time.Duration
+	}{
+		{"default", nil, defaultAsyncTimeout},
+		{"custom", []Option{AsyncTimeout(time.Minute)}, time.Minute},
+		{"ignored", []Option{AsyncTimeout(-time.Second)}, defaultAsyncTimeout},
+		{"write-back", []Option{AsyncTimeout(time.Minute), WriteBack(time.Hour, 0)}, time.Minute},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &deadlineMockStore{mockStore: newMockStore[string, int](), left: make(chan time.Duration, 1)}
+			cache, err := NewTiered[string, int](store, tc.opts...)
+			if err != nil {
+				t.Fatalf("NewTiered: %v", err)
+			}
+			if err := cache.SetAsync(ctx, "k", 1); err != nil {
+				t.Fatalf("SetAsync: %v", err)
+			}
+			if err := cache.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if left := <-store.left; left > tc.want || left < tc.want-time.Second {
+				t.Errorf("store write deadline in %v; want ~%v", left, tc.want)
+			}
+		})
+	}
+}
+
+// gatedMockStore blocks each Set until gate is closed.
+type gatedMockStore struct {
+	*mockStore[string, int]
+	gate chan struct{}
+}
+
+func (m *gatedMockStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	<-m.gate
+	return m.mockStore.Set(ctx, key, value, expiry)
+}
+
+func TestTieredCache_AsyncWorkers(t *testing.T) {
+	ctx := context.Background()
+	store := &gatedMockStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store, AsyncWorkers(1, 1), AsyncDropWhenFull())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	// One write held by the worker, one queued, the rest dropped.
+	for i := range 5 {
+		if err := cache.SetAsync(ctx, fmt.Sprint(i), i); err != nil {
+			t.Fatalf("SetAsync: %v", err)
+		}
+		if i == 0 {
+			for len(cache.async.queue) != 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+	if n := cache.PendingWrites(); n != 2 {
+		t.Errorf("PendingWrites() = %d; want 2 (one writing, one queued)", n)
+	}
+	if v, ok := cache.memory.get("4"); !ok || v != 4 {
+		t.Errorf("memory[4] = %d, %v; want 4, true (only the store write is dropped)", v, ok)
+	}
+	if n := cache.Stats().AsyncDropped; n != 3 {
+		t.Errorf("Stats().AsyncDropped = %d; want 3", n)
+	}
+
+	close(store.gate)
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if n := cache.PendingWrites(); n != 0 {
+		t.Errorf("PendingWrites() after Close = %d; want 0", n)
+	}
+	for i := range 5 {
+		_, _, found, _ := store.mockStore.Get(ctx, fmt.Sprint(i)) //nolint:errcheck // Test helper
+		if want := i < 2; found != want {
+			t.Errorf("store has %d = %v; want %v", i, found, want)
+		}
+	}
+	if err := cache.SetAsync(ctx, "late", 1); err != nil {
+		t.Errorf("SetAsync after Close = %v; want nil (store write dropped)", err)
+	}
+	if n := cache.Stats().AsyncDropped; n != 4 {
+		t.Errorf("Stats().AsyncDropped after Close = %d; want 4", n)
+	}
+}
+
+func TestTieredCache_AsyncWorkers_WaitForRoom(t *testing.T) {
+	store := &gatedMockStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store, AsyncWorkers(1, 1), AsyncTimeout(time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close()     //nolint:errcheck // Test cleanup
+	defer close(store.gate) // runs before Close
+
+	ctx := context.Background()
+	cache.SetAsync(ctx, "a", 1) //nolint:errcheck // Test helper
+	for len(cache.async.queue) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cache.SetAsync(ctx, "b", 2) //nolint:errcheck // Test helper
+
+	// The queue is full: SetAsync waits until its context is done.
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := cache.SetAsync(cctx, "c", 3); err != nil {
+		t.Errorf("SetAsync = %v; want nil", err)
+	}
+	if waited := time.Since(start); waited < 15*time.Millisecond {
+		t.Errorf("SetAsync returned after %v; want it to wait for room", waited)
+	}
+	if n := cache.PendingWrites(); n != 2 {
+		t.Errorf("PendingWrites() = %d; want 2", n)
+	}
+
+	// Without a deadline it waits asyncEnqueueWait, not AsyncTimeout.
+	start = time.Now()
+	if err := cache.SetAsync(ctx, "d", 4); err != nil {
+		t.Errorf("SetAsync = %v; want nil", err)
+	}
+	if waited := time.Since(start); waited < asyncEnqueueWait*3/4 || waited > 10*asyncEnqueueWait {
+		t.Errorf("SetAsync returned after %v; want about %v", waited, asyncEnqueueWait)
+	}
+	if n := cache.PendingWrites(); n != 2 {
+		t.Errorf("PendingWrites() = %d; want 2 (d dropped)", n)
 	}
 }
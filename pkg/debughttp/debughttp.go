@@ -0,0 +1,115 @@
+// Package debughttp serves read-only fido cache internals as JSON for operational debugging.
+//
+// Mount it under a path of your choice:
+//
+//	http.Handle("/debug/cache/", http.StripPrefix("/debug/cache", debughttp.Handler(cache)))
+//
+// GET / returns Stats. GET /keys returns a page of keys, sorted by their string form;
+// use the offset and limit query parameters to paginate (default limit 100, max 1000).
+// Values are never exposed, and the handler never mutates the cache.
+package debughttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codeGROOVE-dev/fido"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Cache is the read-only subset of fido.Cache and fido.TieredCache used by Handler.
+type Cache[K comparable, V any] interface {
+	Stats() fido.Stats
+	Range() iter.Seq2[K, V]
+}
+
+// KeyPage is the response body for /keys.
+type KeyPage struct {
+	Keys   []string `json:"keys"`
+	Total  int      `json:"total"`
+	Offset int      `json:"offset"`
+	Limit  int      `json:"limit"`
+}
+
+// Handler returns an http.Handler exposing c's stats and keys.
+func Handler[K comparable, V any](c Cache[K, V]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch strings.TrimSuffix(r.URL.Path, "/") {
+		case "":
+			writeJSON(w, c.Stats())
+		case "/keys":
+			page, err := keyPage(c, r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, page)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func keyPage[K comparable, V any](c Cache[K, V], r *http.Request) (KeyPage, error) {
+	q := r.URL.Query()
+	offset, err := intParam(q.Get("offset"), 0)
+	if err != nil {
+		return KeyPage{}, fmt.Errorf("offset: %w", err)
+	}
+	limit, err := intParam(q.Get("limit"), defaultLimit)
+	if err != nil {
+		return KeyPage{}, fmt.Errorf("limit: %w", err)
+	}
+	limit = min(limit, maxLimit)
+
+	// Range order is undefined, so sort for stable pagination.
+	var keys []string
+	for k := range c.Range() {
+		keys = append(keys, fmt.Sprint(k))
+	}
+	slices.Sort(keys)
+
+	start := min(offset, len(keys))
+	end := min(start+limit, len(keys))
+	page := keys[start:end]
+	if page == nil {
+		page = []string{}
+	}
+	return KeyPage{Keys: page, Total: len(keys), Offset: offset, Limit: limit}, nil
+}
+
+func intParam(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("must not be negative: %d", n)
+	}
+	return n, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
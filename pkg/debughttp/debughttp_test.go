@@ -0,0 +1,119 @@
+package debughttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+)
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, http.NoBody)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_Stats(t *testing.T) {
+	cache := fido.New[string, int]()
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	rec := get(t, Handler(cache), "/")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+	var st fido.Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st.Len != 2 {
+		t.Errorf("Len = %d; want 2", st.Len)
+	}
+	if !strings.Contains(rec.Body.String(), `"AsyncDropped":0`) {
+		t.Errorf("body = %s; want every Stats counter, including AsyncDropped", rec.Body)
+	}
+}
+
+func TestHandler_Keys(t *testing.T) {
+	cache := fido.New[int, string]()
+	for i := range 25 {
+		cache.Set(i, fmt.Sprintf("secret-%d", i))
+	}
+	h := Handler(cache)
+
+	var all []string
+	for offset := 0; offset < 25; offset += 10 {
+		rec := get(t, h, fmt.Sprintf("/keys?offset=%d&limit=10", offset))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d; want 200", rec.Code)
+		}
+		var page KeyPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if page.Total != 25 {
+			t.Errorf("Total = %d; want 25", page.Total)
+		}
+		all = append(all, page.Keys...)
+	}
+	if len(all) != 25 || !slices.IsSorted(all) || len(slices.Compact(slices.Clone(all))) != 25 {
+		t.Errorf("paged keys = %v; want 25 distinct sorted keys", all)
+	}
+
+	rec := get(t, h, "/keys?offset=100")
+	var page KeyPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if page.Keys == nil || len(page.Keys) != 0 {
+		t.Errorf("Keys past the end = %v; want empty list", page.Keys)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	h := Handler(fido.New[string, int]())
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/keys?limit=-1", http.StatusBadRequest},
+		{http.MethodGet, "/keys?offset=x", http.StatusBadRequest},
+		{http.MethodGet, "/nope", http.StatusNotFound},
+		{http.MethodPost, "/", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequestWithContext(context.Background(), tt.method, tt.path, http.NoBody)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d; want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestHandler_ReadOnlyView(t *testing.T) {
+	cache := fido.New[string, int](fido.TTL(time.Hour))
+	cache.Set("k", 1)
+	rec := get(t, Handler(cache.ReadOnly()), "/keys")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+	var page KeyPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !slices.Equal(page.Keys, []string{"k"}) {
+		t.Errorf("Keys = %v; want [k]", page.Keys)
+	}
+}
@@ -0,0 +1,350 @@
+// Package blob provides object-store persistence for fido over gocloud.dev/blob,
+// so one implementation covers S3, GCS, Azure Blob Storage and the other gocloud drivers.
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+const (
+	maxKeyLength = 1024 // keeps the encoded key well under provider metadata limits (2 KB on S3)
+
+	// Object metadata keys. Keys are lowercase: some providers don't preserve case.
+	metaExpiry = "fido-expiry" // Unix nanoseconds; absent for entries that never expire
+	metaKey    = "fido-key"    // base64url JSON of the cache key, for LoadRecent
+)
+
+// Store implements persistence in a cloud object store bucket.
+//
+// Every operation is at least one network round trip: Get costs two (attributes, then
+// body) and Cleanup, Len, Flush and LoadRecent list the whole cacheID prefix. Expect
+// tens of milliseconds per call, so pair this store with a memory Size large enough
+// to hold the working set.
+type Store[K comparable, V any] struct {
+	bucket     *blob.Bucket
+	compressor compress.Compressor
+	prefix     string // cacheID + "/"
+	ext        string
+}
+
+// New creates an object-store persistence layer in bucket, as opened by blob.OpenBucket
+// (e.g. "s3://my-bucket?region=us-east-1" or "gs://my-bucket" with the matching driver
+// imported). Objects are written under cacheID + "/". The caller owns bucket: Close does
+// not close it.
+// Optional compressor enables compression (default: no compression).
+func New[K comparable, V any](bucket *blob.Bucket, cacheID string, c ...compress.Compressor) (*Store[K, V], error) {
+	if bucket == nil {
+		return nil, errors.New("bucket cannot be nil")
+	}
+	if cacheID == "" {
+		return nil, errors.New("cacheID cannot be empty")
+	}
+	if strings.Contains(cacheID, "..") || strings.Contains(cacheID, "/") || strings.Contains(cacheID, "\\") {
+		return nil, errors.New("invalid cacheID: contains path separators or traversal sequences")
+	}
+
+	comp := compress.None()
+	if len(c) > 0 && c[0] != nil {
+		comp = c[0]
+	}
+	ext := comp.Extension()
+	if ext == "" {
+		ext = ".j"
+	}
+
+	return &Store[K, V]{
+		bucket:     bucket,
+		compressor: comp,
+		prefix:     cacheID + "/",
+		ext:        ext,
+	}, nil
+}
+
+// ValidateKey checks if a key is valid for object-store persistence.
+// Object names are hashed, so any characters are allowed; length is limited because
+// the key is also kept in object metadata.
+func (*Store[K, V]) ValidateKey(key K) error {
+	k := fmt.Sprintf("%v", key)
+	if k == "" {
+		return errors.New("key cannot be empty")
+	}
+	if len(k) > maxKeyLength {
+		return fmt.Errorf("key too long: %d bytes (max %d)", len(k), maxKeyLength)
+	}
+	return nil
+}
+
+// objectName hashes a cache key into an object name, with the first 2 hex characters
+// as a pseudo-directory (e.g. "myapp/a3/a3f2....j").
+func (s *Store[K, V]) objectName(key K) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%v", key))
+	h := hex.EncodeToString(sum[:])
+	return s.prefix + h[:2] + "/" + h + s.ext
+}
+
+// Location returns the object name where a key is stored.
+func (s *Store[K, V]) Location(key K) string {
+	return s.objectName(key)
+}
+
+// parseExpiry returns the expiry recorded in object metadata, or the zero time.
+func parseExpiry(md map[string]string) time.Time {
+	ns, err := strconv.ParseInt(md[metaExpiry], 10, 64)
+	if err != nil || ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// Get retrieves a value, skipping expired entries.
+//
+//nolint:revive,gocritic // function-result-limit, unnamedResult - required by persist.Store interface
+func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	var zero V
+	name := s.objectName(key)
+
+	attrs, err := s.bucket.Attributes(ctx, name)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return zero, time.Time{}, false, nil
+	}
+	if err != nil {
+		return zero, time.Time{}, false, fmt.Errorf("blob attributes: %w", err)
+	}
+	exp := parseExpiry(attrs.Metadata)
+	if !exp.IsZero() && time.Now().After(exp) {
+		return zero, time.Time{}, false, nil
+	}
+
+	v, err := s.read(ctx, name)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return zero, time.Time{}, false, nil // deleted between the two requests
+	}
+	if err != nil {
+		return zero, time.Time{}, false, err
+	}
+	return v, exp, true, nil
+}
+
+// read downloads and decodes the value stored in object name.
+func (s *Store[K, V]) read(ctx context.Context, name string) (V, error) {
+	var v V
+	data, err := s.bucket.ReadAll(ctx, name)
+	if err != nil {
+		return v, fmt.Errorf("blob read: %w", err)
+	}
+	raw, err := s.compressor.Decode(data)
+	if err != nil {
+		return v, &compress.DecodeError{Err: fmt.Errorf("decompress: %w", err)}
+	}
+	if err := compress.UnmarshalValue(raw, &v); err != nil {
+		return v, &compress.DecodeError{Err: fmt.Errorf("unmarshal value: %w", err)}
+	}
+	return v, nil
+}
+
+// Set saves a value as an object, replacing any existing entry for key.
+func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	raw, err := compress.MarshalValue(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	data, err := s.compressor.Encode(raw)
+	if err != nil {
+		return fmt.Errorf("compress: %w", err)
+	}
+	k, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("marshal key: %w", err)
+	}
+
+	md := map[string]string{metaKey: base64.RawURLEncoding.EncodeToString(k)}
+	if !expiry.IsZero() {
+		md[metaExpiry] = strconv.FormatInt(expiry.UnixNano(), 10)
+	}
+	if err := s.bucket.WriteAll(ctx, s.objectName(key), data, &blob.WriterOptions{
+		ContentType: "application/octet-stream",
+		Metadata:    md,
+	}); err != nil {
+		return fmt.Errorf("blob write: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a value.
+func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
+	if err := s.bucket.Delete(ctx, s.objectName(key)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return fmt.Errorf("blob delete: %w", err)
+	}
+	return nil
+}
+
+// list calls fn for every object of this store.
+func (s *Store[K, V]) list(ctx context.Context, fn func(*blob.ListObject) error) error {
+	it := s.bucket.List(&blob.ListOptions{Prefix: s.prefix})
+	for {
+		obj, err := it.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("blob list: %w", err)
+		}
+		if obj.IsDir || !strings.HasSuffix(obj.Key, s.ext) {
+			continue
+		}
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+}
+
+// Cleanup removes entries that expired more than maxAge ago.
+// Lists every object and reads its metadata, so the cost grows with the size of the store.
+// Returns the count of deleted entries and any errors encountered.
+func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	n := 0
+	var errs []error
+	err := s.list(ctx, func(obj *blob.ListObject) error {
+		attrs, err := s.bucket.Attributes(ctx, obj.Key)
+		if err != nil {
+			if gcerrors.Code(err) != gcerrors.NotFound {
+				errs = append(errs, fmt.Errorf("attributes %s: %w", obj.Key, err))
+			}
+			return nil
+		}
+		exp := parseExpiry(attrs.Metadata)
+		if exp.IsZero() || !exp.Before(cutoff) {
+			return nil
+		}
+		if err := s.bucket.Delete(ctx, obj.Key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			errs = append(errs, fmt.Errorf("delete %s: %w", obj.Key, err))
+			return nil
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return n, errors.Join(errs...)
+}
+
+// Flush removes all entries of this store.
+// Returns the number of entries removed and any errors encountered.
+func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
+	n := 0
+	var errs []error
+	err := s.list(ctx, func(obj *blob.ListObject) error {
+		if err := s.bucket.Delete(ctx, obj.Key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			errs = append(errs, fmt.Errorf("delete %s: %w", obj.Key, err))
+			return nil
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return n, errors.Join(errs...)
+}
+
+// Len returns the number of entries of this store, including expired entries
+// not yet removed by Cleanup.
+func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
+	n := 0
+	err := s.list(ctx, func(*blob.ListObject) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// Close releases no resources: the caller owns the bucket passed to New.
+func (*Store[K, V]) Close() error {
+	return nil
+}
+
+// Ping checks that the bucket exists and is accessible. Implements fido.HealthChecker.
+func (s *Store[K, V]) Ping(ctx context.Context) error {
+	ok, err := s.bucket.IsAccessible(ctx)
+	if err != nil {
+		return fmt.Errorf("blob ping: %w", err)
+	}
+	if !ok {
+		return errors.New("blob ping: bucket is not accessible")
+	}
+	return nil
+}
+
+// LoadRecent calls fn for up to limit non-expired entries, most recently modified first.
+// Implements fido.RecentLoader. Every object is listed to sort by modification time,
+// then entries are read one at a time (two requests each) until limit is reached.
+func (s *Store[K, V]) LoadRecent(ctx context.Context, limit int, fn func(key K, value V, expiry time.Time) bool) error {
+	var objs []*blob.ListObject
+	if err := s.list(ctx, func(obj *blob.ListObject) error {
+		objs = append(objs, obj)
+		return nil
+	}); err != nil {
+		return err
+	}
+	slices.SortFunc(objs, func(a, b *blob.ListObject) int { return b.ModTime.Compare(a.ModTime) })
+
+	now := time.Now()
+	n := 0
+	for _, obj := range objs {
+		if limit > 0 && n >= limit {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		attrs, err := s.bucket.Attributes(ctx, obj.Key)
+		if err != nil {
+			continue // deleted since listing, or unreadable
+		}
+		exp := parseExpiry(attrs.Metadata)
+		if !exp.IsZero() && now.After(exp) {
+			continue
+		}
+		var key K
+		if err := decodeKey(attrs.Metadata[metaKey], &key); err != nil {
+			continue
+		}
+		v, err := s.read(ctx, obj.Key)
+		if err != nil {
+			continue
+		}
+
+		n++
+		if !fn(key, v, exp) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// decodeKey reverses the metaKey encoding written by Set.
+func decodeKey[K any](s string, key *K) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, key)
+}
@@ -0,0 +1,168 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob/memblob"
+)
+
+func newTestStore(t *testing.T) *Store[string, int] {
+	t.Helper()
+	b := memblob.OpenBucket(nil)
+	t.Cleanup(func() { b.Close() }) //nolint:errcheck // Test cleanup
+	s, err := New[string, int](b, "test")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+func TestBlob_New_Invalid(t *testing.T) {
+	b := memblob.OpenBucket(nil)
+	defer b.Close() //nolint:errcheck // Test cleanup
+
+	if _, err := New[string, int](nil, "test"); err == nil {
+		t.Error("New() should fail with nil bucket")
+	}
+	for _, id := range []string{"", "a/b", "../x", "a\\b"} {
+		if _, err := New[string, int](b, id); err == nil {
+			t.Errorf("New(%q) should fail", id)
+		}
+	}
+}
+
+func TestBlob_SetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	exp := time.Now().Add(time.Hour)
+	if err := s.Set(ctx, "a", 1, exp); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	v, gotExp, ok, err := s.Get(ctx, "a")
+	if err != nil || !ok || v != 1 || !gotExp.Equal(time.Unix(0, exp.UnixNano())) {
+		t.Errorf("Get(a) = %d, %v, %v, %v; want 1, %v, true, nil", v, gotExp, ok, err, exp)
+	}
+
+	if _, _, ok, err := s.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Get(missing) = _, _, %v, %v; want false, nil", ok, err)
+	}
+
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Errorf("Delete(missing) = %v; want nil", err)
+	}
+	if _, _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Error("Get(a) found after Delete")
+	}
+}
+
+func TestBlob_CleanupFlushLen(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	sets := []struct {
+		key string
+		exp time.Time
+	}{
+		{"old", time.Now().Add(-2 * time.Hour)},
+		{"recent", time.Now().Add(-time.Minute)},
+		{"live", time.Now().Add(time.Hour)},
+		{"forever", time.Time{}},
+	}
+	for i, e := range sets {
+		if err := s.Set(ctx, e.key, i, e.exp); err != nil {
+			t.Fatalf("Set(%s): %v", e.key, err)
+		}
+	}
+
+	if _, _, ok, _ := s.Get(ctx, "recent"); ok {
+		t.Error("Get should skip expired entries")
+	}
+	if n, err := s.Cleanup(ctx, time.Hour); err != nil || n != 1 {
+		t.Errorf("Cleanup(1h) = %d, %v; want 1, nil", n, err)
+	}
+	if n, _ := s.Len(ctx); n != 3 {
+		t.Errorf("Len() = %d; want 3", n)
+	}
+	if n, err := s.Flush(ctx); err != nil || n != 3 {
+		t.Errorf("Flush() = %d, %v; want 3, nil", n, err)
+	}
+	if n, _ := s.Len(ctx); n != 0 {
+		t.Errorf("Len() = %d; want 0 after Flush", n)
+	}
+}
+
+func TestBlob_IsolatedNamespaces(t *testing.T) {
+	ctx := context.Background()
+	b := memblob.OpenBucket(nil)
+	defer b.Close() //nolint:errcheck // Test cleanup
+
+	s1, err := New[string, int](b, "one")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s2, err := New[string, int](b, "two")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s1.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, _, ok, _ := s2.Get(ctx, "k"); ok {
+		t.Error("stores with different cacheIDs should not share entries")
+	}
+	if n, _ := s2.Flush(ctx); n != 0 {
+		t.Errorf("Flush of empty namespace removed %d entries", n)
+	}
+	if n, _ := s1.Len(ctx); n != 1 {
+		t.Errorf("Len() = %d; want 1", n)
+	}
+}
+
+func TestBlob_LoadRecent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	for i := range 5 {
+		if err := s.Set(ctx, fmt.Sprintf("key-%d", i), i, time.Time{}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := s.Set(ctx, "expired", -1, time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got := map[string]int{}
+	if err := s.LoadRecent(ctx, 0, func(k string, v int, _ time.Time) bool {
+		got[k] = v
+		return true
+	}); err != nil {
+		t.Fatalf("LoadRecent: %v", err)
+	}
+	if len(got) != 5 || got["key-3"] != 3 {
+		t.Errorf("LoadRecent(0) = %v; want key-0..key-4 without expired entries", got)
+	}
+
+	n := 0
+	if err := s.LoadRecent(ctx, 2, func(string, int, time.Time) bool {
+		n++
+		return true
+	}); err != nil {
+		t.Fatalf("LoadRecent: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("LoadRecent(2) loaded %d entries; want 2", n)
+	}
+}
+
+func TestBlob_Ping(t *testing.T) {
+	if err := newTestStore(t).Ping(context.Background()); err != nil {
+		t.Errorf("Ping() = %v; want nil", err)
+	}
+}
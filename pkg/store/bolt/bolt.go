@@ -0,0 +1,358 @@
+// Package bolt provides single-file bbolt persistence for fido.
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	bbolt "go.etcd.io/bbolt"
+)
+
+const (
+	maxKeyLength = 32768 // bbolt's MaxKeySize
+	headerLen    = 8     // expiry, Unix nanoseconds, big-endian
+)
+
+var bucket = []byte("entries")
+
+// Store implements persistence in a single bbolt database file.
+//
+// Each value is stored as an 8-byte header holding the expiry (big-endian Unix
+// nanoseconds; 0 never expires) followed by the compressed value, so expiry checks
+// don't decode values.
+type Store[K comparable, V any] struct {
+	db         *bbolt.DB
+	compressor compress.Compressor
+	Path       string // Exported for testing - database file path
+}
+
+// New creates a bbolt-based persistence layer.
+// The database file is named cacheID + ".db" under dir, or under the OS cache directory
+// if dir is empty. bbolt locks the file, so only one Store per file may be open at a time.
+// Optional compressor enables compression (default: no compression).
+func New[K comparable, V any](cacheID, dir string, c ...compress.Compressor) (*Store[K, V], error) {
+	if cacheID == "" {
+		return nil, errors.New("cacheID cannot be empty")
+	}
+	if strings.Contains(cacheID, "..") || strings.Contains(cacheID, "/") || strings.Contains(cacheID, "\\") {
+		return nil, errors.New("invalid cacheID: contains path separators or traversal sequences")
+	}
+	if strings.Contains(cacheID, "\x00") {
+		return nil, errors.New("invalid cacheID: contains null byte")
+	}
+
+	comp := compress.None()
+	if len(c) > 0 && c[0] != nil {
+		comp = c[0]
+	}
+
+	if dir == "" {
+		var err error
+		if dir, err = os.UserCacheDir(); err != nil {
+			return nil, fmt.Errorf("get user cache dir: %w", err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+
+	path := filepath.Join(dir, cacheID+".db")
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt db: %w", err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		_ = db.Close() //nolint:errcheck // already returning an error
+		return nil, fmt.Errorf("create bucket: %w", err)
+	}
+
+	return &Store[K, V]{db: db, compressor: comp, Path: path}, nil
+}
+
+// ValidateKey checks if a key is valid for bbolt persistence.
+func (*Store[K, V]) ValidateKey(key K) error {
+	k := fmt.Sprintf("%v", key)
+	if k == "" {
+		return errors.New("key cannot be empty")
+	}
+	if len(k) > maxKeyLength {
+		return fmt.Errorf("key too long: %d bytes (max %d)", len(k), maxKeyLength)
+	}
+	return nil
+}
+
+// Location returns the database file and key a cache key is stored under.
+func (s *Store[K, V]) Location(key K) string {
+	return fmt.Sprintf("%s:%v", s.Path, key)
+}
+
+// expiryOf returns the expiry recorded in a stored value's header.
+func expiryOf(v []byte) int64 {
+	if len(v) < headerLen {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(v)) //nolint:gosec // written from an int64
+}
+
+// expired reports whether a stored value's expiry is set and before now.
+func expired(v []byte, now int64) bool {
+	exp := expiryOf(v)
+	return exp != 0 && exp <= now
+}
+
+// Get retrieves a value, skipping expired entries.
+//
+//nolint:revive,gocritic // function-result-limit, unnamedResult - required by persist.Store interface
+func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	var zero V
+	if err := ctx.Err(); err != nil {
+		return zero, time.Time{}, false, err
+	}
+
+	var data []byte
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		// Copy: bbolt values are only valid for the life of the transaction.
+		data = bytes.Clone(tx.Bucket(bucket).Get(fmt.Appendf(nil, "%v", key)))
+		return nil
+	}); err != nil {
+		return zero, time.Time{}, false, fmt.Errorf("bolt get: %w", err)
+	}
+	if data == nil || expired(data, time.Now().UnixNano()) {
+		return zero, time.Time{}, false, nil
+	}
+
+	v, err := s.decode(data)
+	if err != nil {
+		return zero, time.Time{}, false, err
+	}
+	var expiry time.Time
+	if exp := expiryOf(data); exp != 0 {
+		expiry = time.Unix(0, exp)
+	}
+	return v, expiry, true, nil
+}
+
+// decode decompresses and unmarshals a stored value, header included.
+func (s *Store[K, V]) decode(data []byte) (V, error) {
+	var v V
+	if len(data) < headerLen {
+		return v, &compress.DecodeError{Err: errors.New("truncated entry header")}
+	}
+	raw, err := s.compressor.Decode(data[headerLen:])
+	if err != nil {
+		return v, &compress.DecodeError{Err: fmt.Errorf("decompress: %w", err)}
+	}
+	if err := compress.UnmarshalValue(raw, &v); err != nil {
+		return v, &compress.DecodeError{Err: fmt.Errorf("unmarshal value: %w", err)}
+	}
+	return v, nil
+}
+
+// Set saves a value, replacing any existing entry for key.
+func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	raw, err := compress.MarshalValue(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	data, err := s.compressor.Encode(raw)
+	if err != nil {
+		return fmt.Errorf("compress: %w", err)
+	}
+
+	var exp int64
+	if !expiry.IsZero() {
+		exp = expiry.UnixNano()
+	}
+	buf := make([]byte, headerLen, headerLen+len(data))
+	binary.BigEndian.PutUint64(buf, uint64(exp)) //nolint:gosec // read back as int64
+	buf = append(buf, data...)
+
+	if err := s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Put(fmt.Appendf(nil, "%v", key), buf)
+	}); err != nil {
+		return fmt.Errorf("bolt set: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a value.
+func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Delete(fmt.Appendf(nil, "%v", key))
+	}); err != nil {
+		return fmt.Errorf("bolt delete: %w", err)
+	}
+	return nil
+}
+
+// Cleanup removes entries that expired more than maxAge ago, in a single write transaction.
+// Only entry headers are read. Returns the count of deleted entries.
+func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge).UnixNano()
+	n := 0
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		// Collect first: deleting under a cursor can make it skip the next key.
+		var dead [][]byte
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if expired(v, cutoff) {
+				dead = append(dead, bytes.Clone(k))
+			}
+		}
+		for _, k := range dead {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(dead)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("bolt cleanup: %w", err)
+	}
+	return n, nil
+}
+
+// Flush removes all entries from the database.
+// Returns the number of entries removed.
+func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	n := 0
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		n = tx.Bucket(bucket).Stats().KeyN
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(bucket)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("bolt flush: %w", err)
+	}
+	return n, nil
+}
+
+// Len returns the number of entries in the database, including expired entries
+// not yet removed by Cleanup.
+func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	n := 0
+	if err := s.db.View(func(tx *bbolt.Tx) error {
+		n = tx.Bucket(bucket).Stats().KeyN
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("bolt len: %w", err)
+	}
+	return n, nil
+}
+
+// Close closes the database file.
+func (s *Store[K, V]) Close() error {
+	return s.db.Close()
+}
+
+// Keys returns an iterator over non-expired keys matching prefix, in key order.
+// Implements PrefixScanner[V] interface (only usable when K is string).
+// Seeks a cursor to prefix and reads only entry headers, so values are not decoded.
+func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for k := range s.scan(ctx, prefix, false) {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over non-expired key-value pairs matching prefix, in key order.
+// Implements PrefixScanner[V] interface (only usable when K is string).
+// Entries that fail to decode are skipped.
+func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string, V] {
+	return s.scan(ctx, prefix, true)
+}
+
+// scanBatch is how many entries scan reads per read transaction.
+const scanBatch = 100
+
+// scan iterates over live entries under prefix, decoding values only if withValues.
+// Entries are read in batches, each in its own read transaction, so no transaction is
+// held while yielding and callers may write to the store mid-iteration.
+func (s *Store[K, V]) scan(ctx context.Context, prefix string, withValues bool) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		type item struct {
+			key string
+			val V
+		}
+		now := time.Now().UnixNano()
+		p := []byte(prefix)
+		var after []byte // last key read by the previous batch
+		for {
+			var batch []item
+			n := 0
+			err := s.db.View(func(tx *bbolt.Tx) error {
+				c := tx.Bucket(bucket).Cursor()
+				seek := p
+				if after != nil {
+					seek = after
+				}
+				k, v := c.Seek(seek)
+				if after != nil && bytes.Equal(k, after) {
+					k, v = c.Next()
+				}
+				for ; k != nil && bytes.HasPrefix(k, p) && n < scanBatch; k, v = c.Next() {
+					n++
+					after = bytes.Clone(k)
+					if expired(v, now) {
+						continue
+					}
+					it := item{key: string(k)}
+					if withValues {
+						var err error
+						if it.val, err = s.decode(v); err != nil {
+							continue
+						}
+					}
+					batch = append(batch, it)
+				}
+				return nil
+			})
+			if err != nil {
+				return
+			}
+			for _, it := range batch {
+				if ctx.Err() != nil || !yield(it.key, it.val) {
+					return
+				}
+			}
+			if n < scanBatch {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store[string, int] {
+	t.Helper()
+	s, err := New[string, int]("test", t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() }) //nolint:errcheck // Test cleanup
+	return s
+}
+
+func TestBolt_New_InvalidCacheID(t *testing.T) {
+	for _, id := range []string{"", "../escape", "a/b", "a\\b", "nul\x00"} {
+		if _, err := New[string, int](id, t.TempDir()); err == nil {
+			t.Errorf("New(%q) should fail", id)
+		}
+	}
+}
+
+func TestBolt_SetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	exp := time.Now().Add(time.Hour).Truncate(time.Nanosecond)
+	if err := s.Set(ctx, "a", 1, exp); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, "b", 2, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	v, gotExp, ok, err := s.Get(ctx, "a")
+	if err != nil || !ok || v != 1 || !gotExp.Equal(exp) {
+		t.Errorf("Get(a) = %d, %v, %v, %v; want 1, %v, true, nil", v, gotExp, ok, err, exp)
+	}
+	if _, gotExp, ok, _ := s.Get(ctx, "b"); !ok || !gotExp.IsZero() {
+		t.Errorf("Get(b) = _, %v, %v; want zero expiry, true", gotExp, ok)
+	}
+
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Error("Get(a) found after Delete")
+	}
+}
+
+func TestBolt_Expiry(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	if err := s.Set(ctx, "old", 1, time.Now().Add(-2*time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, "recent", 2, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, "live", 3, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if _, _, ok, _ := s.Get(ctx, "recent"); ok {
+		t.Error("Get should skip expired entries")
+	}
+	if n, _ := s.Len(ctx); n != 3 {
+		t.Errorf("Len() = %d; want 3 before Cleanup", n)
+	}
+
+	n, err := s.Cleanup(ctx, time.Hour)
+	if err != nil || n != 1 {
+		t.Errorf("Cleanup(1h) = %d, %v; want 1, nil", n, err)
+	}
+	if n, _ := s.Len(ctx); n != 2 {
+		t.Errorf("Len() = %d; want 2 after Cleanup", n)
+	}
+}
+
+func TestBolt_KeysRange(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	// More than one scan batch under the prefix.
+	for i := range 250 {
+		if err := s.Set(ctx, fmt.Sprintf("user:%03d", i), i, time.Time{}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	for _, k := range []string{"order:1", "usex", "user:dead"} {
+		exp := time.Time{}
+		if k == "user:dead" {
+			exp = time.Now().Add(-time.Second)
+		}
+		if err := s.Set(ctx, k, -1, exp); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	keys := slices.Collect(s.Keys(ctx, "user:"))
+	if len(keys) != 250 || keys[0] != "user:000" || keys[249] != "user:249" || !slices.IsSorted(keys) {
+		t.Errorf("Keys(user:) returned %d keys (%v...); want user:000..user:249 in order", len(keys), keys[:min(3, len(keys))])
+	}
+
+	sum := 0
+	for k, v := range s.Range(ctx, "user:1") {
+		if k != fmt.Sprintf("user:%03d", v) {
+			t.Errorf("Range yielded %q = %d", k, v)
+		}
+		sum += v
+	}
+	if want := (100 + 199) * 100 / 2; sum != want {
+		t.Errorf("Range(user:1) value sum = %d; want %d", sum, want)
+	}
+
+	// Writing mid-iteration must not deadlock.
+	for k := range s.Keys(ctx, "user:") {
+		if err := s.Delete(ctx, k); err != nil {
+			t.Fatalf("Delete during Keys: %v", err)
+		}
+	}
+	if n, _ := s.Len(ctx); n != 3 {
+		t.Errorf("Len() = %d; want 3 after deleting user: keys", n)
+	}
+}
+
+func TestBolt_Flush(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	for i := range 5 {
+		if err := s.Set(ctx, fmt.Sprint(i), i, time.Time{}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if n, err := s.Flush(ctx); err != nil || n != 5 {
+		t.Errorf("Flush() = %d, %v; want 5, nil", n, err)
+	}
+	if n, _ := s.Len(ctx); n != 0 {
+		t.Errorf("Len() = %d; want 0 after Flush", n)
+	}
+	if err := s.Set(ctx, "after", 1, time.Time{}); err != nil {
+		t.Errorf("Set after Flush: %v", err)
+	}
+}
+
+func TestBolt_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	s, err := New[string, int]("test", dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Set(ctx, "k", 42, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, err = New[string, int]("test", dir)
+	if err != nil {
+		t.Fatalf("New (reopen): %v", err)
+	}
+	defer s.Close() //nolint:errcheck // Test cleanup
+	if v, _, ok, _ := s.Get(ctx, "k"); !ok || v != 42 {
+		t.Errorf("Get(k) after reopen = %d, %v; want 42, true", v, ok)
+	}
+}
@@ -36,5 +36,9 @@ func New[K comparable, V any](ctx context.Context, cacheID string, c ...compress
 			return p, nil
 		}
 	}
-	return localfs.New[K, V](cacheID, "", c...)
+	var opts []localfs.Option
+	if len(c) > 0 {
+		opts = append(opts, localfs.WithCompressor(c[0]))
+	}
+	return localfs.New[K, V](cacheID, "", opts...)
 }
@@ -11,6 +11,7 @@ require (
 require (
 	github.com/codeGROOVE-dev/ds9 v0.8.1 // indirect
 	github.com/klauspost/compress v1.18.3 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
 )
 
 replace github.com/codeGROOVE-dev/fido/pkg/store/datastore => ../datastore
@@ -0,0 +1,153 @@
+package compress
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Binary reports whether values of type V are stored in their own binary format: V must
+// implement encoding.BinaryMarshaler and *V encoding.BinaryUnmarshaler. Other types are
+// stored as JSON. Note that some standard types qualify, such as time.Time and url.URL,
+// so their stored form is binary rather than JSON text.
+func Binary[V any]() bool {
+	var v V
+	_, m := any(&v).(encoding.BinaryMarshaler)
+	_, u := any(&v).(encoding.BinaryUnmarshaler)
+	return m && u
+}
+
+// versionMagic starts the header MarshalValue writes for versioned types. No JSON
+// document starts with a NUL byte, so unversioned entries are told apart safely.
+var versionMagic = [2]byte{0x00, 0xfe}
+
+type migration struct {
+	to int
+	fn func([]byte) ([]byte, error)
+}
+
+// schema is the migration chain registered for one value type.
+type schema struct {
+	mu      sync.RWMutex
+	current int
+	steps   map[int]migration // keyed by from version
+}
+
+var schemas sync.Map // reflect.Type -> *schema
+
+// RegisterMigration registers fn to upgrade stored values of type V from schema version
+// from to version to (to > from). fn receives the encoded value, as written by
+// MarshalValue before the upgrade, and returns it re-encoded for version to.
+//
+// Once a migration is registered, V is versioned: MarshalValue prefixes its output with
+// a small header carrying the highest registered to version, and UnmarshalValue runs the
+// chain of migrations needed to bring older entries up to date before decoding, so stores
+// upgrade old entries on read. Entries written before V was versioned count as version 0.
+// Register migrations at init time, before any values of V are read or written.
+func RegisterMigration[V any](from, to int, fn func([]byte) ([]byte, error)) {
+	if from < 0 || to <= from {
+		panic(fmt.Sprintf("compress: invalid migration %d -> %d", from, to))
+	}
+	v, _ := schemas.LoadOrStore(reflect.TypeFor[V](), &schema{steps: map[int]migration{}})
+	s := v.(*schema) //nolint:forcetypeassert // only *schema is stored
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps[from] = migration{to: to, fn: fn}
+	s.current = max(s.current, to)
+}
+
+// Versioned reports whether values of type V carry a schema version header
+// (see RegisterMigration).
+func Versioned[V any]() bool {
+	return schemaFor[V]() != nil
+}
+
+// HasVersion reports whether data starts with the schema version header MarshalValue
+// writes for versioned types.
+func HasVersion(data []byte) bool {
+	return len(data) >= len(versionMagic) && data[0] == versionMagic[0] && data[1] == versionMagic[1]
+}
+
+func schemaFor[V any]() *schema {
+	v, ok := schemas.Load(reflect.TypeFor[V]())
+	if !ok {
+		return nil
+	}
+	return v.(*schema) //nolint:forcetypeassert // only *schema is stored
+}
+
+// MarshalValue encodes v for storage: with MarshalBinary if Binary[V], otherwise as JSON.
+// Versioned types are prefixed with their current schema version.
+func MarshalValue[V any](v V) ([]byte, error) {
+	var data []byte
+	var err error
+	if Binary[V]() {
+		data, err = any(&v).(encoding.BinaryMarshaler).MarshalBinary() //nolint:forcetypeassert // checked by Binary
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := schemaFor[V]()
+	if s == nil {
+		return data, nil
+	}
+	s.mu.RLock()
+	ver := s.current
+	s.mu.RUnlock()
+	out := make([]byte, 0, len(versionMagic)+binary.MaxVarintLen64+len(data))
+	out = append(out, versionMagic[:]...)
+	out = binary.AppendUvarint(out, uint64(ver))
+	return append(out, data...), nil
+}
+
+// UnmarshalValue decodes data written by MarshalValue into v, first migrating entries
+// of versioned types written under an older schema version.
+func UnmarshalValue[V any](data []byte, v *V) error {
+	if s := schemaFor[V](); s != nil {
+		var err error
+		if data, err = s.upgrade(data); err != nil {
+			return err
+		}
+	}
+	if Binary[V]() {
+		return any(v).(encoding.BinaryUnmarshaler).UnmarshalBinary(data) //nolint:forcetypeassert // checked by Binary
+	}
+	return json.Unmarshal(data, v)
+}
+
+// upgrade strips the version header from data and applies migrations up to the
+// current version. Data without a header is treated as version 0.
+func (s *schema) upgrade(data []byte) ([]byte, error) {
+	ver := 0
+	if HasVersion(data) {
+		u, n := binary.Uvarint(data[len(versionMagic):])
+		if n <= 0 {
+			return nil, errors.New("corrupt schema version header")
+		}
+		ver = int(u) //nolint:gosec // versions are small
+		data = data[len(versionMagic)+n:]
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if ver > s.current {
+		return nil, fmt.Errorf("schema version %d is newer than current version %d", ver, s.current)
+	}
+	for ver < s.current {
+		m, ok := s.steps[ver]
+		if !ok {
+			return nil, fmt.Errorf("no migration from schema version %d", ver)
+		}
+		var err error
+		if data, err = m.fn(data); err != nil {
+			return nil, fmt.Errorf("migrate schema version %d to %d: %w", ver, m.to, err)
+		}
+		ver = m.to
+	}
+	return data, nil
+}
@@ -2,17 +2,47 @@
 package compress
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/klauspost/compress/dict"
 	"github.com/klauspost/compress/s2"
 	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
 )
 
 // Compressor compresses and decompresses data.
+//
+// Extension returns the suffix stores append to file names or keys so the codec can be
+// recovered from stored data. An empty extension means the store picks its own default,
+// which makes uncompressed data indistinguishable from other extension-less entries.
 type Compressor interface {
 	Encode(data []byte) ([]byte, error)
 	Decode(data []byte) ([]byte, error)
 	Extension() string
 }
 
+// DecoderInto is implemented by compressors that can decompress into a caller-supplied
+// buffer. The result reuses dst's backing array when it is large enough, so hot read
+// paths can recycle one buffer instead of allocating per call. S2, Zstd, LZ4 and Auto implement it.
+type DecoderInto interface {
+	DecodeInto(dst, src []byte) ([]byte, error)
+}
+
+// DecodeError reports stored data that could not be decompressed or unmarshaled.
+// Stores wrap decode failures in it so fido.TieredCache can treat corrupt entries as misses.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string { return e.Err.Error() }
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// DecodeFailure identifies the error as a decode failure without importing this package.
+func (*DecodeError) DecodeFailure() bool { return true }
+
 type none struct{}
 
 // None returns a pass-through compressor (no compression).
@@ -22,6 +52,17 @@ func (none) Encode(data []byte) ([]byte, error) { return data, nil }
 func (none) Decode(data []byte) ([]byte, error) { return data, nil }
 func (none) Extension() string                  { return "" }
 
+type plain struct{}
+
+// Plain returns a pass-through compressor like None, but with a defined extension (".j").
+// Stores can then always tell uncompressed entries apart, which keeps migrations to
+// S2 or Zstd clean. The extension matches what localfs already uses for None.
+func Plain() Compressor { return plain{} }
+
+func (plain) Encode(data []byte) ([]byte, error) { return data, nil }
+func (plain) Decode(data []byte) ([]byte, error) { return data, nil }
+func (plain) Extension() string                  { return ".j" }
+
 type s2c struct{}
 
 // S2 returns a fast compressor using S2 (improved Snappy).
@@ -31,6 +72,9 @@ func (s2c) Encode(data []byte) ([]byte, error) { return s2.Encode(nil, data), ni
 func (s2c) Decode(data []byte) ([]byte, error) { return s2.Decode(nil, data) }
 func (s2c) Extension() string                  { return ".s" }
 
+// DecodeInto implements DecoderInto. s2.Decode writes into dst if its capacity allows.
+func (s2c) DecodeInto(dst, src []byte) ([]byte, error) { return s2.Decode(dst[:cap(dst)], src) }
+
 type zstdc struct {
 	enc *zstd.Encoder
 	dec *zstd.Decoder
@@ -39,17 +83,180 @@ type zstdc struct {
 // Zstd returns a compressor using Zstandard.
 // Level: 1 (fastest) to 4 (best compression).
 func Zstd(level int) Compressor {
-	lvl := zstd.SpeedDefault
-	if level <= 1 {
-		lvl = zstd.SpeedFastest
-	} else if level >= 4 {
-		lvl = zstd.SpeedBestCompression
-	}
-	enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(lvl)) //nolint:errcheck // options are valid
-	dec, _ := zstd.NewReader(nil)                             //nolint:errcheck // options are valid
+	enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel(level))) //nolint:errcheck // options are valid
+	dec, _ := zstd.NewReader(nil)                                          //nolint:errcheck // options are valid
 	return &zstdc{enc: enc, dec: dec}
 }
 
+// zstdLevel maps Zstd's 1-4 level to an encoder level.
+func zstdLevel(level int) zstd.EncoderLevel {
+	switch {
+	case level <= 1:
+		return zstd.SpeedFastest
+	case level >= 4:
+		return zstd.SpeedBestCompression
+	default:
+		return zstd.SpeedDefault
+	}
+}
+
 func (z *zstdc) Encode(data []byte) ([]byte, error) { return z.enc.EncodeAll(data, nil), nil }
 func (z *zstdc) Decode(data []byte) ([]byte, error) { return z.dec.DecodeAll(data, nil) }
 func (*zstdc) Extension() string                    { return ".z" }
+
+// DecodeInto implements DecoderInto. DecodeAll appends to dst[:0], growing it only if needed.
+func (z *zstdc) DecodeInto(dst, src []byte) ([]byte, error) { return z.dec.DecodeAll(src, dst[:0]) }
+
+// zstdDict is Zstd with a shared dictionary; it differs only in its extension.
+type zstdDict struct{ zstdc }
+
+// ZstdDict returns a Zstandard compressor that primes both Encode and Decode with dict,
+// as produced by TrainZstdDict. For small values with a shared structure, such as JSON
+// of one schema, a dictionary captures the redundancy between values that per-value
+// compression misses. Data can only be decoded with the dictionary it was written with.
+// Its extension (".zd") differs from Zstd's so stores can tell the formats apart.
+// Level: 1 (fastest) to 4 (best compression). Panics if dict is not a valid dictionary.
+func ZstdDict(level int, dict []byte) Compressor {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel(level)), zstd.WithEncoderDict(dict))
+	if err != nil {
+		panic(fmt.Sprintf("compress: invalid zstd dictionary: %v", err))
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dict))
+	if err != nil {
+		panic(fmt.Sprintf("compress: invalid zstd dictionary: %v", err))
+	}
+	return &zstdDict{zstdc{enc: enc, dec: dec}}
+}
+
+func (*zstdDict) Extension() string { return ".zd" }
+
+// TrainZstdDict builds a Zstandard dictionary of at most dictSize bytes from samples of
+// typical stored data, for use with ZstdDict. Samples should be encoded values as the
+// store writes them (e.g. MarshalValue output); a few hundred to a few thousand give
+// good results, and a dictSize of 16-64 KB suits most workloads.
+func TrainZstdDict(samples [][]byte, dictSize int) ([]byte, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no samples to train on")
+	}
+	if dictSize <= 0 {
+		return nil, fmt.Errorf("invalid dictionary size %d", dictSize)
+	}
+	d, err := dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: dictSize,
+		HashBytes:   6,
+		ZstdLevel:   zstd.SpeedBestCompression,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("train zstd dictionary: %w", err)
+	}
+	return d, nil
+}
+
+type lz4c struct{}
+
+// LZ4 returns a compressor using LZ4 block compression. It compresses less than S2 or
+// Zstd but decodes faster, which suits latency-sensitive reads. Each value is written as
+// a uvarint of its uncompressed length followed by the LZ4 block; values LZ4 cannot
+// shrink are stored as-is after the length.
+func LZ4() Compressor { return lz4c{} }
+
+func (lz4c) Encode(data []byte) ([]byte, error) {
+	out := make([]byte, binary.MaxVarintLen64+lz4.CompressBlockBound(len(data)))
+	hdr := binary.PutUvarint(out, uint64(len(data)))
+	n, err := lz4.CompressBlock(data, out[hdr:], nil)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 || n >= len(data) { // incompressible
+		return append(out[:hdr], data...), nil
+	}
+	return out[:hdr+n], nil
+}
+
+func (c lz4c) Decode(data []byte) ([]byte, error) { return c.DecodeInto(nil, data) }
+func (lz4c) Extension() string                    { return ".l4" }
+
+// DecodeInto implements DecoderInto. The uncompressed length header sizes dst exactly.
+func (lz4c) DecodeInto(dst, src []byte) ([]byte, error) {
+	size, hdr := binary.Uvarint(src)
+	if hdr <= 0 {
+		return nil, errors.New("lz4: invalid length header")
+	}
+	src = src[hdr:]
+	if size == uint64(len(src)) { // stored as-is
+		return append(dst[:0], src...), nil
+	}
+	if size > uint64(len(src))*255 { // LZ4's maximum ratio; guards the allocation below
+		return nil, errors.New("lz4: corrupt length header")
+	}
+	if uint64(cap(dst)) < size {
+		dst = make([]byte, size)
+	}
+	dst = dst[:size]
+	n, err := lz4.UncompressBlock(src, dst)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(n) != size {
+		return nil, fmt.Errorf("lz4: decoded %d bytes, want %d", n, size)
+	}
+	return dst, nil
+}
+
+// Auto tag bytes, the first byte of every value written by Auto.
+const (
+	autoRaw byte = iota
+	autoBig
+)
+
+type auto struct {
+	big       Compressor
+	threshold int
+}
+
+// Auto returns a compressor that stores values shorter than threshold bytes uncompressed
+// and compresses the rest with big. Compressing small values costs CPU and often grows
+// them, since framing overhead outweighs the savings. Values big fails to shrink are
+// also stored uncompressed. A one-byte tag in front of each value records which path
+// was taken. The extension is big's with an "a" prefix (e.g. ".az" for Zstd), so it is
+// stable for a given big compressor and distinct from big's own.
+func Auto(threshold int, big Compressor) Compressor {
+	return &auto{big: big, threshold: threshold}
+}
+
+func (a *auto) Encode(data []byte) ([]byte, error) {
+	if len(data) >= a.threshold {
+		enc, err := a.big.Encode(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(enc) < len(data) {
+			return append([]byte{autoBig}, enc...), nil
+		}
+	}
+	return append([]byte{autoRaw}, data...), nil
+}
+
+func (a *auto) Decode(data []byte) ([]byte, error) { return a.DecodeInto(nil, data) }
+
+func (a *auto) Extension() string {
+	return ".a" + strings.TrimPrefix(a.big.Extension(), ".")
+}
+
+// DecodeInto implements DecoderInto, reusing dst when big does too.
+func (a *auto) DecodeInto(dst, src []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, errors.New("auto: missing tag byte")
+	}
+	switch src[0] {
+	case autoRaw:
+		return append(dst[:0], src[1:]...), nil
+	case autoBig:
+		if di, ok := a.big.(DecoderInto); ok {
+			return di.DecodeInto(dst, src[1:])
+		}
+		return a.big.Decode(src[1:])
+	default:
+		return nil, fmt.Errorf("auto: unknown tag byte %#x", src[0])
+	}
+}
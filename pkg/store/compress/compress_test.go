@@ -2,6 +2,9 @@ package compress
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -13,9 +16,11 @@ func BenchmarkCompressors(b *testing.B) {
 		c    Compressor
 	}{
 		{"None", None()},
+		{"Plain", Plain()},
 		{"S2", S2()},
 		{"Zstd-1", Zstd(1)},
 		{"Zstd-4", Zstd(4)},
+		{"LZ4", LZ4()},
 	}
 
 	for _, tc := range compressors {
@@ -36,6 +41,17 @@ func BenchmarkCompressors(b *testing.B) {
 				_, _ = tc.c.Decode(encoded) //nolint:errcheck // benchmark
 			}
 		})
+
+		if di, ok := tc.c.(DecoderInto); ok {
+			b.Run(tc.name+"/DecodeInto", func(b *testing.B) {
+				b.SetBytes(int64(len(encoded)))
+				b.ReportAllocs()
+				buf := make([]byte, 0, len(benchData))
+				for range b.N {
+					buf, _ = di.DecodeInto(buf, encoded) //nolint:errcheck // benchmark
+				}
+			})
+		}
 	}
 }
 
@@ -46,9 +62,13 @@ func TestCompressorsRoundTrip(t *testing.T) {
 		ext  string
 	}{
 		{"None", None(), ""},
+		{"Plain", Plain(), ".j"},
 		{"S2", S2(), ".s"},
 		{"Zstd-1", Zstd(1), ".z"},
 		{"Zstd-4", Zstd(4), ".z"},
+		{"LZ4", LZ4(), ".l4"},
+		{"Auto-Zstd", Auto(128, Zstd(1)), ".az"},
+		{"Auto-None", Auto(128, None()), ".a"},
 	}
 
 	for _, tc := range compressors {
@@ -74,6 +94,167 @@ func TestCompressorsRoundTrip(t *testing.T) {
 	}
 }
 
+func TestDecodeInto(t *testing.T) {
+	for name, c := range map[string]Compressor{"S2": S2(), "Zstd": Zstd(1), "LZ4": LZ4(), "Auto": Auto(64, S2())} {
+		t.Run(name, func(t *testing.T) {
+			di, ok := c.(DecoderInto)
+			if !ok {
+				t.Fatalf("%s should implement DecoderInto", name)
+			}
+			encoded, err := c.Encode(benchData)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			// Undersized buffer grows.
+			out, err := di.DecodeInto(nil, encoded)
+			if err != nil {
+				t.Fatalf("DecodeInto(nil): %v", err)
+			}
+			if !bytes.Equal(out, benchData) {
+				t.Fatalf("DecodeInto(nil) = %q, want %q", out, benchData)
+			}
+
+			// Large enough buffer is reused.
+			buf := make([]byte, 3, len(benchData)*2)
+			out, err = di.DecodeInto(buf, encoded)
+			if err != nil {
+				t.Fatalf("DecodeInto(buf): %v", err)
+			}
+			if !bytes.Equal(out, benchData) {
+				t.Errorf("DecodeInto(buf) = %q, want %q", out, benchData)
+			}
+			if &out[0] != &buf[:1][0] {
+				t.Error("DecodeInto should reuse dst's backing array when it has capacity")
+			}
+		})
+	}
+}
+
+func TestLZ4EdgeCases(t *testing.T) {
+	c := LZ4()
+	for name, data := range map[string][]byte{
+		"empty":          {},
+		"single byte":    {'x'},
+		"incompressible": []byte("q8Zr!kW3@pL0#vN7"),
+		"repetitive":     bytes.Repeat([]byte("abc"), 1000),
+	} {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := c.Encode(data)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			decoded, err := c.Decode(encoded)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !bytes.Equal(decoded, data) {
+				t.Errorf("roundtrip failed: got %q, want %q", decoded, data)
+			}
+		})
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"truncated": {0xff},
+		"oversized": {0xff, 0xff, 0xff, 0x7f, 0x00},
+	} {
+		if _, err := c.Decode(data); err == nil {
+			t.Errorf("Decode(%s) should fail", name)
+		}
+	}
+}
+
+func TestAuto(t *testing.T) {
+	c := Auto(128, Zstd(1))
+
+	token := []byte("sess_3f9a1c7e2b8d4f6a0e5c9b1d7f3a2e8c4b6d0f1a")
+	encoded, err := c.Encode(token)
+	if err != nil {
+		t.Fatalf("Encode(small): %v", err)
+	}
+	if encoded[0] != autoRaw || len(encoded) != len(token)+1 {
+		t.Errorf("small value encoded to %d bytes with tag %d; want %d bytes, uncompressed", len(encoded), encoded[0], len(token)+1)
+	}
+
+	large := bytes.Repeat(benchData, 10)
+	encoded, err = c.Encode(large)
+	if err != nil {
+		t.Fatalf("Encode(large): %v", err)
+	}
+	if encoded[0] != autoBig || len(encoded) >= len(large) {
+		t.Errorf("large value encoded to %d bytes with tag %d; want compressed", len(encoded), encoded[0])
+	}
+	decoded, err := c.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode(large): %v", err)
+	}
+	if !bytes.Equal(decoded, large) {
+		t.Error("large value roundtrip failed")
+	}
+
+	// Incompressible values above the threshold are kept as-is.
+	random := []byte("q8Zr!kW3@pL0#vN7x2Y$mB5^tH9&jD4*sF6(gK1)cV8_nQ3+wE0=uR7")
+	if encoded, _ := Auto(16, Zstd(1)).Encode(random); encoded[0] != autoRaw { //nolint:errcheck // checked via tag
+		t.Errorf("incompressible value stored with tag %d; want uncompressed", encoded[0])
+	}
+
+	for name, data := range map[string][]byte{"empty": nil, "unknown tag": {0x7f, 'x'}} {
+		if _, err := c.Decode(data); err == nil {
+			t.Errorf("Decode(%s) should fail", name)
+		}
+	}
+}
+
+func TestZstdDict(t *testing.T) {
+	samples := make([][]byte, 500)
+	for i := range samples {
+		samples[i] = fmt.Appendf(nil, `{"id":%d,"name":"user-%d","email":"user-%d@example.com","roles":["reader","writer"],"active":%t}`, i, i*7, i*13, i%2 == 0)
+	}
+	d, err := TrainZstdDict(samples, 4096)
+	if err != nil {
+		t.Fatalf("TrainZstdDict: %v", err)
+	}
+	if len(d) == 0 || len(d) > 4096 {
+		t.Fatalf("TrainZstdDict returned %d bytes; want 1..4096", len(d))
+	}
+
+	c := ZstdDict(3, d)
+	if c.Extension() != ".zd" {
+		t.Errorf("Extension = %q, want %q", c.Extension(), ".zd")
+	}
+	if _, ok := c.(DecoderInto); !ok {
+		t.Error("ZstdDict should implement DecoderInto")
+	}
+
+	val := []byte(`{"id":9001,"name":"user-4242","email":"user-777@example.com","roles":["reader","writer"],"active":true}`)
+	encoded, err := c.Encode(val)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	decoded, err := c.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !bytes.Equal(decoded, val) {
+		t.Errorf("roundtrip failed: got %q, want %q", decoded, val)
+	}
+
+	plain, _ := Zstd(3).Encode(val) //nolint:errcheck // compared by size only
+	if len(encoded) >= len(plain) {
+		t.Errorf("dictionary encoding is %d bytes; want fewer than plain Zstd's %d", len(encoded), len(plain))
+	}
+}
+
+func TestTrainZstdDictInvalid(t *testing.T) {
+	if _, err := TrainZstdDict(nil, 4096); err == nil {
+		t.Error("TrainZstdDict(nil) should fail")
+	}
+	if _, err := TrainZstdDict([][]byte{benchData}, 0); err == nil {
+		t.Error("TrainZstdDict with dictSize 0 should fail")
+	}
+}
+
 func TestNoneZeroCopy(t *testing.T) {
 	c := None()
 	data := []byte("test data")
@@ -94,3 +275,129 @@ func TestNoneZeroCopy(t *testing.T) {
 		t.Error("None.Decode should return same slice (zero-copy)")
 	}
 }
+
+func TestDecodeError(t *testing.T) {
+	inner := errors.New("bad frame")
+	var err error = &DecodeError{Err: inner}
+
+	if !errors.Is(err, inner) {
+		t.Error("DecodeError should unwrap to the underlying error")
+	}
+	if err.Error() != "bad frame" {
+		t.Errorf("Error() = %q; want %q", err.Error(), "bad frame")
+	}
+
+	var df interface{ DecodeFailure() bool }
+	if !errors.As(err, &df) || !df.DecodeFailure() {
+		t.Error("DecodeError should report DecodeFailure() == true")
+	}
+}
+
+// binVal has a compact binary form distinct from its JSON encoding.
+type binVal struct{ n uint8 }
+
+func (b binVal) MarshalBinary() ([]byte, error) { return []byte{b.n}, nil }
+
+func (b *binVal) UnmarshalBinary(data []byte) error {
+	if len(data) != 1 {
+		return errors.New("bad length")
+	}
+	b.n = data[0]
+	return nil
+}
+
+func TestMarshalValue(t *testing.T) {
+	if !Binary[binVal]() {
+		t.Fatal("Binary[binVal] = false; want true")
+	}
+	data, err := MarshalValue(binVal{n: 7})
+	if err != nil {
+		t.Fatalf("MarshalValue: %v", err)
+	}
+	if !bytes.Equal(data, []byte{7}) {
+		t.Errorf("MarshalValue = %v; want MarshalBinary output [7]", data)
+	}
+	var got binVal
+	if err := UnmarshalValue(data, &got); err != nil || got.n != 7 {
+		t.Errorf("UnmarshalValue = %v, %v; want {7}, nil", got, err)
+	}
+
+	// Types without a binary form fall back to JSON.
+	if Binary[map[string]int]() {
+		t.Error("Binary[map[string]int] = true; want false")
+	}
+	data, err = MarshalValue(map[string]int{"a": 1})
+	if err != nil || string(data) != `{"a":1}` {
+		t.Errorf("MarshalValue(map) = %s, %v; want JSON", data, err)
+	}
+	var m map[string]int
+	if err := UnmarshalValue(data, &m); err != nil || m["a"] != 1 {
+		t.Errorf("UnmarshalValue(map) = %v, %v", m, err)
+	}
+}
+
+type userV2 struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func TestRegisterMigration(t *testing.T) {
+	// Version 0 stored {"name": ...}; version 1 adds email; version 2 lowercases names.
+	RegisterMigration[userV2](0, 1, func(old []byte) ([]byte, error) {
+		var m map[string]any
+		if err := json.Unmarshal(old, &m); err != nil {
+			return nil, err
+		}
+		m["email"] = "unknown"
+		return json.Marshal(m)
+	})
+	RegisterMigration[userV2](1, 2, func(old []byte) ([]byte, error) {
+		return bytes.ToLower(old), nil
+	})
+	if !Versioned[userV2]() {
+		t.Fatal("Versioned[userV2] = false after RegisterMigration")
+	}
+
+	// Unversioned legacy data counts as version 0 and runs both migrations.
+	var got userV2
+	if err := UnmarshalValue([]byte(`{"name":"Ann"}`), &got); err != nil {
+		t.Fatalf("UnmarshalValue(legacy): %v", err)
+	}
+	if got != (userV2{Name: "ann", Email: "unknown"}) {
+		t.Errorf("UnmarshalValue(legacy) = %+v; want migrated value", got)
+	}
+
+	// Current-version data round-trips without migrating.
+	data, err := MarshalValue(userV2{Name: "Bob", Email: "b@example.com"})
+	if err != nil {
+		t.Fatalf("MarshalValue: %v", err)
+	}
+	if !HasVersion(data) {
+		t.Errorf("MarshalValue = %q; want version header", data)
+	}
+	got = userV2{}
+	if err := UnmarshalValue(data, &got); err != nil || got.Name != "Bob" {
+		t.Errorf("UnmarshalValue(current) = %+v, %v; want Name Bob unchanged", got, err)
+	}
+
+	// Version 1 data runs only the second migration.
+	v1 := append([]byte{0x00, 0xfe, 1}, `{"name":"Cy","email":"C@X"}`...)
+	got = userV2{}
+	if err := UnmarshalValue(v1, &got); err != nil || got != (userV2{Name: "cy", Email: "c@x"}) {
+		t.Errorf("UnmarshalValue(v1) = %+v, %v", got, err)
+	}
+
+	// Data from a newer schema cannot be read.
+	if err := UnmarshalValue(append([]byte{0x00, 0xfe, 3}, `{}`...), &got); err == nil {
+		t.Error("UnmarshalValue(v3) succeeded; want error")
+	}
+}
+
+type gappedValue struct{ N int }
+
+func TestRegisterMigrationGap(t *testing.T) {
+	RegisterMigration[gappedValue](1, 2, func(old []byte) ([]byte, error) { return old, nil })
+	if err := UnmarshalValue([]byte(`{"N":1}`), new(gappedValue)); err == nil {
+		t.Error("UnmarshalValue with no migration from version 0 succeeded; want error")
+	}
+}
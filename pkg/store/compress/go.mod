@@ -2,4 +2,7 @@ module github.com/codeGROOVE-dev/fido/pkg/store/compress
 
 go 1.25.4
 
-require github.com/klauspost/compress v1.18.3
+require (
+	github.com/klauspost/compress v1.18.3
+	github.com/pierrec/lz4/v4 v4.1.22
+)
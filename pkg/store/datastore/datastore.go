@@ -4,7 +4,6 @@ package datastore
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
@@ -18,6 +17,7 @@ import (
 const (
 	datastoreKind      = "CacheEntry"
 	maxDatastoreKeyLen = 1500 // Datastore has stricter key length limits
+	pingKeyName        = "__fido_ping__"
 )
 
 // Store implements persistence using Google Cloud Datastore.
@@ -108,16 +108,16 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time
 
 	b, err := base64.StdEncoding.DecodeString(e.Value)
 	if err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("decode base64: %w", err)
+		return zero, time.Time{}, false, &compress.DecodeError{Err: fmt.Errorf("decode base64: %w", err)}
 	}
 
-	jsonData, err := s.compressor.Decode(b)
+	raw, err := s.compressor.Decode(b)
 	if err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("decompress: %w", err)
+		return zero, time.Time{}, false, &compress.DecodeError{Err: fmt.Errorf("decompress: %w", err)}
 	}
 
-	if err := json.Unmarshal(jsonData, &value); err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("unmarshal value: %w", err)
+	if err := compress.UnmarshalValue(raw, &value); err != nil {
+		return zero, time.Time{}, false, &compress.DecodeError{Err: fmt.Errorf("unmarshal value: %w", err)}
 	}
 
 	return value, e.Expiry, true, nil
@@ -125,12 +125,12 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time
 
 // Set saves a value to Datastore.
 func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
-	jsonData, err := json.Marshal(value)
+	raw, err := compress.MarshalValue(value)
 	if err != nil {
 		return fmt.Errorf("marshal value: %w", err)
 	}
 
-	data, err := s.compressor.Encode(jsonData)
+	data, err := s.compressor.Encode(raw)
 	if err != nil {
 		return fmt.Errorf("compress: %w", err)
 	}
@@ -219,6 +219,17 @@ func (s *Store[K, V]) Close() error {
 	return s.client.Close()
 }
 
+// Ping looks up a sentinel key to confirm Datastore is reachable and authorized.
+// A missing entity is the expected healthy result. Implements fido.HealthChecker.
+func (s *Store[K, V]) Ping(ctx context.Context) error {
+	var e entry
+	err := s.client.Get(ctx, ds.NameKey(s.kind, pingKeyName, nil), &e)
+	if err != nil && !errors.Is(err, ds.ErrNoSuchEntity) {
+		return fmt.Errorf("datastore ping: %w", err)
+	}
+	return nil
+}
+
 // Keys returns an iterator over keys matching prefix.
 // Implements PrefixScanner[V] interface (only usable when K is string).
 // Uses Datastore keys-only query for efficiency.
@@ -298,7 +309,7 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 			}
 
 			var v V
-			if err := json.Unmarshal(data, &v); err != nil {
+			if err := compress.UnmarshalValue(data, &v); err != nil {
 				continue
 			}
 
@@ -614,3 +614,12 @@ func TestDatastorePersist_Mock_FlushEmpty(t *testing.T) {
 		t.Errorf("Flush deleted %d entries from empty datastore; want 0", deleted)
 	}
 }
+
+func TestDatastorePersist_Mock_Ping(t *testing.T) {
+	dp, cleanup := newMockDatastorePersist[string, int](t)
+	defer cleanup()
+
+	if err := dp.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+// Package failover composes a primary and a secondary store into one, switching to the
+// secondary after repeated primary errors and back once the primary recovers.
+// TieredCache sees a single store and needs no knowledge of the pair.
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Backend is the persistence interface of the composed stores.
+// Matches fido.Store so any fido store can be used.
+type Backend[K comparable, V any] interface {
+	ValidateKey(key K) error
+	Get(ctx context.Context, key K) (V, time.Time, bool, error)
+	Set(ctx context.Context, key K, value V, expiry time.Time) error
+	Delete(ctx context.Context, key K) error
+	Cleanup(ctx context.Context, maxAge time.Duration) (int, error)
+	Flush(ctx context.Context) (int, error)
+	Len(ctx context.Context) (int, error)
+	Close() error
+}
+
+type config struct {
+	threshold     int
+	probeInterval time.Duration
+	writeBoth     bool
+}
+
+// Option configures a failover Store.
+type Option func(*config)
+
+// Threshold sets how many consecutive primary errors trigger failover. Default 3.
+func Threshold(n int) Option {
+	return func(c *config) { c.threshold = max(n, 1) }
+}
+
+// ProbeInterval sets how often a failed-over Store routes one request back to the
+// primary to check whether it has recovered. Default 10s.
+func ProbeInterval(d time.Duration) Option {
+	return func(c *config) { c.probeInterval = d }
+}
+
+// WriteBoth mirrors Set to both stores, so the secondary is warm when failover happens
+// and the primary is current when it recovers. By default Set writes to the active
+// store only. Set succeeds if either write does.
+func WriteBoth() Option {
+	return func(c *config) { c.writeBoth = true }
+}
+
+// Store routes operations to the primary store while it is healthy. After Threshold
+// consecutive primary errors it fails over to the secondary, probing the primary every
+// ProbeInterval and failing back on the first success. A request that fails on the
+// primary is retried on the secondary, so callers only see errors when both fail.
+//
+// Undecodable entries (errors with a DecodeFailure() bool method returning true) are
+// data problems rather than outages: they are returned as is and don't count as errors.
+//
+// Entries written to only one store while the other was unavailable are not copied
+// over; use WriteBoth to keep the two in step.
+type Store[K comparable, V any] struct {
+	primary   Backend[K, V]
+	secondary Backend[K, V]
+	cfg       config
+
+	mu        sync.Mutex
+	errs      int       // consecutive primary errors
+	failed    bool      // failed over to the secondary
+	nextProbe time.Time // when a failed-over Store next tries the primary
+}
+
+// New creates a Store that prefers primary and fails over to secondary.
+func New[K comparable, V any](primary, secondary Backend[K, V], opts ...Option) *Store[K, V] {
+	cfg := config{threshold: 3, probeInterval: 10 * time.Second}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Store[K, V]{primary: primary, secondary: secondary, cfg: cfg}
+}
+
+// FailedOver reports whether the secondary is currently the active store.
+func (s *Store[K, V]) FailedOver() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failed
+}
+
+// usePrimary reports whether the next request should go to the primary: always while
+// healthy, and once per ProbeInterval while failed over.
+func (s *Store[K, V]) usePrimary() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.failed {
+		return true
+	}
+	now := time.Now()
+	if now.Before(s.nextProbe) {
+		return false
+	}
+	s.nextProbe = now.Add(s.cfg.probeInterval)
+	return true
+}
+
+// record updates primary health with the outcome of a primary request.
+func (s *Store[K, V]) record(err error) {
+	if err != nil && isDecodeError(err) {
+		err = nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.errs = 0
+		s.failed = false
+		return
+	}
+	s.errs++
+	if !s.failed && s.errs >= s.cfg.threshold {
+		s.failed = true
+		s.nextProbe = time.Now().Add(s.cfg.probeInterval)
+	}
+}
+
+// isDecodeError reports whether err (or any error it wraps) is a store decode failure.
+func isDecodeError(err error) bool {
+	var df interface{ DecodeFailure() bool }
+	return errors.As(err, &df) && df.DecodeFailure()
+}
+
+// ValidateKey accepts keys that both stores accept.
+func (s *Store[K, V]) ValidateKey(key K) error {
+	return errors.Join(s.primary.ValidateKey(key), s.secondary.ValidateKey(key))
+}
+
+// Get reads from the active store, falling back to the secondary if the primary fails.
+//
+//nolint:revive // function-result-limit: required by Store interface
+func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time, found bool, err error) {
+	if s.usePrimary() {
+		value, expiry, found, err = s.primary.Get(ctx, key)
+		s.record(err)
+		if err == nil || isDecodeError(err) {
+			return value, expiry, found, err
+		}
+	}
+	return s.secondary.Get(ctx, key)
+}
+
+// Set writes to the active store, falling back to the secondary if the primary fails.
+// With WriteBoth it writes to both and succeeds if either write does.
+func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	if s.cfg.writeBoth {
+		perr := s.primary.Set(ctx, key, value, expiry)
+		s.record(perr)
+		serr := s.secondary.Set(ctx, key, value, expiry)
+		if perr != nil && serr != nil {
+			return errors.Join(perr, serr)
+		}
+		return nil
+	}
+	if s.usePrimary() {
+		err := s.primary.Set(ctx, key, value, expiry)
+		s.record(err)
+		if err == nil {
+			return nil
+		}
+	}
+	return s.secondary.Set(ctx, key, value, expiry)
+}
+
+// Delete removes key from both stores, so a stale copy can't resurface after
+// failover or failback.
+func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
+	perr := s.primary.Delete(ctx, key)
+	s.record(perr)
+	return errors.Join(perr, s.secondary.Delete(ctx, key))
+}
+
+// Cleanup removes expired entries from both stores and returns the combined count.
+func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	pn, perr := s.primary.Cleanup(ctx, maxAge)
+	sn, serr := s.secondary.Cleanup(ctx, maxAge)
+	return pn + sn, errors.Join(perr, serr)
+}
+
+// Flush removes all entries from both stores and returns the combined count.
+func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
+	pn, perr := s.primary.Flush(ctx)
+	sn, serr := s.secondary.Flush(ctx)
+	return pn + sn, errors.Join(perr, serr)
+}
+
+// Len returns the number of entries in the active store.
+func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
+	if s.FailedOver() {
+		return s.secondary.Len(ctx)
+	}
+	return s.primary.Len(ctx)
+}
+
+// Close closes both stores.
+func (s *Store[K, V]) Close() error {
+	return errors.Join(s.primary.Close(), s.secondary.Close())
+}
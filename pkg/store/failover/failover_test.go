@@ -0,0 +1,200 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	mu   sync.Mutex
+	data map[string]int
+	fail bool
+	gets int
+}
+
+func newFake() *fakeStore { return &fakeStore{data: map[string]int{}} }
+
+func (f *fakeStore) setFail(v bool) {
+	f.mu.Lock()
+	f.fail = v
+	f.mu.Unlock()
+}
+
+func (f *fakeStore) err() error {
+	if f.fail {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (*fakeStore) ValidateKey(string) error { return nil }
+
+func (f *fakeStore) Get(_ context.Context, key string) (int, time.Time, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
+	if err := f.err(); err != nil {
+		return 0, time.Time{}, false, err
+	}
+	v, ok := f.data[key]
+	return v, time.Time{}, ok, nil
+}
+
+func (f *fakeStore) Set(_ context.Context, key string, value int, _ time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err(); err != nil {
+		return err
+	}
+	f.data[key] = value
+	return nil
+}
+
+func (f *fakeStore) Delete(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err(); err != nil {
+		return err
+	}
+	delete(f.data, key)
+	return nil
+}
+
+func (*fakeStore) Cleanup(context.Context, time.Duration) (int, error) { return 0, nil }
+
+func (f *fakeStore) Flush(context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := len(f.data)
+	clear(f.data)
+	return n, f.err()
+}
+
+func (f *fakeStore) Len(context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.data), f.err()
+}
+
+func (*fakeStore) Close() error { return nil }
+
+func TestFailoverAndRecovery(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newFake(), newFake()
+	s := New[string, int](primary, secondary, Threshold(2), ProbeInterval(20*time.Millisecond))
+
+	if err := s.Set(ctx, "a", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if primary.data["a"] != 1 || len(secondary.data) != 0 {
+		t.Fatalf("Set went to primary=%v secondary=%v; want primary only", primary.data, secondary.data)
+	}
+
+	// Primary errors are retried on the secondary; the second one trips failover.
+	primary.setFail(true)
+	secondary.data["b"] = 2
+	for range 2 {
+		if v, _, found, err := s.Get(ctx, "b"); err != nil || !found || v != 2 {
+			t.Fatalf("Get(b) = %d, %v, %v; want 2 from secondary", v, found, err)
+		}
+	}
+	if !s.FailedOver() {
+		t.Fatal("FailedOver = false after Threshold primary errors")
+	}
+
+	// While failed over and before the probe is due, the primary is not touched.
+	before := primary.gets
+	if _, _, _, err := s.Get(ctx, "b"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if primary.gets != before {
+		t.Error("failed-over Get reached the primary before the probe interval")
+	}
+	if err := s.Set(ctx, "c", 3, time.Time{}); err != nil || secondary.data["c"] != 3 {
+		t.Errorf("failed-over Set = %v, secondary[c]=%d; want written to secondary", err, secondary.data["c"])
+	}
+
+	// Once the primary recovers, the next probe fails back.
+	primary.setFail(false)
+	time.Sleep(30 * time.Millisecond)
+	if v, _, found, err := s.Get(ctx, "a"); err != nil || !found || v != 1 {
+		t.Fatalf("probe Get(a) = %d, %v, %v; want 1 from primary", v, found, err)
+	}
+	if s.FailedOver() {
+		t.Error("FailedOver = true after a successful probe")
+	}
+}
+
+func TestWriteBoth(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newFake(), newFake()
+	s := New[string, int](primary, secondary, WriteBoth())
+
+	if err := s.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if primary.data["k"] != 1 || secondary.data["k"] != 1 {
+		t.Errorf("WriteBoth Set: primary=%v secondary=%v; want both", primary.data, secondary.data)
+	}
+
+	primary.setFail(true)
+	if err := s.Set(ctx, "k", 2, time.Time{}); err != nil {
+		t.Errorf("Set with one store down = %v; want nil", err)
+	}
+	secondary.setFail(true)
+	if err := s.Set(ctx, "k", 3, time.Time{}); err == nil {
+		t.Error("Set with both stores down succeeded; want error")
+	}
+}
+
+type decodeErr struct{}
+
+func (decodeErr) Error() string       { return "corrupt" }
+func (decodeErr) DecodeFailure() bool { return true }
+
+type corruptStore struct{ *fakeStore }
+
+func (corruptStore) Get(context.Context, string) (int, time.Time, bool, error) {
+	return 0, time.Time{}, false, decodeErr{}
+}
+
+func TestDecodeErrorsDoNotFailOver(t *testing.T) {
+	ctx := context.Background()
+	secondary := newFake()
+	s := New[string, int](corruptStore{newFake()}, secondary, Threshold(1))
+
+	_, _, _, err := s.Get(ctx, "k")
+	var de decodeErr
+	if !errors.As(err, &de) {
+		t.Errorf("Get error = %v; want the primary's decode error", err)
+	}
+	if s.FailedOver() {
+		t.Error("FailedOver = true after a decode error")
+	}
+	if secondary.gets != 0 {
+		t.Error("decode error was retried on the secondary")
+	}
+}
+
+func TestDeleteAndFlushBoth(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newFake(), newFake()
+	s := New[string, int](primary, secondary)
+	primary.data["k"], secondary.data["k"], secondary.data["x"] = 1, 1, 2
+
+	if err := s.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := primary.data["k"]; ok {
+		t.Error("Delete left key in primary")
+	}
+	if _, ok := secondary.data["k"]; ok {
+		t.Error("Delete left key in secondary")
+	}
+	if n, err := s.Flush(ctx); err != nil || n != 1 {
+		t.Errorf("Flush = %d, %v; want 1, nil", n, err)
+	}
+}
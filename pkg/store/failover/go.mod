@@ -0,0 +1,3 @@
+module github.com/codeGROOVE-dev/fido/pkg/store/failover
+
+go 1.25.4
@@ -50,7 +50,7 @@ func BenchmarkCompressionOverhead(b *testing.B) {
 
 	b.Run("NoneExplicit", func(b *testing.B) {
 		dir := b.TempDir()
-		store, err := New[string, benchValue]("bench-none-explicit", dir, compress.None())
+		store, err := New[string, benchValue]("bench-none-explicit", dir, WithCompressor(compress.None()))
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -70,7 +70,7 @@ func BenchmarkCompressionOverhead(b *testing.B) {
 
 	b.Run("S2", func(b *testing.B) {
 		dir := b.TempDir()
-		store, err := New[string, benchValue]("bench-s2", dir, compress.S2())
+		store, err := New[string, benchValue]("bench-s2", dir, WithCompressor(compress.S2()))
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -90,7 +90,7 @@ func BenchmarkCompressionOverhead(b *testing.B) {
 
 	b.Run("Zstd", func(b *testing.B) {
 		dir := b.TempDir()
-		store, err := New[string, benchValue]("bench-zstd", dir, compress.Zstd(1))
+		store, err := New[string, benchValue]("bench-zstd", dir, WithCompressor(compress.Zstd(1)))
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -151,7 +151,7 @@ func TestCompressionOverheadReport(t *testing.T) {
 		if tc.c == nil {
 			store, err = New[string, benchValue]("bench-"+tc.name, dir)
 		} else {
-			store, err = New[string, benchValue]("bench-"+tc.name, dir, tc.c)
+			store, err = New[string, benchValue]("bench-"+tc.name, dir, WithCompressor(tc.c))
 		}
 		if err != nil {
 			t.Fatal(err)
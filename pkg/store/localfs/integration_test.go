@@ -1,13 +1,16 @@
 package localfs
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -374,6 +377,55 @@ func TestFilePersist_Cleanup(t *testing.T) {
 	}
 }
 
+func TestFilePersist_Cleanup_OrphanTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, int]("test", dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := fp.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	if err := fp.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Temp files as left by writes that crashed before renaming
+	loc := fp.Location("k")
+	stale := loc + ".123456.tmp"
+	fresh := loc + ".654321.tmp"
+	for _, fn := range []string{stale, fresh} {
+		if err := os.WriteFile(fn, []byte("partial"), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * orphanTmpAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	count, err := fp.Cleanup(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Cleanup count = %d; want 0 (temp files are not entries)", count)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale temp file still exists: %v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("recent temp file removed; it may belong to a write in progress: %v", err)
+	}
+	if _, _, found, err := fp.Get(ctx, "k"); err != nil || !found {
+		t.Errorf("Get(k) = %v, %v; want the entry kept", found, err)
+	}
+}
+
 func TestFilePersist_Location(t *testing.T) {
 	dir := t.TempDir()
 	fp, err := New[string, int]("test", dir)
@@ -427,8 +479,158 @@ func TestFilePersist_LoadErrors(t *testing.T) {
 	if found {
 		t.Error("Load should not find corrupted entry")
 	}
-	// Error is acceptable for corrupted data
-	_ = err
+	// Corruption is reported as a decode failure so TieredCache can treat it as a miss.
+	var de *compress.DecodeError
+	if !errors.As(err, &de) {
+		t.Errorf("Get error = %v; want *compress.DecodeError", err)
+	}
+}
+
+func TestFilePersist_Checksum(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	fp, err := New[string, string]("cache", dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer fp.Close() //nolint:errcheck // Test cleanup
+
+	if err := fp.Set(ctx, "key", "hello world", time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	loc := fp.Location("key")
+	data, err := os.ReadFile(loc)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.HasPrefix(data, checksumMagic) {
+		t.Fatalf("file should start with the checksum header, got %q", data[:min(8, len(data))])
+	}
+
+	// Flip one bit of the value: still valid JSON, but the checksum no longer matches.
+	i := bytes.Index(data, []byte("hello"))
+	data[i] ^= 0x20
+	if err := os.WriteFile(loc, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	v, _, found, err := fp.Get(ctx, "key")
+	if found || !errors.Is(err, errChecksum) {
+		t.Errorf("Get = %q, %v, %v; want miss with checksum error", v, found, err)
+	}
+	var de *compress.DecodeError
+	if !errors.As(err, &de) {
+		t.Errorf("Get error = %v; want *compress.DecodeError", err)
+	}
+	if _, err := os.Stat(loc); !os.IsNotExist(err) {
+		t.Error("corrupt file should be removed")
+	}
+
+	// Files written without a checksum stay readable by a store that writes them.
+	fpOff, err := New[string, string]("cache", dir, WithChecksum(false))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := fpOff.Set(ctx, "legacy", "v1", time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if data, _ := os.ReadFile(fpOff.Location("legacy")); bytes.HasPrefix(data, checksumMagic) { //nolint:errcheck // Test helper
+		t.Error("WithChecksum(false) should write no checksum header")
+	}
+	if v, _, found, err := fp.Get(ctx, "legacy"); !found || err != nil || v != "v1" {
+		t.Errorf("Get(legacy) = %q, %v, %v; want v1, true, nil", v, found, err)
+	}
+}
+
+func TestFilePersist_AtomicSet(t *testing.T) {
+	for _, syncWrites := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sync=%v", syncWrites), func(t *testing.T) {
+			dir := t.TempDir()
+			ctx := context.Background()
+			fp, err := New[string, string]("cache", dir, WithSync(syncWrites))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer fp.Close() //nolint:errcheck // Test cleanup
+
+			// Concurrent writers of one key must never leave a torn file for readers.
+			var wg sync.WaitGroup
+			for w := range 4 {
+				wg.Go(func() {
+					for i := range 25 {
+						v := strings.Repeat(string(rune('a'+w)), 1000+i)
+						if err := fp.Set(ctx, "key", v, time.Time{}); err != nil {
+							t.Errorf("Set: %v", err)
+						}
+					}
+				})
+			}
+			for range 100 {
+				if _, _, _, err := fp.Get(ctx, "key"); err != nil {
+					t.Errorf("Get during concurrent Set: %v", err)
+				}
+			}
+			wg.Wait()
+
+			entries, err := os.ReadDir(filepath.Dir(fp.Location("key")))
+			if err != nil {
+				t.Fatalf("ReadDir: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory holds %d files; want only the entry, no temp files", len(entries))
+			}
+		})
+	}
+}
+
+func TestFilePersist_Fanout(t *testing.T) {
+	ctx := context.Background()
+	for _, levels := range []int{0, 1, 2, 4} {
+		t.Run(fmt.Sprintf("levels=%d", levels), func(t *testing.T) {
+			dir := t.TempDir()
+			fp, err := New[string, int]("cache", dir, WithFanout(levels))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer fp.Close() //nolint:errcheck // Test cleanup
+
+			rel, err := filepath.Rel(fp.Dir, fp.Location("key"))
+			if err != nil {
+				t.Fatalf("Rel: %v", err)
+			}
+			if depth := strings.Count(rel, string(filepath.Separator)); depth != levels {
+				t.Errorf("Location %q is %d directories deep; want %d", rel, depth, levels)
+			}
+
+			for i := range 20 {
+				exp := time.Time{}
+				if i%2 == 0 {
+					exp = time.Now().Add(-2 * time.Hour)
+				}
+				if err := fp.Set(ctx, fmt.Sprintf("key-%d", i), i, exp); err != nil {
+					t.Fatalf("Set: %v", err)
+				}
+			}
+			if v, _, found, err := fp.Get(ctx, "key-7"); !found || err != nil || v != 7 {
+				t.Errorf("Get(key-7) = %d, %v, %v; want 7, true, nil", v, found, err)
+			}
+			if n, err := fp.Cleanup(ctx, time.Hour); err != nil || n != 10 {
+				t.Errorf("Cleanup = %d, %v; want 10, nil", n, err)
+			}
+			if n, err := fp.Len(ctx); err != nil || n != 10 {
+				t.Errorf("Len = %d, %v; want 10, nil", n, err)
+			}
+			if n, err := fp.Flush(ctx); err != nil || n != 10 {
+				t.Errorf("Flush = %d, %v; want 10, nil", n, err)
+			}
+		})
+	}
+
+	for _, levels := range []int{-1, maxFanout + 1} {
+		if _, err := New[string, int]("bad", t.TempDir(), WithFanout(levels)); err == nil {
+			t.Errorf("New with WithFanout(%d) should fail", levels)
+		}
+	}
 }
 
 func TestFilePersist_StoreCreateDir(t *testing.T) {
@@ -927,7 +1129,7 @@ func TestFilePersist_Len_NewStoreSameDir(t *testing.T) {
 
 func TestFilePersist_Compression_S2(t *testing.T) {
 	dir := t.TempDir()
-	fp, err := New[string, string]("test", dir, compress.S2())
+	fp, err := New[string, string]("test", dir, WithCompressor(compress.S2()))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -969,7 +1171,7 @@ func TestFilePersist_Compression_S2(t *testing.T) {
 
 func TestFilePersist_Compression_Zstd(t *testing.T) {
 	dir := t.TempDir()
-	fp, err := New[string, string]("test", dir, compress.Zstd(1))
+	fp, err := New[string, string]("test", dir, WithCompressor(compress.Zstd(1)))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1006,7 +1208,7 @@ func TestFilePersist_Compression_Zstd(t *testing.T) {
 
 func TestFilePersist_Compression_None(t *testing.T) {
 	dir := t.TempDir()
-	fp, err := New[string, string]("test", dir, compress.None())
+	fp, err := New[string, string]("test", dir, WithCompressor(compress.None()))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1060,7 +1262,7 @@ func TestFilePersist_Compression_DefaultIsNone(t *testing.T) {
 	}
 
 	// Create store with explicit None
-	fp2, err := New[string, string]("test2", dir, compress.None())
+	fp2, err := New[string, string]("test2", dir, WithCompressor(compress.None()))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1104,7 +1306,7 @@ func TestFilePersist_Compression_ActuallyCompresses(t *testing.T) {
 	}
 
 	// Store with S2 compression
-	fpS2, err := New[string, string]("s2", dir, compress.S2())
+	fpS2, err := New[string, string]("s2", dir, WithCompressor(compress.S2()))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1118,7 +1320,7 @@ func TestFilePersist_Compression_ActuallyCompresses(t *testing.T) {
 	}
 
 	// Store with Zstd compression
-	fpZstd, err := New[string, string]("zstd", dir, compress.Zstd(3))
+	fpZstd, err := New[string, string]("zstd", dir, WithCompressor(compress.Zstd(3)))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1173,7 +1375,7 @@ func TestFilePersist_Compression_IsolatedNamespaces(t *testing.T) {
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
-	fpS2, err := New[string, string]("cache", dir, compress.S2())
+	fpS2, err := New[string, string]("cache", dir, WithCompressor(compress.S2()))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1210,6 +1412,71 @@ func TestFilePersist_Compression_IsolatedNamespaces(t *testing.T) {
 	}
 }
 
+func TestFilePersist_Codec(t *testing.T) {
+	type user struct {
+		Name  string
+		Roles []string
+	}
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	fp, err := New[string, user]("cache", dir, WithCodec(CodecGob), WithCompressor(compress.S2()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer fp.Close() //nolint:errcheck // Test cleanup
+
+	want := user{Name: "alice", Roles: []string{"admin"}}
+	if err := fp.Set(ctx, "user:alice", want, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ext := filepath.Ext(fp.Location("user:alice")); ext != ".gs" {
+		t.Errorf("gob+S2 extension = %q; want .gs", ext)
+	}
+
+	// A store reopened with the same options reads the file back.
+	fp2, err := New[string, user]("cache", dir, WithCodec(CodecGob), WithCompressor(compress.S2()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, _, found, err := fp2.Get(ctx, "user:alice")
+	if err != nil || !found || got.Name != want.Name || !slices.Equal(got.Roles, want.Roles) {
+		t.Errorf("Get = %+v, %v, %v; want %+v", got, found, err, want)
+	}
+	if keys := slices.Collect(fp2.Keys(ctx, "user:")); !slices.Equal(keys, []string{"user:alice"}) {
+		t.Errorf("Keys = %v; want [user:alice]", keys)
+	}
+	for k, v := range fp2.Range(ctx, "user:") {
+		if k != "user:alice" || v.Name != "alice" {
+			t.Errorf("Range yielded %q = %+v", k, v)
+		}
+	}
+
+	// A JSON store with the same compressor decodes the gob file as gob, through the
+	// fallback formats, but doesn't count it as its own.
+	fpJSON, err := New[string, user]("cache", dir, WithCompressor(compress.S2()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, _, found, err := fpJSON.Get(ctx, "user:alice"); !found || err != nil || got.Name != want.Name {
+		t.Errorf("JSON store Get = %+v, %v, %v; want %+v", got, found, err, want)
+	}
+	if n, _ := fpJSON.Len(ctx); n != 0 { //nolint:errcheck // Test helper
+		t.Errorf("JSON store Len = %d; want 0", n)
+	}
+
+	fpGob, err := New[string, user]("plain", dir, WithCodec(CodecGob))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if ext := filepath.Ext(fpGob.Location("k")); ext != ".g" {
+		t.Errorf("gob extension = %q; want .g", ext)
+	}
+	if _, err := New[string, user]("bad", dir, WithCodec(Codec(42))); err == nil {
+		t.Error("New should reject an unknown codec")
+	}
+}
+
 func TestFilePersist_Keys(t *testing.T) {
 	dir := t.TempDir()
 	fp, err := New[string, string]("test", dir)
@@ -1401,6 +1668,38 @@ func TestFilePersist_Range_SkipsExpired(t *testing.T) {
 	}
 }
 
+func TestFilePersist_Keys_SkipsValueDecode(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// Write string values, then list them through a store whose value type can't decode them.
+	writer, err := New[string, string]("test", dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := writer.Set(ctx, "user:1", "not-an-int", time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := writer.Set(ctx, "user:2", "expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	reader, err := New[string, int]("test", dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var keys []string
+	for k := range reader.Keys(ctx, "user:") {
+		keys = append(keys, k)
+	}
+	if len(keys) != 1 || keys[0] != "user:1" {
+		t.Errorf("Keys() = %v; want [user:1] without decoding values", keys)
+	}
+	for k := range reader.Range(ctx, "user:") {
+		t.Errorf("Range() yielded %q; undecodable values should be skipped", k)
+	}
+}
+
 func TestFilePersist_Keys_ContextCancellation(t *testing.T) {
 	dir := t.TempDir()
 	fp, err := New[string, int]("test", dir)
@@ -1441,7 +1740,7 @@ func TestFilePersist_Compression_CleanupRespectExtension(t *testing.T) {
 	ctx := context.Background()
 
 	// Create S2 store and add entries
-	fp, err := New[string, int]("cache", dir, compress.S2())
+	fp, err := New[string, int]("cache", dir, WithCompressor(compress.S2()))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1495,7 +1794,7 @@ func TestFilePersist_Compression_FlushRespectExtension(t *testing.T) {
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
-	fpS2, err := New[string, int]("cache", dir, compress.S2())
+	fpS2, err := New[string, int]("cache", dir, WithCompressor(compress.S2()))
 	if err != nil {
 		t.Fatalf("New: %v", err)
 	}
@@ -1533,3 +1832,257 @@ func TestFilePersist_Compression_FlushRespectExtension(t *testing.T) {
 		t.Errorf("S2 Len = %d; want 5 (should not be affected by None flush)", n)
 	}
 }
+
+func TestFilePersist_Compression_FallbackFormats(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// Entries written before the store was reopened with other options
+	old, err := New[string, int]("cache", dir, WithCodec(CodecGob), WithCompressor(compress.S2()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer old.Close() //nolint:errcheck // test cleanup
+	for i := range 3 {
+		if err := old.Set(ctx, fmt.Sprintf("key-%d", i), i, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := old.Set(ctx, "expired", 9, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	fp, err := New[string, int]("cache", dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer fp.Close() //nolint:errcheck // test cleanup
+
+	v, _, found, err := fp.Get(ctx, "key-1")
+	if err != nil || !found || v != 1 {
+		t.Errorf("Get(key-1) = %d, %v, %v; want 1 from the gob+S2 file", v, found, err)
+	}
+
+	// The store's own format wins once the key is rewritten
+	if err := fp.Set(ctx, "key-2", 20, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, _, _, _ := fp.Get(ctx, "key-2"); v != 20 { //nolint:errcheck // checked via value
+		t.Errorf("Get(key-2) = %d; want 20", v)
+	}
+
+	// Delete removes the fallback copy too, so the old value can't come back
+	if err := fp.Delete(ctx, "key-0"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, found, _ := fp.Get(ctx, "key-0"); found { //nolint:errcheck // checked via found
+		t.Error("Get(key-0) found a value after Delete")
+	}
+
+	// Expired fallback files are misses, and left alone like the rest
+	if _, _, found, err := fp.Get(ctx, "expired"); err != nil || found {
+		t.Errorf("Get(expired) = %v, %v; want miss", found, err)
+	}
+	if _, err := fp.Cleanup(ctx, 0); err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if _, err := fp.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	for _, k := range []string{"key-1", "expired"} {
+		if _, err := os.Stat(old.Location(k)); err != nil {
+			t.Errorf("other store's file for %s was removed: %v", k, err)
+		}
+	}
+	if n, err := old.Len(ctx); err != nil || n != 3 {
+		t.Errorf("old Len = %d, %v; want 3", n, err)
+	}
+}
+
+func TestFilePersist_LoadRecent(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := fp.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	for i, key := range []string{"oldest", "middle", "expired", "newest"} {
+		expiry := time.Time{}
+		if key == "expired" {
+			expiry = time.Now().Add(-time.Hour)
+		}
+		if err := fp.Set(ctx, key, i, expiry); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+		time.Sleep(2 * time.Millisecond) // distinct UpdatedAt
+	}
+
+	var got []string
+	if err := fp.LoadRecent(ctx, 2, func(key string, _ int, _ time.Time) bool {
+		got = append(got, key)
+		return true
+	}); err != nil {
+		t.Fatalf("LoadRecent: %v", err)
+	}
+	if want := []string{"newest", "middle"}; !slices.Equal(got, want) {
+		t.Errorf("LoadRecent(limit=2) = %v; want %v", got, want)
+	}
+
+	got = nil
+	if err := fp.LoadRecent(ctx, 0, func(key string, _ int, _ time.Time) bool {
+		got = append(got, key)
+		return true
+	}); err != nil {
+		t.Fatalf("LoadRecent: %v", err)
+	}
+	if want := []string{"newest", "middle", "oldest"}; !slices.Equal(got, want) {
+		t.Errorf("LoadRecent(limit=0) = %v; want %v", got, want)
+	}
+
+	// Returning false stops iteration.
+	n := 0
+	if err := fp.LoadRecent(ctx, 0, func(string, int, time.Time) bool {
+		n++
+		return false
+	}); err != nil {
+		t.Fatalf("LoadRecent: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("callback calls after returning false = %d; want 1", n)
+	}
+}
+
+func TestFilePersist_Ping(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := fp.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	if err := fp.Ping(ctx); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+
+	if err := os.RemoveAll(fp.Dir); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if err := fp.Ping(ctx); err == nil {
+		t.Error("Ping should fail once the cache directory is gone")
+	}
+}
+
+// versionedValue has a hand-rolled binary format; its JSON form would be empty.
+type versionedValue struct {
+	name string
+	ver  byte
+}
+
+func (v versionedValue) MarshalBinary() ([]byte, error) {
+	return append([]byte{v.ver}, v.name...), nil
+}
+
+func (v *versionedValue) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("empty")
+	}
+	v.ver, v.name = data[0], string(data[1:])
+	return nil
+}
+
+func TestFilePersist_BinaryMarshaler(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, versionedValue](filepath.Base(dir), filepath.Dir(dir), WithCompressor(compress.S2()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := fp.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	want := versionedValue{name: "alice", ver: 2}
+	if err := fp.Set(ctx, "k", want, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, _, found, err := fp.Get(ctx, "k")
+	if err != nil || !found {
+		t.Fatalf("Get: found=%v err=%v", found, err)
+	}
+	if got != want {
+		t.Errorf("Get = %+v; want %+v", got, want)
+	}
+
+	n := 0
+	if err := fp.LoadRecent(ctx, 0, func(_ string, v versionedValue, _ time.Time) bool {
+		n++
+		if v != want {
+			t.Errorf("LoadRecent value = %+v; want %+v", v, want)
+		}
+		return true
+	}); err != nil {
+		t.Fatalf("LoadRecent: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("LoadRecent visited %d entries; want 1", n)
+	}
+}
+
+type profile struct {
+	Name  string
+	Email string
+}
+
+func TestFilePersist_SchemaMigration(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, profile](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := fp.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	// Written before profile was versioned, so stored as plain JSON.
+	if err := fp.Set(ctx, "old", profile{Name: "alice"}, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	compress.RegisterMigration[profile](0, 1, func(old []byte) ([]byte, error) {
+		return bytes.Replace(old, []byte(`"Email":""`), []byte(`"Email":"none"`), 1), nil
+	})
+
+	got, _, found, err := fp.Get(ctx, "old")
+	if err != nil || !found {
+		t.Fatalf("Get(old): found=%v err=%v", found, err)
+	}
+	if want := (profile{Name: "alice", Email: "none"}); got != want {
+		t.Errorf("Get(old) = %+v; want migrated %+v", got, want)
+	}
+
+	want := profile{Name: "bob", Email: "bob@example.com"}
+	if err := fp.Set(ctx, "new", want, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, _, found, err = fp.Get(ctx, "new")
+	if err != nil || !found || got != want {
+		t.Errorf("Get(new) = %+v, %v, %v; want %+v", got, found, err, want)
+	}
+}
@@ -2,15 +2,20 @@
 package localfs
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"iter"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -26,9 +31,159 @@ type Entry[K comparable, V any] struct {
 	UpdatedAt time.Time
 }
 
+// binaryEntry is the on-disk form of Entry for value types with their own binary
+// encoding or a schema version (see compress.Binary and compress.Versioned); Value
+// holds the MarshalValue output.
+type binaryEntry[K comparable] struct {
+	Key       K
+	Value     []byte
+	Expiry    time.Time
+	UpdatedAt time.Time
+}
+
+// rawEntry defers decoding Value, which may be a binaryEntry value or, for entries
+// written before V was versioned, plain JSON.
+type rawEntry[K comparable] struct {
+	Key       K
+	Value     json.RawMessage
+	Expiry    time.Time
+	UpdatedAt time.Time
+}
+
+// keyEntry is the subset of an encoded entry Keys needs; the value is never decoded.
+type keyEntry[K comparable] struct {
+	Key    K
+	Expiry time.Time
+}
+
+// Codec selects how entries are serialized before compression.
+type Codec uint8
+
+const (
+	// CodecJSON serializes entries as JSON. This is the default.
+	CodecJSON Codec = iota
+	// CodecGob serializes entries with encoding/gob, which is usually faster to decode
+	// and smaller than JSON for Go structs. Values holding interface types need their
+	// concrete types registered with gob.Register.
+	CodecGob
+)
+
+func (c Codec) marshal(v any) ([]byte, error) {
+	if c == CodecGob {
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(v)
+		return buf.Bytes(), err
+	}
+	return json.Marshal(v)
+}
+
+func (c Codec) unmarshal(data []byte, v any) error {
+	if c == CodecGob {
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// extension returns the file extension for entries written with this codec and a
+// compressor with extension ext. JSON keeps the compressor's extension, so existing
+// stores keep their files; gob prefixes it with "g" (e.g. ".gs" for gob with S2).
+func (c Codec) extension(ext string) string {
+	if c != CodecGob {
+		if ext == "" {
+			return ".j"
+		}
+		return ext
+	}
+	if ext == ".j" {
+		ext = ""
+	}
+	return ".g" + strings.TrimPrefix(ext, ".")
+}
+
+// encodeEntry marshals e with codec c, with the value in its MarshalValue form when V
+// has a binary encoding or schema version.
+func encodeEntry[K comparable, V any](c Codec, e Entry[K, V]) ([]byte, error) {
+	if !compress.Binary[V]() && !compress.Versioned[V]() {
+		return c.marshal(e)
+	}
+	v, err := compress.MarshalValue(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshal(binaryEntry[K]{Key: e.Key, Value: v, Expiry: e.Expiry, UpdatedAt: e.UpdatedAt})
+}
+
+// decodeEntry reverses encodeEntry.
+func decodeEntry[K comparable, V any](c Codec, data []byte) (Entry[K, V], error) {
+	var e Entry[K, V]
+	if !compress.Binary[V]() && !compress.Versioned[V]() {
+		err := c.unmarshal(data, &e)
+		return e, err
+	}
+	if c == CodecGob {
+		// Gob stores were never written without the MarshalValue form.
+		var b binaryEntry[K]
+		if err := c.unmarshal(data, &b); err != nil {
+			return e, err
+		}
+		if err := compress.UnmarshalValue(b.Value, &e.Value); err != nil {
+			return e, err
+		}
+		e.Key, e.Expiry, e.UpdatedAt = b.Key, b.Expiry, b.UpdatedAt
+		return e, nil
+	}
+	var r rawEntry[K]
+	if err := json.Unmarshal(data, &r); err != nil {
+		return e, err
+	}
+	v := []byte(r.Value)
+	var b []byte
+	if err := json.Unmarshal(r.Value, &b); err == nil && (compress.Binary[V]() || compress.HasVersion(b)) {
+		v = b
+	}
+	if err := compress.UnmarshalValue(v, &e.Value); err != nil {
+		return e, err
+	}
+	e.Key, e.Expiry, e.UpdatedAt = r.Key, r.Expiry, r.UpdatedAt
+	return e, nil
+}
+
+// format is a codec and compressor pair, named on disk by its file extension.
+type format struct {
+	codec Codec
+	comp  compress.Compressor
+	ext   string
+}
+
+// fallbackFormats lists every format Get can read besides a store's own: both codecs
+// with no compression, S2, Zstd or LZ4, alone or wrapped in Auto. ZstdDict files are
+// missing because they can only be decoded with the dictionary they were written with.
+var fallbackFormats = sync.OnceValue(func() []format {
+	var comps []compress.Compressor
+	for _, c := range []compress.Compressor{compress.None(), compress.Plain(), compress.S2(), compress.Zstd(1), compress.LZ4()} {
+		comps = append(comps, c, compress.Auto(0, c))
+	}
+	var fs []format
+	seen := make(map[string]bool)
+	for _, codec := range []Codec{CodecJSON, CodecGob} {
+		for _, c := range comps {
+			ext := codec.extension(c.Extension())
+			if !seen[ext] {
+				seen[ext] = true
+				fs = append(fs, format{codec: codec, comp: c, ext: ext})
+			}
+		}
+	}
+	return fs
+})
+
 const maxKeyLength = 127 // Maximum key length to avoid filesystem constraints
 
-// Store implements file-based persistence using local files with JSON encoding.
+// orphanTmpAge is how old a Set temp file must be before Cleanup treats it as left
+// behind by a crashed write and removes it.
+const orphanTmpAge = time.Hour
+
+// Store implements file-based persistence using local files with JSON or gob encoding.
 //
 //nolint:govet // fieldalignment - current layout groups related fields logically (mutex with map it protects)
 type Store[K comparable, V any] struct {
@@ -36,14 +191,87 @@ type Store[K comparable, V any] struct {
 	Dir         string              // Exported for testing - directory path
 	subdirsMade map[string]bool     // Cache of created subdirectories
 	compressor  compress.Compressor // Compression algorithm
-	ext         string              // File extension based on compressor
+	codec       Codec               // Entry serialization
+	ext         string              // File extension based on codec and compressor
+	fallback    []format            // Other formats Get reads when a key has no file in ext
+	checksum    bool                // Write a CRC32C header to new files
+	sync        bool                // fsync files before renaming them into place
+	fanout      int                 // Subdirectory levels under Dir
+}
+
+type options struct {
+	compressor compress.Compressor
+	codec      Codec
+	checksum   bool
+	sync       bool
+	fanout     int
+}
+
+// Option configures a Store.
+type Option func(*options)
+
+// WithCompressor compresses entries with c (default: no compression).
+func WithCompressor(c compress.Compressor) Option {
+	return func(o *options) {
+		o.compressor = c
+	}
+}
+
+// WithCodec serializes entries with c (default: CodecJSON).
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithChecksum controls whether new files start with a CRC32C checksum of their contents
+// (default: true). Files with a checksum are verified whenever they are read, whatever
+// this setting, so it can be changed without discarding the store.
+func WithChecksum(enabled bool) Option {
+	return func(o *options) {
+		o.checksum = enabled
+	}
+}
+
+// WithSync makes Set fsync each file before renaming it into place, and the directory
+// after (default: false). Set is atomic either way; with sync, a completed Set also
+// survives power loss, at the cost of a disk flush per write.
+func WithSync(enabled bool) Option {
+	return func(o *options) {
+		o.sync = enabled
+	}
+}
+
+// maxFanout bounds WithFanout: 4 levels of 256 directories each is already far more
+// directories than any cache has files.
+const maxFanout = 4
+
+// WithFanout sets how many levels of subdirectories files are spread over (default: 1).
+// Each level is named by the next 2 hex characters of the key hash, so it splits files
+// 256 ways: 1 level keeps 1M entries to about 4K files per directory, 2 levels to 16.
+// 0 stores every file directly in the cache directory.
+//
+// Get, Set and Delete only look at the configured layout, so changing the fanout of an
+// existing store makes its old entries unreachable to them. Cleanup, Len and Flush walk
+// every level, so Flush still removes them.
+func WithFanout(levels int) Option {
+	return func(o *options) {
+		o.fanout = levels
+	}
 }
 
 // New creates a new file-based persistence layer.
 // The cacheID is used as a subdirectory name under the OS cache directory.
 // If dir is provided (non-empty), it's used as the base directory instead of OS cache dir.
-// Optional compressor enables compression (default: no compression, plain JSON with .j extension).
-func New[K comparable, V any](cacheID, dir string, c ...compress.Compressor) (*Store[K, V], error) {
+// By default entries are plain JSON with the .j extension; see WithCompressor and WithCodec.
+//
+// The file extension records both the codec and the compressor, so every file is decoded
+// the way it was written. Stores with different options in the same directory keep
+// separate entries rather than misreading each other's files. When a key has no file
+// in the store's own format, Get falls back to the other formats it knows (see
+// fallbackFormats), so a store reopened with new options still reads entries written
+// with the old ones until they are rewritten.
+func New[K comparable, V any](cacheID, dir string, opts ...Option) (*Store[K, V], error) {
 	if cacheID == "" {
 		return nil, errors.New("cacheID cannot be empty")
 	}
@@ -54,9 +282,19 @@ func New[K comparable, V any](cacheID, dir string, c ...compress.Compressor) (*S
 		return nil, errors.New("invalid cacheID: contains null byte")
 	}
 
-	comp := compress.None()
-	if len(c) > 0 && c[0] != nil {
-		comp = c[0]
+	o := options{checksum: true, fanout: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.codec != CodecJSON && o.codec != CodecGob {
+		return nil, fmt.Errorf("unknown codec %d", o.codec)
+	}
+	if o.fanout < 0 || o.fanout > maxFanout {
+		return nil, fmt.Errorf("invalid fanout %d: must be 0 to %d", o.fanout, maxFanout)
+	}
+	comp := o.compressor
+	if comp == nil {
+		comp = compress.None()
 	}
 
 	var fullDir string
@@ -80,19 +318,68 @@ func New[K comparable, V any](cacheID, dir string, c ...compress.Compressor) (*S
 	}
 	_ = os.Remove(testFile) //nolint:errcheck // best-effort cleanup
 
-	ext := comp.Extension()
-	if ext == "" {
-		ext = ".j"
+	ext := o.codec.extension(comp.Extension())
+	var fallback []format
+	for _, f := range fallbackFormats() {
+		if f.ext != ext {
+			fallback = append(fallback, f)
+		}
 	}
 
 	return &Store[K, V]{
 		Dir:         fullDir,
 		subdirsMade: make(map[string]bool),
 		compressor:  comp,
+		codec:       o.codec,
 		ext:         ext,
+		fallback:    fallback,
+		checksum:    o.checksum,
+		sync:        o.sync,
+		fanout:      o.fanout,
 	}, nil
 }
 
+// checksumMagic starts files whose next 4 bytes are a big-endian CRC32C of the rest of
+// the file. Files without it were written before checksums or with WithChecksum(false).
+var checksumMagic = []byte{0xff, 'f', 'c', 1}
+
+const checksumHeaderLen = 8
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errChecksum reports a file whose contents don't match its checksum.
+var errChecksum = errors.New("checksum mismatch")
+
+// seal prepends the checksum header to data.
+func seal(data []byte) []byte {
+	buf := make([]byte, checksumHeaderLen, checksumHeaderLen+len(data))
+	copy(buf, checksumMagic)
+	binary.BigEndian.PutUint32(buf[len(checksumMagic):], crc32.Checksum(data, crcTable))
+	return append(buf, data...)
+}
+
+// payload verifies and strips the checksum header of file contents, if any, and
+// decompresses the rest.
+func (s *Store[K, V]) payload(data []byte) ([]byte, error) {
+	return unseal(s.compressor, data)
+}
+
+// unseal is payload for a file written with compressor c.
+func unseal(c compress.Compressor, data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, checksumMagic) && len(data) >= checksumHeaderLen {
+		sum := binary.BigEndian.Uint32(data[len(checksumMagic):])
+		data = data[checksumHeaderLen:]
+		if crc32.Checksum(data, crcTable) != sum {
+			return nil, errChecksum
+		}
+	}
+	out, err := c.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("decompress: %w", err)
+	}
+	return out, nil
+}
+
 // ValidateKey checks if a key is valid for file persistence.
 // Since keys are hashed to SHA256, any characters are allowed.
 // Only length is validated to prevent memory issues.
@@ -108,12 +395,17 @@ func (*Store[K, V]) ValidateKey(key K) error {
 }
 
 // keyToFilename converts a cache key to a filename with squid-style directory layout.
-// Hashes the key and uses first 2 characters of hex hash as subdirectory for even distribution
-// (e.g., key "mykey" -> "a3/a3f2....j" or "a3/a3f2....s" with S2 compression).
+// Hashes the key and uses successive 2-character pairs of the hex hash as subdirectories,
+// one per fanout level, for even distribution (e.g., key "mykey" -> "a3/a3f2....j" at the
+// default fanout of 1, or "a3/f2/a3f2....s" at fanout 2 with S2 compression).
 func (s *Store[K, V]) keyToFilename(key K) string {
 	sum := sha256.Sum256(fmt.Appendf(nil, "%v", key))
 	h := hex.EncodeToString(sum[:])
-	return filepath.Join(h[:2], h+s.ext)
+	parts := make([]string, 0, s.fanout+1)
+	for i := range s.fanout {
+		parts = append(parts, h[2*i:2*i+2])
+	}
+	return filepath.Join(append(parts, h+s.ext)...)
 }
 
 // Location returns the full file path where a key is stored.
@@ -131,24 +423,24 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time
 	data, err := os.ReadFile(fn)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return zero, time.Time{}, false, nil
+			return s.getFallback(key)
 		}
 		return zero, time.Time{}, false, fmt.Errorf("read file: %w", err)
 	}
 
-	jsonData, err := s.compressor.Decode(data)
+	jsonData, err := s.payload(data)
 	if err != nil {
 		rmErr := os.Remove(fn)
-		return zero, time.Time{}, false, errors.Join(fmt.Errorf("decompress: %w", err), rmErr)
+		return zero, time.Time{}, false, &compress.DecodeError{Err: errors.Join(err, rmErr)}
 	}
 
-	var e Entry[K, V]
-	if err := json.Unmarshal(jsonData, &e); err != nil {
+	e, err := decodeEntry[K, V](s.codec, jsonData)
+	if err != nil {
 		rmErr := os.Remove(fn)
-		return zero, time.Time{}, false, errors.Join(
+		return zero, time.Time{}, false, &compress.DecodeError{Err: errors.Join(
 			fmt.Errorf("decode file: %w", err),
 			rmErr,
-		)
+		)}
 	}
 
 	if !e.Expiry.IsZero() && time.Now().After(e.Expiry) {
@@ -161,6 +453,34 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time
 	return e.Value, e.Expiry, true, nil
 }
 
+// getFallback looks for key in the store's fallback formats. Files found there are
+// only read: one that is corrupt or expired is a miss, and is left for its own store.
+//
+//nolint:revive // function-result-limit - mirrors Get
+func (s *Store[K, V]) getFallback(key K) (value V, expiry time.Time, found bool, err error) {
+	var zero V
+	base := strings.TrimSuffix(s.Location(key), s.ext)
+	for _, f := range s.fallback {
+		data, err := os.ReadFile(base + f.ext)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return zero, time.Time{}, false, fmt.Errorf("read file: %w", err)
+		}
+		raw, err := unseal(f.comp, data)
+		if err != nil {
+			return zero, time.Time{}, false, nil
+		}
+		e, err := decodeEntry[K, V](f.codec, raw)
+		if err != nil || (!e.Expiry.IsZero() && time.Now().After(e.Expiry)) {
+			return zero, time.Time{}, false, nil
+		}
+		return e.Value, e.Expiry, true, nil
+	}
+	return zero, time.Time{}, false, nil
+}
+
 // Set saves a value to a file.
 func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
 	fn := filepath.Join(s.Dir, s.keyToFilename(key))
@@ -194,7 +514,7 @@ func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time)
 		UpdatedAt: time.Now(),
 	}
 
-	jsonData, err := json.Marshal(e)
+	jsonData, err := encodeEntry(s.codec, e)
 	if err != nil {
 		return fmt.Errorf("encode entry: %w", err)
 	}
@@ -203,11 +523,28 @@ func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time)
 	if err != nil {
 		return fmt.Errorf("compress: %w", err)
 	}
+	if s.checksum {
+		data = seal(data)
+	}
 
-	// Write to temp file first, then rename for atomicity
-	tmp := fn + ".tmp"
-	if err := os.WriteFile(tmp, data, 0o600); err != nil {
-		return fmt.Errorf("write temp file: %w", err)
+	// Write to a temp file unique to this call, then rename it over fn. Rename is atomic,
+	// so readers and a crashed process's successor see the old file or the new one, never
+	// a partial write. A shared temp name would let concurrent Sets of a key interleave.
+	f, err := os.CreateTemp(dir, filepath.Base(fn)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil && s.sync {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		rmErr := os.Remove(tmp)
+		return errors.Join(fmt.Errorf("write temp file: %w", err), rmErr)
 	}
 
 	// Atomic rename
@@ -216,16 +553,38 @@ func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time)
 		return errors.Join(fmt.Errorf("rename file: %w", err), rmErr)
 	}
 
+	if s.sync {
+		syncDir(dir)
+	}
 	return nil
 }
 
-// Delete removes a file.
+// syncDir flushes a directory's entries to disk, so a rename into it survives a crash.
+// Best-effort: not every platform can sync a directory.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()  //nolint:errcheck // unsupported on some platforms, e.g. Windows
+	_ = d.Close() //nolint:errcheck // read-only handle
+}
+
+// Delete removes a file, along with the key's files in fallback formats so Get can't
+// fall back to an older value.
 func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
 	fn := filepath.Join(s.Dir, s.keyToFilename(key))
 	if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("remove file: %w", err)
 	}
-	return nil
+	base := strings.TrimSuffix(fn, s.ext)
+	var errs []error
+	for _, f := range s.fallback {
+		if err := os.Remove(base + f.ext); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("remove file: %w", err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // isCacheFile returns true if the file matches the store's cache file extension.
@@ -233,11 +592,37 @@ func (s *Store[K, V]) isCacheFile(name string) bool {
 	return filepath.Ext(name) == s.ext
 }
 
+// isTempFile reports whether name is a temp file Set writes before renaming it into
+// place: an entry file name followed by ".<random>.tmp".
+func isTempFile(name string) bool {
+	rest, ok := strings.CutSuffix(name, ".tmp")
+	if !ok {
+		return false
+	}
+	i := strings.LastIndexByte(rest, '.')
+	return i > 0 && isEntryFile(rest[:i])
+}
+
+// isEntryFile reports whether name is an entry file written with any codec and
+// compressor: a hex key hash followed by an extension.
+func isEntryFile(name string) bool {
+	ext := filepath.Ext(name)
+	h := strings.TrimSuffix(name, ext)
+	if ext == "" || len(h) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(h)
+	return err == nil
+}
+
 // Cleanup removes expired entries from file storage.
-// Walks through all cache files and deletes those with expired timestamps.
+// Walks through all cache files and deletes those with expired timestamps. Files in
+// other formats are left to the stores that write them. Temp files more than an hour
+// old, left by writes interrupted before their rename, are removed too but not counted.
 // Returns the count of deleted entries and any errors encountered.
 func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
 	cutoff := time.Now().Add(-maxAge)
+	tmpCutoff := time.Now().Add(-orphanTmpAge)
 	n := 0
 	var errs []error
 
@@ -255,6 +640,13 @@ func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, e
 			return nil
 		}
 
+		if !fi.IsDir() && isTempFile(fi.Name()) && fi.ModTime().Before(tmpCutoff) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("remove %s: %w", path, err))
+			}
+			return nil
+		}
+
 		// Skip directories and non-matching files
 		if fi.IsDir() || !s.isCacheFile(fi.Name()) {
 			return nil
@@ -266,14 +658,14 @@ func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, e
 			return nil
 		}
 
-		jsonData, err := s.compressor.Decode(data)
+		jsonData, err := s.payload(data)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("decompress %s: %w", path, err))
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
 			return nil
 		}
 
-		var e Entry[K, V]
-		if err := json.Unmarshal(jsonData, &e); err != nil {
+		e, err := decodeEntry[K, V](s.codec, jsonData)
+		if err != nil {
 			errs = append(errs, fmt.Errorf("decode %s: %w", path, err))
 			return nil
 		}
@@ -372,13 +764,52 @@ func (*Store[K, V]) Close() error {
 
 // Keys returns an iterator over keys matching prefix.
 // Implements PrefixScanner[V] interface (only usable when K is string).
+// Files are still read, but only the key and expiry are decoded, not the value.
 func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string] {
 	return func(yield func(string) bool) {
-		for k := range s.Range(ctx, prefix) {
-			if !yield(k) {
-				return
+		//nolint:errcheck // Walk errors are benign - we skip problematic files
+		_ = filepath.Walk(s.Dir, func(path string, fi os.FileInfo, err error) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
 			}
-		}
+
+			//nolint:nilerr // Skip files with errors
+			if err != nil || fi.IsDir() || !s.isCacheFile(fi.Name()) {
+				return nil
+			}
+
+			b, err := os.ReadFile(path)
+			//nolint:nilerr // Skip unreadable files
+			if err != nil {
+				return nil
+			}
+			data, err := s.payload(b)
+			//nolint:nilerr // Skip corrupted files
+			if err != nil {
+				return nil
+			}
+
+			// Fields not in keyEntry, including Value, are skipped by both decoders.
+			var e keyEntry[K]
+			//nolint:nilerr // Skip malformed files
+			if err := s.codec.unmarshal(data, &e); err != nil {
+				return nil
+			}
+			if !e.Expiry.IsZero() && time.Now().After(e.Expiry) {
+				return nil
+			}
+
+			name := fmt.Sprintf("%v", e.Key)
+			if !strings.HasPrefix(name, prefix) {
+				return nil
+			}
+			if !yield(name) {
+				return filepath.SkipAll
+			}
+			return nil
+		})
 	}
 }
 
@@ -408,15 +839,15 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 				return nil
 			}
 
-			data, err := s.compressor.Decode(b)
+			data, err := s.payload(b)
 			//nolint:nilerr // Skip corrupted files
 			if err != nil {
 				return nil
 			}
 
-			var e Entry[K, V]
+			e, err := decodeEntry[K, V](s.codec, data)
 			//nolint:nilerr // Skip malformed files
-			if err := json.Unmarshal(data, &e); err != nil {
+			if err != nil {
 				return nil
 			}
 
@@ -441,3 +872,80 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 		})
 	}
 }
+
+// readEntry reads and decodes the cache file at path.
+func (s *Store[K, V]) readEntry(path string) (Entry[K, V], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry[K, V]{}, fmt.Errorf("read file: %w", err)
+	}
+	jsonData, err := s.payload(data)
+	if err != nil {
+		return Entry[K, V]{}, &compress.DecodeError{Err: err}
+	}
+	e, err := decodeEntry[K, V](s.codec, jsonData)
+	if err != nil {
+		return e, &compress.DecodeError{Err: fmt.Errorf("decode file: %w", err)}
+	}
+	return e, nil
+}
+
+// LoadRecent calls fn for up to limit non-expired entries, most recently updated first.
+// Implements fido.RecentLoader. Every file is read to sort by update time, so the cost
+// grows with the size of the store rather than with limit.
+func (s *Store[K, V]) LoadRecent(ctx context.Context, limit int, fn func(key K, value V, expiry time.Time) bool) error {
+	var entries []Entry[K, V]
+	now := time.Now()
+
+	walkErr := filepath.Walk(s.Dir, func(path string, fi os.FileInfo, err error) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		//nolint:nilerr // Skip files with errors
+		if err != nil || fi.IsDir() || !s.isCacheFile(fi.Name()) {
+			return nil
+		}
+
+		e, err := s.readEntry(path)
+		//nolint:nilerr // Skip unreadable or corrupted files
+		if err != nil {
+			return nil
+		}
+		if !e.Expiry.IsZero() && now.After(e.Expiry) {
+			return nil
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if walkErr != nil {
+		return fmt.Errorf("walk directory: %w", walkErr)
+	}
+
+	slices.SortFunc(entries, func(a, b Entry[K, V]) int { return b.UpdatedAt.Compare(a.UpdatedAt) })
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	for _, e := range entries {
+		if !fn(e.Key, e.Value, e.Expiry) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Ping checks that the cache directory exists and is a directory.
+// Implements fido.HealthChecker.
+func (s *Store[K, V]) Ping(_ context.Context) error {
+	fi, err := os.Stat(s.Dir)
+	if err != nil {
+		return fmt.Errorf("stat cache dir: %w", err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("cache dir %s is not a directory", s.Dir)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+module github.com/codeGROOVE-dev/fido/pkg/store/sqlite
+
+go 1.25.4
+
+require github.com/codeGROOVE-dev/fido/pkg/store/compress v1.10.0
+
+require (
+	github.com/klauspost/compress v1.18.3 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
+)
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/compress => ../compress
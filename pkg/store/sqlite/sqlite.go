@@ -0,0 +1,326 @@
+// Package sqlite provides single-file SQLite persistence for fido.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+)
+
+// Store implements persistence in one SQLite table per cacheID.
+// It works with any database/sql SQLite driver, which the caller registers and opens.
+type Store[K comparable, V any] struct {
+	db         *sql.DB
+	compressor compress.Compressor
+	table      string
+
+	// Statements built once from table.
+	getSQL     string
+	setSQL     string
+	deleteSQL  string
+	cleanupSQL string
+	flushSQL   string
+	lenSQL     string
+	keysSQL    string
+	rangeSQL   string
+	hotSQL     string
+}
+
+// New creates a SQLite-backed persistence layer, creating its table if needed.
+// The cacheID names the table ("fido_" + cacheID), so it may contain only ASCII letters,
+// digits and underscores. db must be opened with a SQLite driver, for example
+// sql.Open("sqlite", "cache.db") with modernc.org/sqlite. The caller owns db: Close
+// does not close it.
+// Optional compressor enables compression of stored values (default: no compression).
+//
+// Get counts reads per entry for LoadByFrequency with an UPDATE ... RETURNING, so the
+// database must be SQLite 3.35 or later, and every hit is a write.
+func New[K comparable, V any](ctx context.Context, db *sql.DB, cacheID string, c ...compress.Compressor) (*Store[K, V], error) {
+	if db == nil {
+		return nil, errors.New("db cannot be nil")
+	}
+	if cacheID == "" {
+		return nil, errors.New("cacheID cannot be empty")
+	}
+	for _, r := range cacheID {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return nil, fmt.Errorf("invalid cacheID %q: only letters, digits and underscores are allowed", cacheID)
+		}
+	}
+
+	comp := compress.None()
+	if len(c) > 0 && c[0] != nil {
+		comp = c[0]
+	}
+
+	t := `"fido_` + cacheID + `"`
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS ` + t + ` (key TEXT PRIMARY KEY, value BLOB NOT NULL, expiry INTEGER NOT NULL, updated_at INTEGER NOT NULL, reads INTEGER NOT NULL DEFAULT 0)`,
+		`CREATE INDEX IF NOT EXISTS "fido_` + cacheID + `_expiry" ON ` + t + ` (expiry)`,
+	}
+	for _, q := range schema {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return nil, fmt.Errorf("create table: %w", err)
+		}
+	}
+	// Tables created before read counting lack the reads column.
+	var hasReads int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('fido_`+cacheID+`') WHERE name = 'reads'`).Scan(&hasReads); err != nil {
+		return nil, fmt.Errorf("inspect table: %w", err)
+	}
+	if hasReads == 0 {
+		if _, err := db.ExecContext(ctx, `ALTER TABLE `+t+` ADD COLUMN reads INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return nil, fmt.Errorf("add reads column: %w", err)
+		}
+	}
+
+	// Expiry and updated_at are Unix nanoseconds; an expiry of 0 never expires.
+	live := `(expiry = 0 OR expiry > ?)`
+	prefixed := `substr(key, 1, ?) = ?`
+	return &Store[K, V]{
+		db:         db,
+		compressor: comp,
+		table:      t,
+		getSQL:     `UPDATE ` + t + ` SET reads = reads + 1 WHERE key = ? AND ` + live + ` RETURNING value, expiry`,
+		setSQL: `INSERT INTO ` + t + ` (key, value, expiry, updated_at) VALUES (?, ?, ?, ?)` +
+			` ON CONFLICT(key) DO UPDATE SET value = excluded.value, expiry = excluded.expiry, updated_at = excluded.updated_at`,
+		deleteSQL:  `DELETE FROM ` + t + ` WHERE key = ?`,
+		cleanupSQL: `DELETE FROM ` + t + ` WHERE expiry != 0 AND expiry < ?`,
+		flushSQL:   `DELETE FROM ` + t,
+		lenSQL:     `SELECT COUNT(*) FROM ` + t,
+		keysSQL:    `SELECT key FROM ` + t + ` WHERE ` + prefixed + ` AND ` + live + ` ORDER BY key`,
+		rangeSQL:   `SELECT key, value FROM ` + t + ` WHERE ` + prefixed + ` AND ` + live + ` ORDER BY key`,
+		hotSQL:     `SELECT key, value, expiry FROM ` + t + ` WHERE ` + live + ` ORDER BY reads DESC, updated_at DESC LIMIT ?`,
+	}, nil
+}
+
+// ValidateKey checks if a key is valid for SQLite persistence.
+// Keys are stored as text, so any non-empty key is allowed.
+func (*Store[K, V]) ValidateKey(key K) error {
+	if fmt.Sprintf("%v", key) == "" {
+		return errors.New("key cannot be empty")
+	}
+	return nil
+}
+
+// Location returns the table and key a cache key is stored under.
+func (s *Store[K, V]) Location(key K) string {
+	return fmt.Sprintf("%s/%v", s.table, key)
+}
+
+// Get retrieves a value, skipping expired entries, and counts the read for
+// LoadByFrequency.
+//
+//nolint:revive,gocritic // function-result-limit, unnamedResult - required by persist.Store interface
+func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	var zero V
+	var data []byte
+	var exp int64
+	err := s.db.QueryRowContext(ctx, s.getSQL, fmt.Sprintf("%v", key), time.Now().UnixNano()).Scan(&data, &exp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return zero, time.Time{}, false, nil
+	}
+	if err != nil {
+		return zero, time.Time{}, false, fmt.Errorf("sqlite get: %w", err)
+	}
+
+	v, err := s.decode(data)
+	if err != nil {
+		return zero, time.Time{}, false, err
+	}
+
+	var expiry time.Time
+	if exp != 0 {
+		expiry = time.Unix(0, exp)
+	}
+	return v, expiry, true, nil
+}
+
+// decode decompresses and unmarshals a stored value.
+func (s *Store[K, V]) decode(data []byte) (V, error) {
+	var v V
+	raw, err := s.compressor.Decode(data)
+	if err != nil {
+		return v, &compress.DecodeError{Err: fmt.Errorf("decompress: %w", err)}
+	}
+	if err := compress.UnmarshalValue(raw, &v); err != nil {
+		return v, &compress.DecodeError{Err: fmt.Errorf("unmarshal value: %w", err)}
+	}
+	return v, nil
+}
+
+// Set saves a value, replacing any existing entry for key.
+func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	raw, err := compress.MarshalValue(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	data, err := s.compressor.Encode(raw)
+	if err != nil {
+		return fmt.Errorf("compress: %w", err)
+	}
+
+	var exp int64
+	if !expiry.IsZero() {
+		exp = expiry.UnixNano()
+	}
+	if _, err := s.db.ExecContext(ctx, s.setSQL, fmt.Sprintf("%v", key), data, exp, time.Now().UnixNano()); err != nil {
+		return fmt.Errorf("sqlite set: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a value.
+func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
+	if _, err := s.db.ExecContext(ctx, s.deleteSQL, fmt.Sprintf("%v", key)); err != nil {
+		return fmt.Errorf("sqlite delete: %w", err)
+	}
+	return nil
+}
+
+// Cleanup removes entries that expired more than maxAge ago, in a single statement.
+// Returns the count of deleted entries.
+func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	return s.exec(ctx, "cleanup", s.cleanupSQL, time.Now().Add(-maxAge).UnixNano())
+}
+
+// Flush removes all entries from the table.
+// Returns the number of entries removed.
+func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
+	return s.exec(ctx, "flush", s.flushSQL)
+}
+
+// exec runs a DELETE statement and returns the number of rows it removed.
+func (s *Store[K, V]) exec(ctx context.Context, op, query string, args ...any) (int, error) {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite %s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite %s: %w", op, err)
+	}
+	return int(n), nil
+}
+
+// Len returns the number of entries in the table, including expired entries
+// not yet removed by Cleanup.
+func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, s.lenSQL).Scan(&n); err != nil {
+		return 0, fmt.Errorf("sqlite len: %w", err)
+	}
+	return n, nil
+}
+
+// Close releases no resources: the caller owns the *sql.DB passed to New.
+func (*Store[K, V]) Close() error {
+	return nil
+}
+
+// Ping checks that the database is reachable. Implements fido.HealthChecker.
+func (s *Store[K, V]) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("sqlite ping: %w", err)
+	}
+	return nil
+}
+
+// Keys returns an iterator over non-expired keys matching prefix, in key order.
+// Implements PrefixScanner[V] interface (only usable when K is string).
+// Only the key column is read, so values are not loaded or decoded.
+func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		rows, err := s.db.QueryContext(ctx, s.keysSQL, utf8.RuneCountInString(prefix), prefix, time.Now().UnixNano())
+		if err != nil {
+			return
+		}
+		defer rows.Close() //nolint:errcheck // read-only query
+
+		for rows.Next() {
+			var k string
+			if err := rows.Scan(&k); err != nil {
+				return
+			}
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over non-expired key-value pairs matching prefix, in key order.
+// Implements PrefixScanner[V] interface (only usable when K is string).
+// Entries that fail to decode are skipped.
+func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		rows, err := s.db.QueryContext(ctx, s.rangeSQL, utf8.RuneCountInString(prefix), prefix, time.Now().UnixNano())
+		if err != nil {
+			return
+		}
+		defer rows.Close() //nolint:errcheck // read-only query
+
+		for rows.Next() {
+			var k string
+			var data []byte
+			if err := rows.Scan(&k, &data); err != nil {
+				return
+			}
+			v, err := s.decode(data)
+			if err != nil {
+				continue
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// LoadByFrequency calls fn for up to limit non-expired entries, most often read by Get
+// first, ties broken by most recent update. Implements fido.FrequencyLoader. Keys are
+// stored as text, so it returns an error unless K is string. A limit <= 0 loads all.
+func (s *Store[K, V]) LoadByFrequency(ctx context.Context, limit int, fn func(key K, value V, expiry time.Time) bool) error {
+	if _, ok := any(*new(K)).(string); !ok {
+		return errors.New("sqlite LoadByFrequency: requires string keys")
+	}
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	rows, err := s.db.QueryContext(ctx, s.hotSQL, time.Now().UnixNano(), limit)
+	if err != nil {
+		return fmt.Errorf("sqlite load: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // read-only query
+
+	for rows.Next() {
+		var k string
+		var data []byte
+		var exp int64
+		if err := rows.Scan(&k, &data, &exp); err != nil {
+			return fmt.Errorf("sqlite load: %w", err)
+		}
+		v, err := s.decode(data)
+		if err != nil {
+			continue
+		}
+		var expiry time.Time
+		if exp != 0 {
+			expiry = time.Unix(0, exp)
+		}
+		key, _ := any(k).(K) //nolint:errcheck // K is string, checked above
+		if !fn(key, v, expiry) {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("sqlite load: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// downConnector is a database/sql connector whose connections always fail,
+// standing in for an unreachable database.
+type downConnector struct{}
+
+func (downConnector) Connect(context.Context) (driver.Conn, error) { return nil, errors.New("down") }
+func (downConnector) Driver() driver.Driver                        { return downDriver{} }
+
+type downDriver struct{}
+
+func (downDriver) Open(string) (driver.Conn, error) { return nil, errors.New("down") }
+
+func TestSQLite_New_Validation(t *testing.T) {
+	ctx := context.Background()
+	db := sql.OpenDB(downConnector{})
+	defer db.Close() //nolint:errcheck // Test cleanup
+
+	if _, err := New[string, int](ctx, nil, "app"); err == nil {
+		t.Error("New() should fail with nil db")
+	}
+	for _, id := range []string{"", "my-app", `app"; DROP TABLE x; --`, "app.v2"} {
+		if _, err := New[string, int](ctx, db, id); err == nil || strings.Contains(err.Error(), "down") {
+			t.Errorf("New(%q) error = %v; want a cacheID validation error", id, err)
+		}
+	}
+	if _, err := New[string, int](ctx, db, "my_app2"); err == nil || !strings.Contains(err.Error(), "create table") {
+		t.Errorf("New(my_app2) error = %v; want a create table error from the unreachable db", err)
+	}
+}
+
+func TestSQLite_ValidateKey(t *testing.T) {
+	var s Store[string, int]
+	if err := s.ValidateKey(""); err == nil {
+		t.Error("ValidateKey(\"\") should fail")
+	}
+	if err := s.ValidateKey(strings.Repeat("k", 4096)); err != nil {
+		t.Errorf("ValidateKey(long key) = %v; want nil", err)
+	}
+}
+
+// fakeDB is an in-memory database/sql driver that understands exactly the statements
+// Store issues, evaluating them the way SQLite would. Any other statement fails, so a
+// change to the store's SQL shows up here.
+//
+//nolint:govet // fieldalignment - mutex kept next to the state it guards
+type fakeDB struct {
+	mu       sync.Mutex
+	rows     map[string]*fakeRow
+	hasReads bool // table already has the reads column
+	altered  bool // ALTER TABLE ran
+}
+
+type fakeRow struct {
+	value   []byte
+	expiry  int64
+	updated int64
+	reads   int64
+}
+
+const fakeLive = `(expiry = 0 OR expiry > ?)`
+
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{rows: make(map[string]*fakeRow), hasReads: true}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck,gosec // Test cleanup
+	return db, f
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (*fakeDB) Driver() driver.Driver                          { return downDriver{} }
+
+type fakeConn struct{ db *fakeDB }
+
+func (*fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake: prepare unsupported")
+}
+
+func (*fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake: transactions unsupported")
+}
+
+func (*fakeConn) Close() error { return nil }
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(q, "CREATE "):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(q, "ALTER TABLE ") && strings.HasSuffix(q, " ADD COLUMN reads INTEGER NOT NULL DEFAULT 0"):
+		f.hasReads, f.altered = true, true
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(q, "INSERT INTO ") && strings.Contains(q, "ON CONFLICT(key) DO UPDATE"):
+		key := args[0].Value.(string) //nolint:errcheck,forcetypeassert // Test helper
+		r, ok := f.rows[key]
+		if !ok {
+			r = &fakeRow{}
+			f.rows[key] = r
+		}
+		r.value, r.expiry, r.updated = args[1].Value.([]byte), args[2].Value.(int64), args[3].Value.(int64) //nolint:errcheck,forcetypeassert // Test helper
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(q, "DELETE FROM ") && strings.HasSuffix(q, " WHERE key = ?"):
+		key := args[0].Value.(string) //nolint:errcheck,forcetypeassert // Test helper
+		_, ok := f.rows[key]
+		delete(f.rows, key)
+		return driver.RowsAffected(boolInt(ok)), nil
+	case strings.HasPrefix(q, "DELETE FROM ") && strings.HasSuffix(q, " WHERE expiry != 0 AND expiry < ?"):
+		cutoff := args[0].Value.(int64) //nolint:errcheck,forcetypeassert // Test helper
+		var n int64
+		for k, r := range f.rows {
+			if r.expiry != 0 && r.expiry < cutoff {
+				delete(f.rows, k)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	case strings.HasPrefix(q, "DELETE FROM ") && !strings.Contains(q, " WHERE "):
+		n := int64(len(f.rows))
+		f.rows = make(map[string]*fakeRow)
+		return driver.RowsAffected(n), nil
+	}
+	return nil, fmt.Errorf("fake: unsupported exec %q", q)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	live := func(r *fakeRow, now driver.Value) bool { return r.expiry == 0 || r.expiry > now.(int64) } //nolint:errcheck,forcetypeassert // Test helper
+	prefixed := func(key string, n, prefix driver.Value) bool {
+		runes := []rune(key)
+		return string(runes[:min(len(runes), int(n.(int64)))]) == prefix.(string) //nolint:errcheck,forcetypeassert // Test helper
+	}
+	sorted := func() []string {
+		keys := make([]string, 0, len(f.rows))
+		for k := range f.rows {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		return keys
+	}
+
+	out := &fakeRows{}
+	switch {
+	case strings.Contains(q, "FROM pragma_table_info(") && strings.HasSuffix(q, "WHERE name = 'reads'"):
+		out.cols = []string{"n"}
+		out.data = [][]driver.Value{{boolInt(f.hasReads)}}
+	case strings.HasPrefix(q, "UPDATE ") && strings.HasSuffix(q, " SET reads = reads + 1 WHERE key = ? AND "+fakeLive+" RETURNING value, expiry"):
+		out.cols = []string{"value", "expiry"}
+		if r, ok := f.rows[args[0].Value.(string)]; ok && live(r, args[1].Value) { //nolint:errcheck,forcetypeassert // Test helper
+			r.reads++
+			out.data = [][]driver.Value{{r.value, r.expiry}}
+		}
+	case strings.HasPrefix(q, "SELECT COUNT(*) FROM ") && !strings.Contains(q, " WHERE "):
+		out.cols = []string{"n"}
+		out.data = [][]driver.Value{{int64(len(f.rows))}}
+	case strings.HasPrefix(q, "SELECT key FROM ") && strings.HasSuffix(q, " WHERE substr(key, 1, ?) = ? AND "+fakeLive+" ORDER BY key"):
+		out.cols = []string{"key"}
+		for _, k := range sorted() {
+			if prefixed(k, args[0].Value, args[1].Value) && live(f.rows[k], args[2].Value) {
+				out.data = append(out.data, []driver.Value{k})
+			}
+		}
+	case strings.HasPrefix(q, "SELECT key, value FROM ") && strings.HasSuffix(q, " WHERE substr(key, 1, ?) = ? AND "+fakeLive+" ORDER BY key"):
+		out.cols = []string{"key", "value"}
+		for _, k := range sorted() {
+			if r := f.rows[k]; prefixed(k, args[0].Value, args[1].Value) && live(r, args[2].Value) {
+				out.data = append(out.data, []driver.Value{k, r.value})
+			}
+		}
+	case strings.HasPrefix(q, "SELECT key, value, expiry FROM ") && strings.HasSuffix(q, " WHERE "+fakeLive+" ORDER BY reads DESC, updated_at DESC LIMIT ?"):
+		out.cols = []string{"key", "value", "expiry"}
+		keys := sorted()
+		slices.SortStableFunc(keys, func(a, b string) int {
+			ra, rb := f.rows[a], f.rows[b]
+			if c := cmp.Compare(rb.reads, ra.reads); c != 0 {
+				return c
+			}
+			return cmp.Compare(rb.updated, ra.updated)
+		})
+		limit := args[1].Value.(int64) //nolint:errcheck,forcetypeassert // Test helper
+		for _, k := range keys {
+			if r := f.rows[k]; live(r, args[0].Value) && (limit < 0 || int64(len(out.data)) < limit) {
+				out.data = append(out.data, []driver.Value{k, r.value, r.expiry})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("fake: unsupported query %q", q)
+	}
+	return out, nil
+}
+
+type fakeRows struct {
+	cols []string
+	data [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (*fakeRows) Close() error        { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.data[0])
+	r.data = r.data[1:]
+	return nil
+}
+
+func newFakeStore(t *testing.T) (*Store[string, int], *fakeDB) {
+	t.Helper()
+	db, f := newFakeDB(t)
+	s, err := New[string, int](context.Background(), db, "app")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s, f
+}
+
+func TestSQLite_SetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newFakeStore(t)
+
+	if _, _, found, err := s.Get(ctx, "k"); err != nil || found {
+		t.Errorf("Get(missing) = found %v, err %v; want miss", found, err)
+	}
+	exp := time.Now().Add(time.Hour).Truncate(time.Nanosecond)
+	if err := s.Set(ctx, "k", 1, exp); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set(ctx, "k", 2, exp); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	v, got, found, err := s.Get(ctx, "k")
+	if err != nil || !found || v != 2 || !got.Equal(exp) {
+		t.Errorf("Get = %d, %v, %v, %v; want 2, %v, true, nil", v, got, found, err, exp)
+	}
+
+	if err := s.Set(ctx, "forever", 3, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, got, found, _ := s.Get(ctx, "forever"); !found || !got.IsZero() { //nolint:errcheck // checked via found
+		t.Errorf("Get(forever) = expiry %v, found %v; want zero expiry, true", got, found)
+	}
+
+	if err := s.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, found, _ := s.Get(ctx, "k"); found { //nolint:errcheck // checked via found
+		t.Error("Get after Delete found the entry")
+	}
+}
+
+func TestSQLite_Expiry(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newFakeStore(t)
+	now := time.Now()
+
+	entries := map[string]time.Time{
+		"expired-old":    now.Add(-2 * time.Hour),
+		"expired-recent": now.Add(-30 * time.Minute),
+		"live":           now.Add(time.Hour),
+		"forever":        {},
+	}
+	for k, exp := range entries {
+		if err := s.Set(ctx, k, 1, exp); err != nil {
+			t.Fatalf("Set(%s): %v", k, err)
+		}
+	}
+
+	for k, want := range map[string]bool{"expired-old": false, "expired-recent": false, "live": true, "forever": true} {
+		if _, _, found, err := s.Get(ctx, k); err != nil || found != want {
+			t.Errorf("Get(%s) = found %v, err %v; want %v", k, found, err, want)
+		}
+	}
+
+	// Cleanup removes only rows expired more than maxAge ago.
+	n, err := s.Cleanup(ctx, time.Hour)
+	if err != nil || n != 1 {
+		t.Errorf("Cleanup(1h) = %d, %v; want 1, nil", n, err)
+	}
+	if n, err := s.Len(ctx); err != nil || n != 3 {
+		t.Errorf("Len = %d, %v; want 3 (expired rows count until cleaned)", n, err)
+	}
+	if n, err := s.Cleanup(ctx, 0); err != nil || n != 1 {
+		t.Errorf("Cleanup(0) = %d, %v; want 1, nil", n, err)
+	}
+
+	n, err = s.Flush(ctx)
+	if err != nil || n != 2 {
+		t.Errorf("Flush = %d, %v; want 2, nil", n, err)
+	}
+	if n, err := s.Len(ctx); err != nil || n != 0 {
+		t.Errorf("Len after Flush = %d, %v; want 0", n, err)
+	}
+}
+
+func TestSQLite_KeysRange(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newFakeStore(t)
+
+	for i, k := range []string{"user:b", "user:a", "org:a", "user:é"} {
+		if err := s.Set(ctx, k, i, time.Time{}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := s.Set(ctx, "user:gone", 9, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if got, want := slices.Collect(s.Keys(ctx, "user:")), []string{"user:a", "user:b", "user:é"}; !slices.Equal(got, want) {
+		t.Errorf("Keys(user:) = %v; want %v", got, want)
+	}
+	if got := slices.Collect(s.Keys(ctx, "user:é")); !slices.Equal(got, []string{"user:é"}) {
+		t.Errorf("Keys(user:é) = %v; want [user:é] (prefix length counts runes)", got)
+	}
+	got := map[string]int{}
+	for k, v := range s.Range(ctx, "user:") {
+		got[k] = v
+	}
+	if want := map[string]int{"user:a": 1, "user:b": 0, "user:é": 3}; !maps.Equal(got, want) {
+		t.Errorf("Range(user:) = %v; want %v", got, want)
+	}
+}
+
+func TestSQLite_LoadByFrequency(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newFakeStore(t)
+
+	for i, k := range []string{"cold", "warm", "hot", "expired"} {
+		exp := time.Time{}
+		if k == "expired" {
+			exp = time.Now().Add(-time.Minute)
+		}
+		if err := s.Set(ctx, k, i, exp); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	for k, n := range map[string]int{"hot": 3, "warm": 1} {
+		for range n {
+			s.Get(ctx, k) //nolint:errcheck // Test helper
+		}
+	}
+
+	var got []string
+	load := func(key string, _ int, _ time.Time) bool {
+		got = append(got, key)
+		return true
+	}
+	if err := s.LoadByFrequency(ctx, 0, load); err != nil {
+		t.Fatalf("LoadByFrequency: %v", err)
+	}
+	if want := []string{"hot", "warm", "cold"}; !slices.Equal(got, want) {
+		t.Errorf("LoadByFrequency order = %v; want %v", got, want)
+	}
+
+	got = nil
+	if err := s.LoadByFrequency(ctx, 1, load); err != nil || !slices.Equal(got, []string{"hot"}) {
+		t.Errorf("LoadByFrequency(limit 1) = %v, %v; want [hot]", got, err)
+	}
+
+	db, _ := newFakeDB(t)
+	is, err := New[int, int](ctx, db, "ints")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := is.LoadByFrequency(ctx, 0, func(int, int, time.Time) bool { return true }); err == nil {
+		t.Error("LoadByFrequency with int keys should fail")
+	}
+}
+
+func TestSQLite_New_AddsReadsColumn(t *testing.T) {
+	db, f := newFakeDB(t)
+	f.hasReads = false
+	if _, err := New[string, int](context.Background(), db, "old"); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !f.altered {
+		t.Error("New did not add the reads column to an existing table")
+	}
+
+	db, f = newFakeDB(t)
+	if _, err := New[string, int](context.Background(), db, "current"); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if f.altered {
+		t.Error("New altered a table that already has the reads column")
+	}
+}
@@ -9,6 +9,7 @@ require (
 
 require (
 	github.com/klauspost/compress v1.18.3 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 )
 
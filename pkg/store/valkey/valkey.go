@@ -3,7 +3,6 @@ package valkey
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
@@ -104,14 +103,14 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error
 		return zero, time.Time{}, false, fmt.Errorf("valkey get: %w", err)
 	}
 
-	jsonData, err := s.compressor.Decode(data)
+	raw, err := s.compressor.Decode(data)
 	if err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("decompress: %w", err)
+		return zero, time.Time{}, false, &compress.DecodeError{Err: fmt.Errorf("decompress: %w", err)}
 	}
 
 	var v V
-	if err := json.Unmarshal(jsonData, &v); err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("unmarshal value: %w", err)
+	if err := compress.UnmarshalValue(raw, &v); err != nil {
+		return zero, time.Time{}, false, &compress.DecodeError{Err: fmt.Errorf("unmarshal value: %w", err)}
 	}
 
 	// Parse TTL
@@ -126,12 +125,12 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error
 
 // Set saves a value to Valkey with optional expiry.
 func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
-	jsonData, err := json.Marshal(value)
+	raw, err := compress.MarshalValue(value)
 	if err != nil {
 		return fmt.Errorf("marshal value: %w", err)
 	}
 
-	data, err := s.compressor.Encode(jsonData)
+	data, err := s.compressor.Encode(raw)
 	if err != nil {
 		return fmt.Errorf("compress: %w", err)
 	}
@@ -241,6 +240,14 @@ func (s *Store[K, V]) Close() error {
 	return nil // valkey client.Close() doesn't return an error
 }
 
+// Ping sends PING to the server. Implements fido.HealthChecker.
+func (s *Store[K, V]) Ping(ctx context.Context) error {
+	if err := s.client.Do(ctx, s.client.B().Ping().Build()).Error(); err != nil {
+		return fmt.Errorf("valkey ping: %w", err)
+	}
+	return nil
+}
+
 // Keys returns an iterator over keys matching prefix.
 // Implements PrefixScanner[V] interface (only usable when K is string).
 // Uses SCAN with pattern matching for efficiency.
@@ -320,7 +327,7 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 				}
 
 				var v V
-				if err := json.Unmarshal(data, &v); err != nil {
+				if err := compress.UnmarshalValue(data, &v); err != nil {
 					continue
 				}
 
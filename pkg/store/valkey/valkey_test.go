@@ -249,6 +249,42 @@ func TestValkeyPersist_TTL(t *testing.T) {
 	}
 }
 
+func TestValkeyPersist_RemainingTTL(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	addr := os.Getenv("VALKEY_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	p, err := New[string, string](ctx, "test-cache-remaining-ttl", addr)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if err := p.Set(ctx, "ttl-key", "value", time.Now().Add(10*time.Second)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Let part of the TTL elapse, then reload: the expiry must reflect what is left.
+	time.Sleep(2 * time.Second)
+
+	_, expiry, found, err := p.Get(ctx, "ttl-key")
+	if err != nil || !found {
+		t.Fatalf("Get: found=%v err=%v", found, err)
+	}
+	remaining := time.Until(expiry)
+	if remaining < 7*time.Second || remaining > 8500*time.Millisecond {
+		t.Errorf("remaining TTL = %v; want ~8s", remaining)
+	}
+}
+
 func TestValkeyPersist_Delete(t *testing.T) {
 	skipIfNoValkey(t)
 
@@ -1071,3 +1107,27 @@ func TestValkeyPersist_Keys_ContextCancellation(t *testing.T) {
 		_ = p.Delete(ctx, fmt.Sprintf("key-%d", i)) //nolint:errcheck // test cleanup
 	}
 }
+
+func TestValkeyPersist_Ping(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	addr := os.Getenv("VALKEY_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	p, err := New[string, int](ctx, "test-cache-ping", addr)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if err := p.Ping(ctx); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package fido
+
+// Policy selects the eviction algorithm.
+type Policy uint8
+
+const (
+	// PolicyS3FIFO is the default: small and main FIFO queues with ghost tracking and
+	// frequency-based promotion. Scan resistant and the best choice for most workloads.
+	PolicyS3FIFO Policy = iota
+	// PolicyLRU evicts the least recently read or written entry. Every hit takes the
+	// write lock to update recency, so read throughput is far lower than S3-FIFO.
+	PolicyLRU
+	// PolicyFIFO evicts the oldest inserted entry regardless of access.
+	PolicyFIFO
+)
+
+// The LRU and FIFO policies keep every entry on the main queue, ordered oldest first,
+// and skip the small queue, ghost filters and death row entirely.
+
+// touch moves ent to the back of main, marking it most recently used (PolicyLRU).
+func (c *s3fifo[K, V]) touch(key K, ent *entry[K, V]) {
+	c.lock()
+	if cur, ok := c.entries.Load(key); ok && cur == ent && c.main.tail != ent {
+		c.main.remove(ent)
+		c.main.pushBack(ent)
+	}
+	c.mu.Unlock()
+}
+
+// evictHead removes up to n entries from the front of main (PolicyLRU, PolicyFIFO).
+// Caller must hold c.mu.
+func (c *s3fifo[K, V]) evictHead(n int) {
+	for range n {
+		e := c.main.head
+		if e == nil {
+			return
+		}
+		c.main.remove(e)
+		c.countEviction(e)
+		c.entries.Delete(e.key)
+		c.chargeCost(e, -1)
+		e.prev, e.next = nil, nil
+		c.freeEntry = e
+		c.totalEntries.Add(-1)
+	}
+}
@@ -1,8 +1,10 @@
 package fido
 
 import (
+	"cmp"
 	"fmt"
 	"math/bits"
+	"math/rand/v2"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -49,10 +51,11 @@ func hashString(s string) uint64 {
 }
 
 const (
-	// maxFreq caps the frequency counter for eviction. Paper uses 3; 5 tuned via binary search.
+	// defaultMaxFreq caps the frequency counter for eviction unless MaxFreq is set.
+	// Paper uses 3; 5 tuned via binary search.
 	// WARNING: Must be >= 2. Setting to 1 creates infinite loop in eviction (items with
 	// freq=1 get promoted instead of evicted, causing evictFromSmall to never return true).
-	maxFreq = 5
+	defaultMaxFreq = 5
 
 	// maxPeakFreq caps peakFreq for death row admission decisions.
 	maxPeakFreq = 21
@@ -67,6 +70,9 @@ const (
 	// minDeathRowSize is the minimum death row slots.
 	// Death row size scales with capacity to match pre-sharding behavior.
 	minDeathRowSize = 8
+
+	// lazyPresize is the initial entry map size hint when LazyMapGrowth is set.
+	lazyPresize = 64
 )
 
 // smallRatio returns the optimal small queue ratio (per-mille) for a capacity.
@@ -194,15 +200,56 @@ type s3fifo[K comparable, V any] struct {
 
 	capacity       int
 	smallThresh    int // adaptive small queue threshold
+	evictBatch     int // entries reclaimed per eviction pass when full
 	warmupComplete bool
 	totalEntries   atomic.Int64
 
+	contentions *xsync.Counter // contended write-lock acquisitions; nil unless ContentionStats
+	hits        *xsync.Counter // get calls that found a live entry; nil unless HitStats
+	misses      *xsync.Counter // get calls that didn't; nil unless HitStats
+
+	maxKeyBytes  int           // longest string key accepted on insert; 0 means unlimited
+	validateKey  func(K) error // KeyValidator; nil means none
+	rejectedKeys atomic.Int64  // inserts dropped by maxKeyBytes or validateKey
+
+	cost      func(V) int64 // Cost; nil unless MaxCost is set
+	maxCost   int64
+	totalCost atomic.Int64 // summed cost of counted entries; written under mu
+
+	policy         Policy       // eviction algorithm; see policy.go for LRU and FIFO
+	writeNoBump    bool         // updates leave freq and peakFreq unchanged
+	maxFreq        uint32       // freq saturation; see MaxFreq
+	rejectWhenFull bool         // refuse new keys at capacity instead of evicting
+	disabled       bool         // persistence-only TieredCache (Size(0)): never store entries
+	rejectedFull   atomic.Int64 // inserts dropped by rejectWhenFull
+
+	// Expiry clock and TTL bounds.
+	monotonic bool          // expiry clock follows monoStart's monotonic reading
+	monoStart time.Time     // construction time, with its monotonic reading
+	minTTL    time.Duration // MinTTL; 0 means none
+	maxTTL    time.Duration // MaxTTL; 0 means none
+
+	// TTLJitter fraction, 0 for none, and its random source, uniform in [0, 1).
+	// Tests replace the source to make jitter deterministic.
+	jitter    float64
+	jitterRnd func() float64
+
+	capacityEvictions atomic.Int64 // live entries evicted to make room
+	expiredEvictions  atomic.Int64 // already-expired entries evicted to make room
+	explicitDeletes   atomic.Int64 // entries removed by delete
+
+	loaderCalls    atomic.Int64 // Fetch loader invocations
+	coalescedCalls atomic.Int64 // Fetch callers that waited on another's load
+
 	// Type flags cache key type detection done once at construction.
 	// Enables fast paths that avoid interface{} boxing on every get/set.
 	// Removing these and using runtime type switches causes -6.4% throughput.
 	keyIsInt    bool
 	keyIsInt64  bool
+	keyIsInt32  bool
+	keyIsUint32 bool
 	keyIsString bool
+	strHash     bool // keyIsString with the built-in hasher, so writes hash outside the lock
 }
 
 // ghostFreqRing is a fixed-size ring buffer for ghost frequency tracking.
@@ -277,6 +324,73 @@ func timeToSec(t time.Time) uint32 {
 	return uint32(t.Unix())
 }
 
+// nowSec returns the current time on the cache's expiry clock.
+// With MonotonicExpiry the clock is the wall time at construction advanced by the
+// monotonic clock, so wall-clock jumps and VM suspends do not shift expiry.
+func (c *s3fifo[K, V]) nowSec() uint32 {
+	if !c.monotonic {
+		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+		return uint32(time.Now().Unix())
+	}
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	return uint32(c.monoStart.Unix() + int64(time.Since(c.monoStart)/time.Second))
+}
+
+// clampTTL applies MinTTL and MaxTTL to a positive ttl.
+func (c *s3fifo[K, V]) clampTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return ttl
+	}
+	if c.minTTL > 0 && ttl < c.minTTL {
+		ttl = c.minTTL
+	}
+	if c.maxTTL > 0 && ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl
+}
+
+// expiry returns the absolute expiry (zero for none) of an entry written now with ttl,
+// or defaultTTL if ttl <= 0, after TTLJitter, MinTTL and MaxTTL. Jitter comes first so
+// the bounds still hold.
+func (c *s3fifo[K, V]) expiry(ttl, defaultTTL time.Duration) time.Time {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	if c.jitter > 0 {
+		ttl = max(ttl+time.Duration((2*c.jitterRnd()-1)*c.jitter*float64(ttl)), 0)
+	}
+	return time.Now().Add(c.clampTTL(ttl))
+}
+
+// toSec converts an absolute expiry (zero for none) to the cache's expiry clock.
+func (c *s3fifo[K, V]) toSec(t time.Time) uint32 {
+	if !c.monotonic || t.IsZero() {
+		return timeToSec(t)
+	}
+	// Carry over the remaining lifetime; an already-past expiry stays in the past.
+	d := time.Until(t)
+	if d <= 0 {
+		return max(c.nowSec()-1, 1)
+	}
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	return c.nowSec() + uint32((d+time.Second-1)/time.Second)
+}
+
+// fromSec converts an expiry on the cache's expiry clock back to wall time (zero for none).
+func (c *s3fifo[K, V]) fromSec(sec uint32) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	if !c.monotonic {
+		return time.Unix(int64(sec), 0)
+	}
+	return time.Now().Add(time.Duration(int64(sec)-int64(c.nowSec())) * time.Second)
+}
+
 // entry is a cached key-value pair with eviction metadata.
 // Uses seqlock for zero-allocation value storage.
 //
@@ -284,18 +398,31 @@ func timeToSec(t time.Time) uint32 {
 type entry[K comparable, V any] struct {
 	key       K
 	value     V             // stored inline, protected by seqlock
-	seq       atomic.Uint64 // seqlock: odd = write in progress
+	seq       atomic.Uint64 // seqlock: bit 0 = write in progress, bit 1 = cached miss
 	prev      *entry[K, V]
 	next      *entry[K, V]
 	hash64    uint64        // full 64-bit hash for bloom filter (avoids re-hashing on eviction)
 	expirySec atomic.Uint32 // 0 means no expiry; seconds since Unix epoch
-	freqFlags atomic.Uint32 // bits 0-3: freq, bits 4-9: peakFreq, bit 30: inSmall, bit 31: onDeathRow
+	freqFlags atomic.Uint32 // bits 0-3: freq, bits 4-9: peakFreq, bits 10-29: gen, bit 30: inSmall, bit 31: onDeathRow
 }
 
+// seqMiss marks an entry holding a cached miss (see Cache.SetMiss). It lives in the
+// seqlock word so readers see the flag and the value change together.
+const seqMiss = 2
+
 // storeValue stores a value using seqlock protocol (zero allocations).
+func (e *entry[K, V]) storeValue(v V) { e.store(v, 0) }
+
+// storeMiss replaces the value with the zero value and marks the entry as a cached miss.
+func (e *entry[K, V]) storeMiss() {
+	var zero V
+	e.store(zero, seqMiss)
+}
+
+// store writes v and the miss flag under the seqlock.
 // Uses CAS to ensure only one writer can be active at a time, preventing
 // sequence corruption when multiple goroutines update the same entry.
-func (e *entry[K, V]) storeValue(v V) {
+func (e *entry[K, V]) store(v V, miss uint64) {
 	for {
 		seq := e.seq.Load()
 		if seq&1 != 0 {
@@ -305,13 +432,16 @@ func (e *entry[K, V]) storeValue(v V) {
 		if e.seq.CompareAndSwap(seq, seq+1) {
 			// Successfully marked as writing (seq is now odd)
 			e.value = v
-			e.seq.Store(seq + 2) // End write (seq is now even)
+			e.seq.Store((seq&^seqMiss + 4) | miss) // End write (seq is now even)
 			return
 		}
 	}
 }
 
-// loadValue loads a value using seqlock protocol.
+// isMiss reports whether the entry holds a cached miss.
+func (e *entry[K, V]) isMiss() bool { return e.seq.Load()&seqMiss != 0 }
+
+// loadValue loads a value using seqlock protocol. A cached miss loads as not found.
 func (e *entry[K, V]) loadValue() (V, bool) {
 	for range 1000 { // bounded retry
 		s1 := e.seq.Load() // acquire semantics
@@ -321,7 +451,7 @@ func (e *entry[K, V]) loadValue() (V, bool) {
 		v := e.value
 		s2 := e.seq.Load() // acquire semantics
 		if s2 == s1 {
-			return v, s1 > 0 // s1>0 means value was stored at least once
+			return v, s1 > 0 && s1&seqMiss == 0 // s1>0 means value was stored at least once
 		}
 	}
 	var zero V
@@ -335,8 +465,15 @@ const (
 	peakFreqMask  = 0x3F // bits 4-9 for peakFreq (0-63), accessed after shift
 	inSmallBit    = 1 << 30
 	onDeathRowBit = 1 << 31
+
+	genShift = 10      // gen starts at bit 10
+	genMask  = 0xFFFFF // bits 10-29 for gen, accessed after shift
 )
 
+// gen returns how many times the entry has been recycled for a new key, modulo 2^20,
+// so holders of an entry pointer can tell whether it still holds the same insert.
+func (e *entry[K, V]) gen() uint32 { return (e.freqFlags.Load() >> genShift) & genMask }
+
 // freq returns the access frequency (0-15).
 func (e *entry[K, V]) freq() uint32 { return e.freqFlags.Load() & freqMask }
 
@@ -390,7 +527,7 @@ func (e *entry[K, V]) incPeakFreq(limit uint32) {
 // setFreqPeak sets freq and peakFreq, preserving flags. Must be called under mutex.
 func (e *entry[K, V]) setFreqPeak(f, p uint32) {
 	cur := e.freqFlags.Load()
-	flags := cur & (inSmallBit | onDeathRowBit)
+	flags := cur & (inSmallBit | onDeathRowBit | genMask<<genShift)
 	e.freqFlags.Store((f & freqMask) | ((p & peakFreqMask) << peakFreqShift) | flags)
 }
 
@@ -431,15 +568,58 @@ func newS3FIFO[K comparable, V any](cfg *config) *s3fifo[K, V] {
 	// becomes a second cache that distorts benchmark results.
 	deathRowSize := max(minDeathRowSize, size/768)
 
+	presize := size
+	if cfg.lazyMapGrowth {
+		presize = min(size, lazyPresize)
+	}
+	if cfg.presize > 0 {
+		presize = cfg.presize
+	}
+
+	// Each ghost filter gets half the budget. Don't let a 1-byte budget round down to
+	// 0, which newBloomFilterMax reads as no cap.
+	ghostBudget := cfg.ghostBudget / 2
+	if cfg.ghostBudget > 0 {
+		ghostBudget = max(ghostBudget, 1)
+	}
+
 	c := &s3fifo[K, V]{
 		mu:          xsync.NewRBMutex(),
-		entries:     xsync.NewMap[K, *entry[K, V]](xsync.WithPresize(size)),
+		entries:     xsync.NewMap[K, *entry[K, V]](xsync.WithPresize(presize)),
 		capacity:    size,
-		smallThresh: size * smallRatio(size) / 1000,
+		smallThresh: size * cmp.Or(cfg.smallPerMille, smallRatio(size)) / 1000,
+		maxFreq:     cmp.Or(cfg.maxFreq, defaultMaxFreq),
 		ghostCap:    size * ghostRatio(size) / 1000,
-		ghostActive: newBloomFilter(size, ghostFPRate),
-		ghostAging:  newBloomFilter(size, ghostFPRate),
+		ghostActive: newBloomFilterMax(size, ghostFPRate, ghostBudget),
+		ghostAging:  newBloomFilterMax(size, ghostFPRate, ghostBudget),
 		deathRow:    make([]*entry[K, V], deathRowSize),
+		evictBatch:  min(max(cfg.evictBatch, 1), size),
+	}
+
+	if cfg.contentionStats {
+		c.contentions = xsync.NewCounter()
+	}
+	if cfg.hitStats {
+		c.hits, c.misses = xsync.NewCounter(), xsync.NewCounter()
+	}
+
+	c.maxKeyBytes = max(cfg.maxKeyBytes, 0)
+	c.validateKey, _ = cfg.keyValidator.(func(K) error) // type checked by New and NewTiered
+	if cfg.maxCost > 0 {
+		if fn, ok := cfg.costFn.(func(V) int64); ok {
+			c.cost, c.maxCost = fn, cfg.maxCost
+		}
+	}
+	c.rejectWhenFull = cfg.rejectWhenFull
+	c.policy = cfg.policy
+	c.writeNoBump = cfg.writeNoBump
+	c.minTTL = cfg.minTTL
+	c.maxTTL = cfg.maxTTL
+	c.jitter = cfg.ttlJitter
+	c.jitterRnd = rand.Float64
+	if cfg.monotonicExpiry {
+		c.monotonic = true
+		c.monoStart = time.Now()
 	}
 
 	// Detect key type once to avoid type switch on every operation.
@@ -449,11 +629,17 @@ func newS3FIFO[K comparable, V any](cfg *config) *s3fifo[K, V] {
 		c.keyIsInt = true
 	case int64:
 		c.keyIsInt64 = true
+	case int32:
+		c.keyIsInt32 = true
+	case uint32:
+		c.keyIsUint32 = true
 	case string:
 		c.keyIsString = true
 	}
 
 	switch {
+	case cfg.hasher != nil:
+		c.hasher, _ = cfg.hasher.(func(K) uint64) // type checked by New and NewTiered
 	case c.keyIsInt:
 		c.hasher = func(k K) uint64 {
 			return hashInt64(int64(*(*int)(unsafe.Pointer(&k))))
@@ -462,7 +648,16 @@ func newS3FIFO[K comparable, V any](cfg *config) *s3fifo[K, V] {
 		c.hasher = func(k K) uint64 {
 			return hashInt64(*(*int64)(unsafe.Pointer(&k)))
 		}
+	case c.keyIsInt32:
+		c.hasher = func(k K) uint64 {
+			return hashInt64(int64(*(*int32)(unsafe.Pointer(&k))))
+		}
+	case c.keyIsUint32:
+		c.hasher = func(k K) uint64 {
+			return hashInt64(int64(*(*uint32)(unsafe.Pointer(&k))))
+		}
 	case c.keyIsString:
+		c.strHash = true
 		c.hasher = func(k K) uint64 {
 			return hashString(*(*string)(unsafe.Pointer(&k)))
 		}
@@ -486,31 +681,92 @@ func newS3FIFO[K comparable, V any](cfg *config) *s3fifo[K, V] {
 	return c
 }
 
+// lock acquires the write lock, counting contended acquisitions when enabled.
+func (c *s3fifo[K, V]) lock() {
+	if c.contentions == nil {
+		c.mu.Lock()
+		return
+	}
+	if c.mu.TryLock() {
+		return
+	}
+	c.contentions.Inc()
+	c.mu.Lock()
+}
+
 // get retrieves a value, incrementing its frequency on hit.
 func (c *s3fifo[K, V]) get(key K) (V, bool) {
 	ent, ok := c.entries.Load(key)
 	if !ok {
+		c.countLookup(false)
 		var zero V
 		return zero, false
 	}
 	if ent.onDeathRow() {
-		return c.resurrectFromDeathRow(key)
+		v, ok := c.resurrectFromDeathRow(key)
+		c.countLookup(ok)
+		return v, ok
 	}
-	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-	if exp := ent.expirySec.Load(); exp != 0 && uint32(time.Now().Unix()) > exp {
+	if exp := ent.expirySec.Load(); exp != 0 && c.nowSec() > exp {
+		c.countLookup(false)
 		var zero V
 		return zero, false
 	}
+	if c.policy == PolicyLRU {
+		c.touch(key, ent)
+	}
 	// Hot path: single Load to check if both counters need increment.
 	// Under Zipf, most accesses hit entries already at max - skip CAS loops.
 	flags := ent.freqFlags.Load()
-	if flags&freqMask < maxFreq {
-		ent.incFreq(maxFreq)
+	if flags&freqMask < c.maxFreq {
+		ent.incFreq(c.maxFreq)
 	}
 	if (flags>>peakFreqShift)&peakFreqMask < maxPeakFreq {
 		ent.incPeakFreq(maxPeakFreq)
 	}
-	return ent.loadValue()
+	v, ok := ent.loadValue() // a cached miss is an access but not a hit
+	c.countLookup(ok)
+	return v, ok
+}
+
+// getOrMiss is get that also reports whether key holds an unexpired cached miss.
+func (c *s3fifo[K, V]) getOrMiss(key K) (v V, found, known bool) {
+	if got, ok := c.get(key); ok {
+		return got, true, true
+	}
+	ent, ok := c.entries.Load(key)
+	if !ok || !ent.isMiss() {
+		return v, false, false
+	}
+	exp := ent.expirySec.Load()
+	return v, false, exp == 0 || c.nowSec() <= exp
+}
+
+// getWithExpiry is get that also returns the entry's expirySec. A key deleted between
+// the two reads is reported as a miss.
+func (c *s3fifo[K, V]) getWithExpiry(key K) (V, uint32, bool) {
+	v, ok := c.get(key)
+	if !ok {
+		return v, 0, false
+	}
+	ent, ok := c.entries.Load(key)
+	if !ok {
+		var zero V
+		return zero, 0, false
+	}
+	return v, ent.expirySec.Load(), true
+}
+
+// countLookup records a get outcome when HitStats is enabled.
+func (c *s3fifo[K, V]) countLookup(hit bool) {
+	if c.hits == nil {
+		return
+	}
+	if hit {
+		c.hits.Inc()
+	} else {
+		c.misses.Inc()
+	}
 }
 
 // resurrectFromDeathRow brings an entry back from pending eviction.
@@ -518,7 +774,7 @@ func (c *s3fifo[K, V]) get(key K) (V, bool) {
 //
 // NOTE: Uses manual unlock instead of defer for -6% throughput improvement on hot path.
 func (c *s3fifo[K, V]) resurrectFromDeathRow(key K) (V, bool) {
-	c.mu.Lock()
+	c.lock()
 	ent, ok := c.entries.Load(key)
 	if !ok || !ent.onDeathRow() {
 		c.mu.Unlock()
@@ -540,11 +796,13 @@ func (c *s3fifo[K, V]) resurrectFromDeathRow(key K) (V, bool) {
 	ent.setFreqPeak(3, 3)
 	c.main.pushBack(ent)
 	c.totalEntries.Add(1)
+	c.chargeCost(ent, 1)
 
 	// Evict to maintain capacity after resurrection.
 	if c.totalEntries.Load() > int64(c.capacity) {
 		c.evictOne()
 	}
+	c.evictForCost(0)
 
 	val, ok := ent.loadValue()
 	c.mu.Unlock()
@@ -552,22 +810,30 @@ func (c *s3fifo[K, V]) resurrectFromDeathRow(key K) (V, bool) {
 }
 
 // set adds or updates a value. expirySec of 0 means no expiry.
-func (c *s3fifo[K, V]) set(key K, value V, expirySec uint32) {
+func (c *s3fifo[K, V]) set(key K, value V, expirySec uint32) bool {
 	var h uint64
-	if c.keyIsString {
+	if c.strHash {
 		h = hashString(*(*string)(unsafe.Pointer(&key)))
 	}
-	c.setWithHash(key, value, expirySec, h)
+	return c.setWithHash(key, value, expirySec, h)
 }
 
-// updateEntry updates an existing entry's value and frequency counters.
-func (*s3fifo[K, V]) updateEntry(ent *entry[K, V], value V, expirySec uint32) {
-	ent.storeValue(value)
+// updateEntry updates an existing entry's value, or marks it a cached miss, and unless
+// writeNoBump, its frequency counters.
+func (c *s3fifo[K, V]) updateEntry(ent *entry[K, V], value V, expirySec uint32, miss bool) {
+	if miss {
+		ent.storeMiss()
+	} else {
+		ent.storeValue(value)
+	}
 	ent.expirySec.Store(expirySec)
+	if c.writeNoBump {
+		return
+	}
 	// Hot path: single Load to check if counters need increment.
 	flags := ent.freqFlags.Load()
-	if flags&freqMask < maxFreq {
-		ent.incFreq(maxFreq)
+	if flags&freqMask < c.maxFreq {
+		ent.incFreq(c.maxFreq)
 	}
 	if (flags>>peakFreqShift)&peakFreqMask < maxPeakFreq {
 		ent.incPeakFreq(maxPeakFreq)
@@ -577,21 +843,202 @@ func (*s3fifo[K, V]) updateEntry(ent *entry[K, V], value V, expirySec uint32) {
 // setWithHash adds or updates a value. hash=0 means compute when needed.
 //
 // NOTE: Uses manual unlock instead of defer for -5% throughput improvement on hot path.
-func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64) {
-	// Fast path: lock-free update for existing entries.
-	if ent, exists := c.entries.Load(key); exists {
-		c.updateEntry(ent, value, expirySec)
-		return
+func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64) bool {
+	// Fast path: lock-free update for existing entries. Cost accounting needs the old
+	// value, so with MaxCost updates take the lock.
+	if ent, exists := c.entries.Load(key); exists && c.cost == nil {
+		c.updateEntry(ent, value, expirySec, false)
+		if c.policy == PolicyLRU {
+			c.touch(key, ent)
+		}
+		return true
 	}
 
 	// Slow path: need lock for new entry insertion.
-	c.mu.Lock()
+	c.lock()
+	ok := c.setLocked(key, value, expirySec, hash)
+	c.mu.Unlock()
+	return ok
+}
+
+// keyTooLong reports whether key is a string longer than maxKeyBytes.
+func (c *s3fifo[K, V]) keyTooLong(key K) bool {
+	return c.maxKeyBytes > 0 && c.keyIsString && len(*(*string)(unsafe.Pointer(&key))) > c.maxKeyBytes
+}
+
+// rejectKey reports whether a new key fails MaxKeyBytes or the KeyValidator, counting
+// it in rejectedKeys if so.
+func (c *s3fifo[K, V]) rejectKey(key K) bool {
+	if c.keyTooLong(key) || (c.validateKey != nil && c.validateKey(key) != nil) {
+		c.rejectedKeys.Add(1)
+		return true
+	}
+	return false
+}
+
+// setIfAbsent inserts key unless a live entry exists. Expired entries are replaced.
+// Returns true if the value was stored.
+func (c *s3fifo[K, V]) setIfAbsent(key K, value V, expirySec uint32) bool {
+	return c.setIfAbsentUnless(key, value, expirySec, nil)
+}
+
+// setIfAbsentUnless is setIfAbsent that also gives up if skip, checked under the lock,
+// reports true. A nil skip never gives up.
+func (c *s3fifo[K, V]) setIfAbsentUnless(key K, value V, expirySec uint32, skip func() bool) bool {
+	if c.rejectKey(key) {
+		return false
+	}
+	// Lock-free check first so concurrent callers only serialize on real inserts.
+	if c.liveEntry(key) {
+		return false
+	}
+
+	c.lock()
+	defer c.mu.Unlock()
+
+	if c.liveEntry(key) || (skip != nil && skip()) {
+		return false
+	}
+	return c.setLocked(key, value, expirySec, 0)
+}
+
+// getOrSet returns the live value for key, or stores value if there is none.
+// loaded reports whether the existing value was returned.
+func (c *s3fifo[K, V]) getOrSet(key K, value V, expirySec uint32) (actual V, loaded bool) {
+	// Lock-free check first so hits never take the write lock.
+	if v, ok := c.liveValue(key); ok {
+		return v, true
+	}
+
+	c.lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.liveValue(key); ok {
+		return v, true
+	}
+	c.setLocked(key, value, expirySec, 0)
+	return value, false
+}
+
+// liveValue returns key's value if it is present and unexpired, without counting an access.
+func (c *s3fifo[K, V]) liveValue(key K) (V, bool) {
+	ent, ok := c.entries.Load(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if exp := ent.expirySec.Load(); exp != 0 && c.nowSec() > exp {
+		var zero V
+		return zero, false
+	}
+	return ent.loadValue()
+}
+
+// liveEntry reports whether key is present and unexpired. Cached misses and entries on
+// death row count as absent.
+func (c *s3fifo[K, V]) liveEntry(key K) bool {
+	ent, ok := c.entries.Load(key)
+	if !ok || ent.onDeathRow() || ent.isMiss() {
+		return false
+	}
+	exp := ent.expirySec.Load()
+	return exp == 0 || c.nowSec() <= exp
+}
+
+// refreshExpiry sets a live entry's expiry in place, leaving its value untouched.
+// Returns false if key is absent, expired, on death row, or a cached miss, whose
+// lifetime is the miss TTL.
+func (c *s3fifo[K, V]) refreshExpiry(key K, expirySec uint32) bool {
+	c.lock()
+	defer c.mu.Unlock()
+
+	ent, ok := c.entries.Load(key)
+	if !ok || ent.onDeathRow() || ent.isMiss() {
+		return false
+	}
+	if exp := ent.expirySec.Load(); exp != 0 && c.nowSec() > exp {
+		return false
+	}
+	ent.expirySec.Store(expirySec)
+	return true
+}
+
+// compute replaces key's value with fn's result under the write lock. fn receives the
+// live value (zero if none) and whether there was one; returning store=false leaves
+// the cache unchanged. A live entry keeps its expiry; a new one gets expirySec.
+// Reports whether a value was stored.
+func (c *s3fifo[K, V]) compute(key K, expirySec uint32, fn func(old V, live bool) (V, bool)) bool {
+	c.lock()
+	defer c.mu.Unlock()
+
+	old, live := c.liveValue(key)
+	if live {
+		if ent, ok := c.entries.Load(key); ok {
+			expirySec = ent.expirySec.Load()
+		}
+	}
+	next, store := fn(old, live)
+	if !store {
+		return false
+	}
+	return c.setLocked(key, next, expirySec, 0)
+}
 
+// swap stores value and returns the previous live value, if any, under the write lock.
+func (c *s3fifo[K, V]) swap(key K, value V, expirySec uint32) (old V, had bool) {
+	c.lock()
+	defer c.mu.Unlock()
+
+	if ent, ok := c.entries.Load(key); ok {
+		if exp := ent.expirySec.Load(); exp == 0 || c.nowSec() <= exp {
+			old, had = ent.loadValue()
+		}
+	}
+	c.setLocked(key, value, expirySec, 0)
+	return old, had
+}
+
+// setMiss stores a cached miss for key. Returns false if the insert was refused.
+func (c *s3fifo[K, V]) setMiss(key K, expirySec uint32) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	var zero V
+	return c.storeLocked(key, zero, expirySec, 0, true)
+}
+
+// setLocked adds or updates a value. Caller must hold c.mu.
+// Returns false if the insert was refused by rejectKey or rejectWhenFull.
+func (c *s3fifo[K, V]) setLocked(key K, value V, expirySec uint32, hash uint64) bool {
+	return c.storeLocked(key, value, expirySec, hash, false)
+}
+
+// storeLocked is setLocked that stores a cached miss instead of value if miss is set.
+func (c *s3fifo[K, V]) storeLocked(key K, value V, expirySec uint32, hash uint64, miss bool) bool {
 	// Double-check after acquiring lock.
 	if ent, exists := c.entries.Load(key); exists {
-		c.updateEntry(ent, value, expirySec)
-		c.mu.Unlock()
-		return
+		if c.cost != nil && !ent.onDeathRow() {
+			old, _ := ent.loadValue()
+			c.totalCost.Add(c.cost(value) - c.cost(old))
+		}
+		c.updateEntry(ent, value, expirySec, miss)
+		if c.policy == PolicyLRU && c.main.tail != ent {
+			c.main.remove(ent)
+			c.main.pushBack(ent)
+		}
+		c.evictForCost(0)
+		return true
+	}
+
+	if c.rejectKey(key) || c.disabled {
+		return false
+	}
+	var cost int64
+	if c.cost != nil {
+		cost = c.cost(value)
+	}
+	if cost > c.maxCost || (c.rejectWhenFull && c.full(cost)) {
+		c.rejectedFull.Add(1)
+		return false
 	}
 
 	// Allocate-first: reuse recycled entry or allocate new one.
@@ -599,11 +1046,16 @@ func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64
 	if ent != nil {
 		c.freeEntry = nil
 		ent.key = key
-		ent.freqFlags.Store(0) // clears freq, peakFreq, inSmall, onDeathRow
+		// Clears freq, peakFreq, inSmall and onDeathRow; bumps gen.
+		ent.freqFlags.Store(((ent.gen() + 1) & genMask) << genShift)
 	} else {
 		ent = &entry[K, V]{key: key}
 	}
-	ent.storeValue(value)
+	if miss {
+		ent.storeMiss()
+	} else {
+		ent.storeValue(value)
+	}
 	ent.expirySec.Store(expirySec)
 
 	// Cache full hash for bloom filter (avoids re-hashing on eviction).
@@ -613,7 +1065,19 @@ func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64
 	}
 	ent.hash64 = h
 
-	full := c.totalEntries.Load() >= int64(c.capacity)
+	full := c.full(cost)
+
+	if c.policy != PolicyS3FIFO {
+		if full {
+			c.evictHead(c.evictBatch)
+			c.evictForCost(cost)
+		}
+		c.main.pushBack(ent)
+		c.entries.Store(key, ent)
+		c.totalEntries.Add(1)
+		c.totalCost.Add(cost)
+		return true
+	}
 
 	// During warmup, skip eviction logic.
 	if !c.warmupComplete && !full {
@@ -621,13 +1085,14 @@ func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64
 		c.small.pushBack(ent)
 		c.entries.Store(key, ent)
 		c.totalEntries.Add(1)
-		c.mu.Unlock()
-		return
+		c.totalCost.Add(cost)
+		return true
 	}
 	c.warmupComplete = true
 
 	// Only check ghost when full (saves bloom lookups during fill).
-	if full {
+	// Batch eviction leaves headroom after each pass, so check it on every insert there.
+	if full || c.evictBatch > 1 {
 		inGhost := c.ghostActive.Contains(h) || c.ghostAging.Contains(h)
 		ent.setInSmall(!inGhost)
 
@@ -638,12 +1103,15 @@ func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64
 				ent.setFreqPeak(peak, peak)
 			}
 		}
-
-		c.evictOne()
 	} else {
 		ent.setInSmall(true)
 	}
 
+	if full {
+		c.evictN(c.evictBatch)
+		c.evictForCost(cost)
+	}
+
 	if ent.inSmall() {
 		c.small.pushBack(ent)
 	} else {
@@ -652,18 +1120,79 @@ func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64
 
 	c.entries.Store(key, ent)
 	c.totalEntries.Add(1)
-	c.mu.Unlock()
+	c.totalCost.Add(cost)
+	return true
+}
+
+// full reports whether adding an entry of the given cost would exceed Size or MaxCost.
+func (c *s3fifo[K, V]) full(cost int64) bool {
+	return c.totalEntries.Load() >= int64(c.capacity) ||
+		(c.cost != nil && c.totalCost.Load()+cost > c.maxCost)
+}
+
+// chargeCost adds sign times ent's cost to totalCost when MaxCost is set.
+// Caller must hold c.mu.
+func (c *s3fifo[K, V]) chargeCost(ent *entry[K, V], sign int64) {
+	if c.cost == nil {
+		return
+	}
+	v, _ := ent.loadValue()
+	c.totalCost.Add(sign * c.cost(v))
+}
+
+// evictForCost evicts until an entry of cost extra fits within MaxCost, or nothing is
+// left to evict. Caller must hold c.mu.
+func (c *s3fifo[K, V]) evictForCost(extra int64) {
+	if c.cost == nil {
+		return
+	}
+	for c.totalCost.Load()+extra > c.maxCost && c.small.len+c.main.len > 0 {
+		if c.policy != PolicyS3FIFO {
+			c.evictHead(1)
+		} else {
+			c.evictOne()
+		}
+	}
 }
 
 func (c *s3fifo[K, V]) del(key K) {
-	c.mu.Lock()
+	c.lock()
 	defer c.mu.Unlock()
+	c.delLocked(key)
+}
 
+// delIf removes key only if it still maps to ent at generation gen. Returns true if removed.
+func (c *s3fifo[K, V]) delIf(key K, ent *entry[K, V], gen uint32) bool {
+	c.lock()
+	defer c.mu.Unlock()
+	if cur, ok := c.entries.Load(key); !ok || cur != ent || ent.gen() != gen {
+		return false
+	}
+	c.delLocked(key)
+	return true
+}
+
+// delLocked removes key. Caller must hold c.mu.
+func (c *s3fifo[K, V]) delLocked(key K) {
 	ent, ok := c.entries.Load(key)
 	if !ok {
 		return
 	}
 
+	// Death row entries are already unlinked and uncounted; just free the slot.
+	if ent.onDeathRow() {
+		for i := range c.deathRow {
+			if c.deathRow[i] == ent {
+				c.deathRow[i] = nil
+				break
+			}
+		}
+		ent.setOnDeathRow(false)
+		c.entries.Delete(key)
+		c.explicitDeletes.Add(1)
+		return
+	}
+
 	if ent.inSmall() {
 		c.small.remove(ent)
 	} else {
@@ -672,6 +1201,78 @@ func (c *s3fifo[K, V]) del(key K) {
 
 	c.entries.Delete(key)
 	c.totalEntries.Add(-1)
+	c.chargeCost(ent, -1)
+	c.explicitDeletes.Add(1)
+}
+
+// deleteKeys removes every entry whose key satisfies match, including entries on
+// death row, and returns how many it removed.
+func (c *s3fifo[K, V]) deleteKeys(match func(K) bool) int {
+	c.lock()
+	defer c.mu.Unlock()
+
+	var keys []K
+	c.entries.Range(func(key K, _ *entry[K, V]) bool {
+		if match(key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	for _, k := range keys {
+		c.delLocked(k)
+	}
+	return len(keys)
+}
+
+// updateMulti runs fn with the live values for keys and applies its result, all under
+// a single hold of the write lock. Keys from the input that are absent from the result
+// are deleted; result keys not listed in keys are ignored.
+func (c *s3fifo[K, V]) updateMulti(keys []K, fn func(map[K]V) map[K]V, expirySec uint32) {
+	c.lock()
+	defer c.mu.Unlock()
+
+	now := c.nowSec()
+	cur := make(map[K]V, len(keys))
+	for _, k := range keys {
+		ent, ok := c.entries.Load(k)
+		if !ok {
+			continue
+		}
+		if exp := ent.expirySec.Load(); exp != 0 && now > exp {
+			continue
+		}
+		if v, ok := ent.loadValue(); ok {
+			cur[k] = v
+		}
+	}
+
+	next := fn(cur)
+
+	for _, k := range keys {
+		v, ok := next[k]
+		if !ok {
+			c.delLocked(k)
+			continue
+		}
+		var h uint64
+		if c.strHash {
+			h = hashString(*(*string)(unsafe.Pointer(&k)))
+		}
+		c.setLocked(k, v, expirySec, h)
+	}
+}
+
+// setMany adds or updates items under a single write-lock acquisition.
+func (c *s3fifo[K, V]) setMany(items map[K]V, expirySec uint32) {
+	c.lock()
+	defer c.mu.Unlock()
+	for k, v := range items {
+		var h uint64
+		if c.strHash {
+			h = hashString(*(*string)(unsafe.Pointer(&k)))
+		}
+		c.setLocked(k, v, expirySec, h)
+	}
 }
 
 // addToGhost records an evicted key's hash for future admission decisions.
@@ -705,6 +1306,17 @@ func (c *s3fifo[K, V]) evictOne() {
 	}
 }
 
+// evictN evicts up to n entries, stopping early if the queues run dry.
+// Reclaiming several entries per pass amortizes eviction over fewer lock-held inserts.
+func (c *s3fifo[K, V]) evictN(n int) {
+	for range n {
+		if c.small.len+c.main.len == 0 {
+			return
+		}
+		c.evictOne()
+	}
+}
+
 // evictFromSmall evicts cold entries (freq<2) or promotes warm ones to main.
 // Returns true if an entry was actually evicted.
 func (c *s3fifo[K, V]) evictFromSmall() bool {
@@ -787,6 +1399,15 @@ func (c *s3fifo[K, V]) sampleAvgPeakFreq() uint32 {
 	return (sum + count - 1) / count
 }
 
+// countEviction attributes the final removal of e to TTL expiry or to capacity pressure.
+func (c *s3fifo[K, V]) countEviction(e *entry[K, V]) {
+	if exp := e.expirySec.Load(); exp != 0 && c.nowSec() > exp {
+		c.expiredEvictions.Add(1)
+		return
+	}
+	c.capacityEvictions.Add(1)
+}
+
 // sendToDeathRow puts an entry on death row for potential resurrection.
 // If death row is full, the oldest pending entry is truly evicted.
 func (c *s3fifo[K, V]) sendToDeathRow(e *entry[K, V]) {
@@ -797,16 +1418,19 @@ func (c *s3fifo[K, V]) sendToDeathRow(e *entry[K, V]) {
 		threshold = 1
 	}
 	if e.peakFreq() < threshold {
+		c.countEviction(e)
 		c.entries.Delete(e.key)
 		c.addToGhost(e.hash64, e.peakFreq())
 		e.prev, e.next = nil, nil
 		c.freeEntry = e
 		c.totalEntries.Add(-1)
+		c.chargeCost(e, -1)
 		return
 	}
 
 	// If death row slot is occupied, truly evict that entry first.
 	if old := c.deathRow[c.deathRowPos]; old != nil {
+		c.countEviction(old)
 		c.entries.Delete(old.key)
 		c.addToGhost(old.hash64, old.peakFreq())
 		old.setOnDeathRow(false)
@@ -819,6 +1443,7 @@ func (c *s3fifo[K, V]) sendToDeathRow(e *entry[K, V]) {
 	c.deathRow[c.deathRowPos] = e
 	c.deathRowPos = (c.deathRowPos + 1) % len(c.deathRow)
 	c.totalEntries.Add(-1)
+	c.chargeCost(e, -1)
 }
 
 func (c *s3fifo[K, V]) len() int {
@@ -832,7 +1457,7 @@ func (c *s3fifo[K, V]) getEntry(key K) (*entry[K, V], bool) {
 }
 
 func (c *s3fifo[K, V]) flush() int {
-	c.mu.Lock()
+	c.lock()
 	defer c.mu.Unlock()
 
 	n := c.entries.Size()
@@ -845,5 +1470,50 @@ func (c *s3fifo[K, V]) flush() int {
 	clear(c.deathRow)
 	c.deathRowPos = 0
 	c.totalEntries.Store(0)
+	c.totalCost.Store(0)
+	return n
+}
+
+// flushVolatile removes every entry that has an expiry, leaving no-expiry entries and
+// their queue positions untouched. Returns count removed, including death row entries.
+func (c *s3fifo[K, V]) flushVolatile() int {
+	return c.removeWhere(func(exp uint32) bool { return exp != 0 })
+}
+
+// removeExpired removes every entry whose expiry has passed. Returns count removed,
+// including death row entries.
+func (c *s3fifo[K, V]) removeExpired() int {
+	now := c.nowSec()
+	return c.removeWhere(func(exp uint32) bool { return exp != 0 && exp < now })
+}
+
+// removeWhere removes every entry whose expirySec satisfies drop, leaving the queue
+// positions of the others untouched. Returns count removed, including death row entries.
+func (c *s3fifo[K, V]) removeWhere(drop func(expirySec uint32) bool) int {
+	c.lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, l := range []*entryList[K, V]{&c.small, &c.main} {
+		for e := l.head; e != nil; {
+			next := e.next
+			if drop(e.expirySec.Load()) {
+				l.remove(e)
+				c.entries.Delete(e.key)
+				c.totalEntries.Add(-1)
+				c.chargeCost(e, -1)
+				n++
+			}
+			e = next
+		}
+	}
+	for i, e := range c.deathRow {
+		if e != nil && drop(e.expirySec.Load()) {
+			e.setOnDeathRow(false)
+			c.entries.Delete(e.key)
+			c.deathRow[i] = nil
+			n++
+		}
+	}
 	return n
 }
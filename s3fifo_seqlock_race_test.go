@@ -119,3 +119,30 @@ func TestEntry_Seqlock_MultiWriter(t *testing.T) {
 // 1. Real caches store word-sized values (int, string) or pointers (*T)
 // 2. Storing large structs by value is an anti-pattern
 // 3. The seqlock will retry and eventually get a consistent read
+
+// TestS3FIFO_PolicyLRU_Concurrent checks queue bookkeeping under concurrent LRU touches.
+// Skipped under race detector because seqlock is a benign race.
+func TestS3FIFO_PolicyLRU_Concurrent(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100, policy: PolicyLRU})
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 2000 {
+				k := (i * (g + 1)) % 300
+				if i%3 == 0 {
+					cache.set(k, k, 0)
+				} else {
+					cache.get(k)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if n := cache.len(); n > 100 {
+		t.Errorf("len() = %d; want <= 100", n)
+	}
+	if cache.main.len != cache.len() {
+		t.Errorf("main.len = %d, len() = %d; queue and count diverged", cache.main.len, cache.len())
+	}
+}
@@ -2125,6 +2125,28 @@ func TestS3FIFO_DefaultHasher_Uint64(t *testing.T) {
 	}
 }
 
+// TestS3FIFO_Hasher_Int32Uint32 checks that 32-bit integer keys take the integer
+// hashing fast path rather than the fmt.Sprintf fallback.
+func TestS3FIFO_Hasher_Int32Uint32(t *testing.T) {
+	c32 := newS3FIFO[int32, int](&config{size: 100})
+	for _, k := range []int32{0, 1, -1, 1<<31 - 1, -1 << 31} {
+		if got, want := c32.hasher(k), hashInt64(int64(k)); got != want {
+			t.Errorf("int32 hasher(%d) = %d; want %d", k, got, want)
+		}
+	}
+	cu32 := newS3FIFO[uint32, int](&config{size: 100})
+	for _, k := range []uint32{0, 1, 1<<32 - 1} {
+		if got, want := cu32.hasher(k), hashInt64(int64(k)); got != want {
+			t.Errorf("uint32 hasher(%d) = %d; want %d", k, got, want)
+		}
+	}
+
+	c32.set(-7, 70, 0)
+	if v, ok := c32.get(-7); !ok || v != 70 {
+		t.Errorf("get(-7) = %d, %v; want 70, true", v, ok)
+	}
+}
+
 // TestS3FIFO_EvictFromSmall_PromotionTriggersMainEviction tests the cascade path.
 func TestS3FIFO_EvictFromSmall_PromotionTriggersMainEviction(t *testing.T) {
 	// Use small cache where main queue capacity (10%) is easily exceeded
@@ -2963,3 +2985,245 @@ func TestGhostSize(t *testing.T) {
 		}
 	}
 }
+
+func TestS3FIFO_LazyMapGrowth(t *testing.T) {
+	eager := newS3FIFO[int, int](&config{size: 100000})
+	lazy := newS3FIFO[int, int](&config{size: 100000, lazyMapGrowth: true})
+
+	eagerBuckets := eager.entries.Stats().RootBuckets
+	lazyBuckets := lazy.entries.Stats().RootBuckets
+	if lazyBuckets >= eagerBuckets {
+		t.Errorf("lazy root buckets = %d; want fewer than eager (%d)", lazyBuckets, eagerBuckets)
+	}
+
+	// Map must still grow to hold a full cache.
+	for i := range 100000 {
+		lazy.set(i, i, 0)
+	}
+	if lazy.len() != 100000 {
+		t.Errorf("len = %d; want 100000", lazy.len())
+	}
+	for i := range 100000 {
+		if v, ok := lazy.get(i); !ok || v != i {
+			t.Fatalf("get(%d) = %d, %v; want %d, true", i, v, ok, i)
+		}
+	}
+}
+
+func TestS3FIFO_Presize(t *testing.T) {
+	eager := newS3FIFO[int, int](&config{size: 100000})
+	presized := newS3FIFO[int, int](&config{size: 1000, presize: 100000, lazyMapGrowth: true})
+	if got, want := presized.entries.Stats().RootBuckets, eager.entries.Stats().RootBuckets; got != want {
+		t.Errorf("Presize(100000) root buckets = %d; want %d, as for Size(100000)", got, want)
+	}
+
+	def := newS3FIFO[int, int](&config{size: 1000}).entries.Stats().RootBuckets
+	if got := newS3FIFO[int, int](&config{size: 1000, presize: -1}).entries.Stats().RootBuckets; got != def {
+		t.Errorf("negative presize: root buckets = %d; want default %d", got, def)
+	}
+}
+
+func TestS3FIFO_DeleteDeathRowEntry(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100})
+	for i := range 10 {
+		cache.set(i, i, 0)
+	}
+
+	// Move key 0 onto death row directly.
+	cache.mu.Lock()
+	ent, _ := cache.getEntry(0)
+	cache.small.remove(ent)
+	ent.setFreqPeak(0, maxPeakFreq)
+	cache.sendToDeathRow(ent)
+	cache.mu.Unlock()
+	if !ent.onDeathRow() {
+		t.Fatal("key 0 should be on death row")
+	}
+
+	smallLen := cache.small.len
+	cache.del(0)
+
+	if _, ok := cache.getEntry(0); ok {
+		t.Error("deleted death row entry still in map")
+	}
+	if cache.len() != 9 {
+		t.Errorf("len = %d; want 9 (death row entries are not counted)", cache.len())
+	}
+	if cache.small.len != smallLen || cache.small.head == nil {
+		t.Error("deleting a death row entry must not touch the queues")
+	}
+	for _, e := range cache.deathRow {
+		if e == ent {
+			t.Error("death row still references deleted entry")
+		}
+	}
+}
+
+func TestS3FIFO_ContentionStats(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100, contentionStats: true})
+
+	cache.set(1, 1, 0)
+	if got := cache.stats().LockContentions; got != 0 {
+		t.Fatalf("LockContentions = %d; want 0 without contention", got)
+	}
+
+	// Hold the lock so an insert must wait for it.
+	cache.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		cache.set(2, 2, 0)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cache.mu.Unlock()
+	<-done
+
+	if got := cache.stats().LockContentions; got != 1 {
+		t.Errorf("LockContentions = %d; want 1", got)
+	}
+}
+
+func TestS3FIFO_EvictBatch(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 1000, evictBatch: 50})
+	for i := range 1000 {
+		cache.set(i, i, 0)
+	}
+
+	// First insert at capacity reclaims a whole batch.
+	cache.set(1000, 1000, 0)
+	if got := cache.len(); got > 1000-50+1 {
+		t.Errorf("len after batch eviction = %d; want <= %d", got, 1000-50+1)
+	}
+
+	// Subsequent inserts fill the headroom without evicting.
+	before := cache.len()
+	for i := 1001; i < 1011; i++ {
+		cache.set(i, i, 0)
+	}
+	if got := cache.len(); got != before+10 {
+		t.Errorf("len = %d; want %d (no eviction while below capacity)", got, before+10)
+	}
+
+	// Capacity is never exceeded over a long run.
+	for i := 2000; i < 20000; i++ {
+		cache.set(i, i, 0)
+		if cache.len() > 1000 {
+			t.Fatalf("len = %d exceeds capacity", cache.len())
+		}
+	}
+}
+
+func TestS3FIFO_EvictBatchClamped(t *testing.T) {
+	if c := newS3FIFO[int, int](&config{size: 10, evictBatch: 100}); c.evictBatch != 10 {
+		t.Errorf("evictBatch = %d; want clamped to capacity 10", c.evictBatch)
+	}
+	if c := newS3FIFO[int, int](&config{size: 10, evictBatch: -5}); c.evictBatch != 1 {
+		t.Errorf("evictBatch = %d; want 1 for non-positive input", c.evictBatch)
+	}
+}
+
+func BenchmarkS3FIFO_SetEvictBatch(b *testing.B) {
+	for _, n := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("batch=%d", n), func(b *testing.B) {
+			cache := newS3FIFO[int, int](&config{size: 10000, evictBatch: n})
+			for i := range 10000 {
+				cache.set(i, i, 0)
+			}
+			b.ResetTimer()
+
+			for i := range b.N {
+				cache.set(10000+i, i, 0)
+			}
+		})
+	}
+}
+
+func TestS3FIFO_PolicyLRU(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 10, policy: PolicyLRU})
+	for i := range 10 {
+		cache.set(i, i, 0)
+	}
+
+	// Read 0 and rewrite 1 so they become most recent; 2 is now least recent.
+	cache.get(0)
+	cache.set(1, 100, 0)
+	cache.set(10, 10, 0)
+	cache.set(11, 11, 0)
+
+	for _, k := range []int{2, 3} {
+		if _, ok := cache.get(k); ok {
+			t.Errorf("key %d should have been evicted as least recently used", k)
+		}
+	}
+	for _, k := range []int{0, 1, 4, 10, 11} {
+		if _, ok := cache.get(k); !ok {
+			t.Errorf("key %d should still be cached", k)
+		}
+	}
+	if cache.len() != 10 {
+		t.Errorf("len() = %d; want 10", cache.len())
+	}
+}
+
+func TestS3FIFO_PolicyFIFO(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 10, policy: PolicyFIFO})
+	for i := range 10 {
+		cache.set(i, i, 0)
+	}
+
+	// Access does not protect entries under FIFO.
+	for range 5 {
+		cache.get(0)
+	}
+	cache.set(10, 10, 0)
+
+	if _, ok := cache.get(0); ok {
+		t.Error("key 0 should have been evicted first despite being accessed")
+	}
+	if _, ok := cache.get(1); !ok {
+		t.Error("key 1 should still be cached")
+	}
+
+	// Deletes unlink from the queue so eviction order stays intact.
+	cache.del(1)
+	cache.set(11, 11, 0)
+	cache.set(12, 12, 0)
+	if _, ok := cache.get(2); ok {
+		t.Error("key 2 should be evicted after the deleted key 1 freed one slot")
+	}
+	if cache.len() != 10 {
+		t.Errorf("len() = %d; want 10", cache.len())
+	}
+}
+
+func TestS3FIFO_MaxCostAccounting(t *testing.T) {
+	cfg := &config{size: 500, maxCost: 5000, costFn: func(v int) int64 { return int64(v) }}
+	cache := newS3FIFO[int, int](cfg)
+	for i := range 5000 {
+		k := i % 700
+		switch i % 7 {
+		case 0:
+			cache.del(k)
+		case 1:
+			cache.get(k)
+		default:
+			cache.set(k, i%97+1, 0)
+		}
+	}
+
+	var sum int64
+	cache.mu.Lock()
+	for _, l := range []*entryList[int, int]{&cache.small, &cache.main} {
+		for e := l.head; e != nil; e = e.next {
+			v, _ := e.loadValue()
+			sum += int64(v)
+		}
+	}
+	cache.mu.Unlock()
+	if got := cache.totalCost.Load(); got != sum {
+		t.Errorf("totalCost = %d; want %d (sum over queued entries)", got, sum)
+	}
+	if sum > cfg.maxCost {
+		t.Errorf("totalCost = %d; want <= %d", sum, cfg.maxCost)
+	}
+}
@@ -0,0 +1,38 @@
+package fido
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// latencySampler times a random fraction of operations and reports them to sink.
+//
+// The sampling decision uses math/rand/v2's top-level generator, which is backed by
+// per-thread runtime state: there is no shared counter or lock to contend on, so the
+// cost of an unsampled call is one random draw and a compare.
+type latencySampler struct {
+	sink      func(op string, d time.Duration)
+	threshold uint64 // sample when a random uint64 falls below this
+}
+
+// newLatencySampler returns nil when sampling is disabled.
+func newLatencySampler(rate float64, sink func(string, time.Duration)) *latencySampler {
+	if rate <= 0 || sink == nil {
+		return nil
+	}
+	threshold := uint64(math.MaxUint64)
+	if rate < 1 {
+		threshold = uint64(rate * math.MaxUint64)
+	}
+	return &latencySampler{sink: sink, threshold: threshold}
+}
+
+func (s *latencySampler) sample() bool {
+	return s != nil && rand.Uint64() < s.threshold
+}
+
+// record reports the time since start. Intended for defer.
+func (s *latencySampler) record(op string, start time.Time) {
+	s.sink(op, time.Since(start))
+}
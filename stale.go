@@ -0,0 +1,68 @@
+package fido
+
+import (
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// softIndex records when entries stored by FetchStale turn stale. A record applies
+// only while its key still maps to the same entry, generation and expiry, so an entry
+// that was since rewritten, touched or recycled is not judged by it.
+type softIndex[K comparable, V any] struct {
+	byKey *xsync.Map[K, softDeadline[K, V]]
+}
+
+type softDeadline[K comparable, V any] struct {
+	ent       *entry[K, V]
+	gen       uint32 // ent's generation when recorded
+	expirySec uint32 // ent's expiry when recorded
+	staleSec  uint32 // when ent turns stale, on the cache's expiry clock
+}
+
+func newSoftIndex[K comparable, V any]() *softIndex[K, V] {
+	return &softIndex[K, V]{byKey: xsync.NewMap[K, softDeadline[K, V]]()}
+}
+
+// record marks key's current entry stale once softTTL has passed.
+func (s *softIndex[K, V]) record(c *s3fifo[K, V], key K, softTTL time.Duration) {
+	ent, ok := c.entries.Load(key)
+	if !ok {
+		return // evicted already
+	}
+	s.byKey.Store(key, softDeadline[K, V]{
+		ent:       ent,
+		gen:       ent.gen(),
+		expirySec: ent.expirySec.Load(),
+		staleSec:  c.toSec(time.Now().Add(softTTL)),
+	})
+	s.prune(c)
+}
+
+// stale reports whether key's current entry is past the soft deadline recorded for it.
+// Entries without a matching record are never stale.
+func (s *softIndex[K, V]) stale(c *s3fifo[K, V], key K) bool {
+	sd, ok := s.byKey.Load(key)
+	if !ok {
+		return false
+	}
+	ent, ok := c.entries.Load(key)
+	if !ok || ent != sd.ent || ent.gen() != sd.gen || ent.expirySec.Load() != sd.expirySec {
+		return false
+	}
+	return c.nowSec() >= sd.staleSec
+}
+
+// prune drops records whose entry is gone, once the index has grown well past the
+// cache's capacity. Evictions do not notify the index, so this bounds it.
+func (s *softIndex[K, V]) prune(c *s3fifo[K, V]) {
+	if s.byKey.Size() <= 2*c.capacity {
+		return
+	}
+	s.byKey.Range(func(key K, sd softDeadline[K, V]) bool {
+		if cur, ok := c.entries.Load(key); !ok || cur != sd.ent || cur.gen() != sd.gen {
+			s.byKey.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,84 @@
+package fido
+
+// Stats is a point-in-time snapshot of cache counters.
+// Queue sizes are read together under one lock hold; counters are read individually,
+// so a snapshot taken under load may be slightly skewed between the two groups.
+type Stats struct {
+	Len  int   // live entries in memory
+	Cost int64 // summed Cost of live entries; 0 unless MaxCost is set
+
+	// Queue occupancy. SmallLen+MainLen equals Len.
+	SmallLen     int // entries in the probationary small queue (S3-FIFO only)
+	MainLen      int // entries in the main queue
+	DeathRowLen  int // evicted entries still held for resurrection
+	GhostEntries int // recently evicted key hashes tracked by the ghost filters
+	GhostBytes   int // memory held by the ghost filters; see GhostMemoryBudget
+
+	// Ghost filter saturation. The active filter rotates out when GhostFill reaches 1.
+	// A GhostFPRate far above the configured rate (1e-5 by default) means new keys are
+	// often mistaken for returning ones and skip probation; see GhostMemoryBudget.
+	GhostFill   float64 // active filter entries as a fraction of its rotation threshold
+	GhostFPRate float64 // estimated chance a never-seen key is reported as a ghost
+
+	// Removals by cause. Entries parked on death row are counted once they are finally
+	// dropped; a resurrected entry is not counted at all.
+	CapacityEvictions int64 // unexpired entries evicted to make room
+	ExpiredEvictions  int64 // entries evicted to make room that had already expired
+	ExplicitDeletes   int64 // entries removed by Delete, UpdateMulti or InvalidateTag
+
+	// Fetch deduplication. FetchMany counts one loader call per batch and one coalesced
+	// call per key it waits on.
+	LoaderCalls    int64 // loader invocations by Fetch and its variants
+	CoalescedCalls int64 // Fetch calls served by waiting on a concurrent load of the same key
+
+	// Lookups. Hits and Misses count memory lookups by every read path, including Fetch
+	// and TieredCache reads, and are 0 unless HitStats is set. StoreHits and StoreMisses
+	// count TieredCache memory misses by whether the store had the key, so
+	// Hits/(Hits+Misses) shows how well Size fits the working set.
+	Hits        int64 // memory lookups that found a live entry
+	Misses      int64 // memory lookups that found nothing or an expired entry
+	StoreHits   int64 // memory misses served by the store (TieredCache only)
+	StoreMisses int64 // memory misses the store didn't have either (TieredCache only)
+
+	LockContentions int64 // write-lock acquisitions that had to wait; 0 unless ContentionStats is set
+	DecodeErrors    int64 // undecodable store entries treated as misses (TieredCache only)
+	AsyncDropped    int64 // SetAsync store writes dropped without being queued (TieredCache only)
+	RejectedKeys    int64 // inserts dropped by MaxKeyBytes or KeyValidator
+	RejectedFull    int64 // inserts refused by RejectWhenFull, or costing more than MaxCost
+}
+
+func (c *s3fifo[K, V]) stats() Stats {
+	t := c.mu.RLock()
+	st := Stats{
+		Len:          c.len(),
+		SmallLen:     c.small.len,
+		MainLen:      c.main.len,
+		GhostEntries: c.ghostActive.entries + c.ghostAging.entries,
+		GhostBytes:   c.ghostActive.bytes() + c.ghostAging.bytes(),
+		GhostFill:    float64(c.ghostActive.entries) / float64(max(c.ghostCap, 1)),
+	}
+	// A lookup checks both filters, so it is a false positive if either is.
+	st.GhostFPRate = 1 - (1-c.ghostActive.fpRate())*(1-c.ghostAging.fpRate())
+	for _, e := range c.deathRow {
+		if e != nil {
+			st.DeathRowLen++
+		}
+	}
+	c.mu.RUnlock(t)
+
+	st.CapacityEvictions = c.capacityEvictions.Load()
+	st.ExpiredEvictions = c.expiredEvictions.Load()
+	st.ExplicitDeletes = c.explicitDeletes.Load()
+	st.RejectedKeys = c.rejectedKeys.Load()
+	st.RejectedFull = c.rejectedFull.Load()
+	st.Cost = c.totalCost.Load()
+	st.LoaderCalls = c.loaderCalls.Load()
+	st.CoalescedCalls = c.coalescedCalls.Load()
+	if c.contentions != nil {
+		st.LockContentions = c.contentions.Value()
+	}
+	if c.hits != nil {
+		st.Hits, st.Misses = c.hits.Value(), c.misses.Value()
+	}
+	return st
+}
@@ -2,11 +2,21 @@ package fido
 
 import (
 	"context"
+	"errors"
 	"iter"
+	"strings"
 	"time"
 )
 
 // Store is the persistence backend interface.
+//
+// Get returns the entry's absolute expiry time (zero for none). Stores with native
+// TTLs convert the remaining TTL to an absolute time at read time, so a reloaded
+// entry keeps exactly the lifetime it has left in the store.
+//
+// Get should report corrupt or undecodable entries with an error that has a
+// DecodeFailure() bool method returning true (see compress.DecodeError), so
+// TieredCache can treat them as misses.
 type Store[K comparable, V any] interface {
 	ValidateKey(key K) error
 	Get(ctx context.Context, key K) (V, time.Time, bool, error)
@@ -29,3 +39,85 @@ type PrefixScanner[V any] interface {
 	// More expensive than Keys: loads and decodes values from storage.
 	Range(ctx context.Context, prefix string) iter.Seq2[string, V]
 }
+
+// HealthChecker is an optional interface for stores that can verify their backend is
+// reachable without touching cache data. TieredCache.Ping delegates to it.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+}
+
+// RecentLoader is an optional interface for stores that can list their most recently
+// updated entries. TieredCache uses it for warmup.
+type RecentLoader[K comparable, V any] interface {
+	// LoadRecent calls fn for up to limit non-expired entries, most recently updated first.
+	// A limit <= 0 loads every entry. Iteration stops early if fn returns false.
+	LoadRecent(ctx context.Context, limit int, fn func(key K, value V, expiry time.Time) bool) error
+}
+
+// FrequencyLoader is an optional interface for stores that track how often entries
+// are read. TieredCache uses it for warmup with WarmupByFrequency. The sqlite store
+// implements it by counting Get hits.
+type FrequencyLoader[K comparable, V any] interface {
+	// LoadByFrequency calls fn for up to limit non-expired entries, most frequently read
+	// first. A limit <= 0 loads every entry. Iteration stops early if fn returns false.
+	LoadByFrequency(ctx context.Context, limit int, fn func(key K, value V, expiry time.Time) bool) error
+}
+
+// CapabilitySet is a bitset of the optional interfaces a store implements.
+type CapabilitySet uint32
+
+// Store capabilities reported by Capabilities.
+const (
+	CapPrefixScan    CapabilitySet = 1 << iota // PrefixScanner
+	CapHealthCheck                             // HealthChecker
+	CapRecentLoad                              // RecentLoader
+	CapFrequencyLoad                           // FrequencyLoader
+)
+
+var capabilityNames = []string{"PrefixScan", "HealthCheck", "RecentLoad", "FrequencyLoad"}
+
+// Capabilities reports which optional interfaces store implements.
+func Capabilities[K comparable, V any](store Store[K, V]) CapabilitySet {
+	var cs CapabilitySet
+	if _, ok := store.(PrefixScanner[V]); ok {
+		cs |= CapPrefixScan
+	}
+	if _, ok := store.(HealthChecker); ok {
+		cs |= CapHealthCheck
+	}
+	if _, ok := store.(RecentLoader[K, V]); ok {
+		cs |= CapRecentLoad
+	}
+	if _, ok := store.(FrequencyLoader[K, V]); ok {
+		cs |= CapFrequencyLoad
+	}
+	return cs
+}
+
+// Has reports whether every capability in c is present.
+func (s CapabilitySet) Has(c CapabilitySet) bool { return s&c == c }
+
+// String lists the capability names joined by "|", or "none".
+func (s CapabilitySet) String() string {
+	var names []string
+	for i, name := range capabilityNames {
+		if s&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// decodeFailure is implemented by store errors caused by undecodable stored data.
+type decodeFailure interface {
+	DecodeFailure() bool
+}
+
+// isDecodeError reports whether err (or any error it wraps) is a store decode failure.
+func isDecodeError(err error) bool {
+	var df decodeFailure
+	return errors.As(err, &df) && df.DecodeFailure()
+}
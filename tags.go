@@ -0,0 +1,117 @@
+package fido
+
+import (
+	"sync"
+	"time"
+)
+
+// tagIndex maps tags to the entries they were attached to, for InvalidateTag.
+// Entries are tracked by pointer and generation, so a key that was deleted or evicted
+// and then re-added without tags is not invalidated by its old tags, even when the
+// new insert reuses the recycled entry.
+type tagIndex[K comparable, V any] struct {
+	byTag map[string]map[K]struct{}
+	byKey map[K]tagged[K, V]
+	mu    sync.Mutex
+}
+
+type tagged[K comparable, V any] struct {
+	ent  *entry[K, V]
+	tags []string
+	gen  uint32 // ent's generation when tagged
+}
+
+func newTagIndex[K comparable, V any]() *tagIndex[K, V] {
+	return &tagIndex[K, V]{
+		byTag: make(map[string]map[K]struct{}),
+		byKey: make(map[K]tagged[K, V]),
+	}
+}
+
+// attach replaces key's tags with tags, bound to ent.
+func (t *tagIndex[K, V]) attach(key K, ent *entry[K, V], tags []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.detachLocked(key)
+	for _, tag := range tags {
+		keys := t.byTag[tag]
+		if keys == nil {
+			keys = make(map[K]struct{})
+			t.byTag[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	t.byKey[key] = tagged[K, V]{ent: ent, tags: tags, gen: ent.gen()}
+}
+
+func (t *tagIndex[K, V]) detachLocked(key K) {
+	for _, tag := range t.byKey[key].tags {
+		delete(t.byTag[tag], key)
+		if len(t.byTag[tag]) == 0 {
+			delete(t.byTag, tag)
+		}
+	}
+	delete(t.byKey, key)
+}
+
+// take removes tag and returns the entries it was attached to.
+func (t *tagIndex[K, V]) take(tag string) map[K]tagged[K, V] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make(map[K]tagged[K, V], len(t.byTag[tag]))
+	for key := range t.byTag[tag] {
+		out[key] = t.byKey[key]
+		t.detachLocked(key)
+	}
+	return out
+}
+
+// prune drops index entries whose cache entry is gone, once the index has grown well
+// past the cache's capacity. Evictions do not notify the index, so this bounds it.
+func (t *tagIndex[K, V]) prune(c *s3fifo[K, V]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.byKey) <= 2*c.capacity {
+		return
+	}
+	for key, tk := range t.byKey {
+		if cur, ok := c.entries.Load(key); !ok || cur != tk.ent || cur.gen() != tk.gen {
+			t.detachLocked(key)
+		}
+	}
+}
+
+// SetWithTags stores a value under the default TTL and attaches tags to it, replacing
+// any tags the key already had. Tags survive plain Set updates to the key and stop
+// applying once the entry is deleted, evicted or invalidated.
+func (c *Cache[K, V]) SetWithTags(key K, value V, tags []string) {
+	c.SetWithTagsTTL(key, value, tags, c.defaultTTL)
+}
+
+// SetWithTagsTTL is like SetWithTags but with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) SetWithTagsTTL(key K, value V, tags []string, ttl time.Duration) {
+	if !c.TrySetTTL(key, value, ttl) {
+		return
+	}
+	ent, ok := c.memory.entries.Load(key)
+	if !ok {
+		return // evicted already
+	}
+	c.tags.attach(key, ent, append([]string(nil), tags...))
+	c.tags.prune(c.memory)
+}
+
+// InvalidateTag deletes every entry carrying tag. Returns the number removed.
+func (c *Cache[K, V]) InvalidateTag(tag string) int {
+	n := 0
+	for key, tk := range c.tags.take(tag) {
+		if c.memory.delIf(key, tk.ent, tk.gen) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,160 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// writeOp is a buffered store write: a Set, or a Delete if del is set.
+type writeOp[V any] struct {
+	expiry time.Time
+	value  V
+	del    bool
+}
+
+// writeBack buffers TieredCache store writes and flushes them from a background
+// goroutine, coalescing repeated writes to a key into the latest one (see WriteBack).
+//
+//nolint:govet // fieldalignment - mutexes kept next to the maps they guard
+type writeBack[K comparable, V any] struct {
+	store Store[K, V]
+
+	mu       sync.Mutex
+	dirty    map[K]writeOp[V] // written since the last flush began
+	inflight map[K]writeOp[V] // being written by the current flush
+
+	// flushMu serializes flushes, so writes to a key reach the store in order.
+	flushMu sync.Mutex
+
+	maxBatch  int
+	timeout   time.Duration // per-write store timeout (AsyncTimeout)
+	kick      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newWriteBack[K comparable, V any](store Store[K, V], interval time.Duration, maxBatch int, timeout time.Duration) *writeBack[K, V] {
+	w := &writeBack[K, V]{
+		store:    store,
+		dirty:    make(map[K]writeOp[V]),
+		maxBatch: maxBatch,
+		timeout:  timeout,
+		kick:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run(interval)
+	return w
+}
+
+// run flushes every interval, or when add signals a full batch, until close.
+func (w *writeBack[K, V]) run(interval time.Duration) {
+	defer close(w.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+		case <-w.kick:
+		}
+		w.flush() //nolint:errcheck // failures are logged and requeued
+	}
+}
+
+// add buffers op for key, replacing any write to key not yet flushed.
+func (w *writeBack[K, V]) add(key K, op writeOp[V]) {
+	w.mu.Lock()
+	w.dirty[key] = op
+	n := len(w.dirty)
+	w.mu.Unlock()
+	if w.maxBatch > 0 && n >= w.maxBatch {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// lookup returns the latest write to key that may not have reached the store yet.
+func (w *writeBack[K, V]) lookup(key K) (writeOp[V], bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if op, ok := w.dirty[key]; ok {
+		return op, true
+	}
+	op, ok := w.inflight[key]
+	return op, ok
+}
+
+// len returns the number of keys with writes not yet in the store.
+func (w *writeBack[K, V]) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.dirty) + len(w.inflight)
+}
+
+// flush writes every buffered write to the store. A failed write is logged and
+// requeued for the next flush, unless the key has been written again meanwhile.
+func (w *writeBack[K, V]) flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := w.dirty
+	w.inflight = batch
+	w.dirty = make(map[K]writeOp[V], len(batch))
+	w.mu.Unlock()
+
+	var errs []error
+	for key, op := range batch {
+		ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+		var err error
+		if op.del || (!op.expiry.IsZero() && !time.Now().Before(op.expiry)) {
+			// An expired write still replaces whatever the store holds for key.
+			err = w.store.Delete(ctx, key)
+		} else {
+			err = w.store.Set(ctx, key, op.value, op.expiry)
+		}
+		cancel()
+		if err == nil {
+			continue
+		}
+		slog.Error("write-back persistence failed", "key", key, "error", err)
+		errs = append(errs, fmt.Errorf("key %v: %w", key, err))
+		w.mu.Lock()
+		if _, ok := w.dirty[key]; !ok {
+			w.dirty[key] = op
+		}
+		w.mu.Unlock()
+	}
+
+	w.mu.Lock()
+	w.inflight = nil
+	w.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// discard drops every buffered write. The caller must hold flushMu.
+func (w *writeBack[K, V]) discard() {
+	w.mu.Lock()
+	w.dirty = make(map[K]writeOp[V])
+	w.mu.Unlock()
+}
+
+// close stops the background flusher and flushes what is left, once.
+func (w *writeBack[K, V]) close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+		w.closeErr = w.flush()
+	})
+	return w.closeErr
+}